@@ -0,0 +1,220 @@
+package account
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/jackc/pgx/v5"
+)
+
+const addressColumns = "id, account_id, type, is_default, name, line1, line2, city, region, postal_code, country, created_at, updated_at"
+
+func (repository *accountRepository) ListAddresses(ctx context.Context, accountID string) ([]Address, error) {
+	query := `
+        SELECT ` + addressColumns + `
+        FROM account_addresses
+        WHERE account_id = $1
+        ORDER BY type DESC, is_default DESC, created_at, id`
+	rows, err := repository.db.Query(ctx, query, accountID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list addresses: %w", err)
+	}
+	defer rows.Close()
+
+	addresses := []Address{}
+	for rows.Next() {
+		address, err := scanAddress(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan address row: %w", err)
+		}
+		addresses = append(addresses, address)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating over address rows: %w", err)
+	}
+	return addresses, nil
+}
+
+func (repository *accountRepository) CreateAddress(ctx context.Context, accountID string, input AddressInput) (Address, error) {
+	tx, err := repository.db.Begin(ctx)
+	if err != nil {
+		return Address{}, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	if err := lockAccount(ctx, tx, accountID); err != nil {
+		return Address{}, err
+	}
+
+	if input.IsDefault {
+		if err := clearDefaultAddress(ctx, tx, accountID, input.Type); err != nil {
+			return Address{}, err
+		}
+	}
+
+	var id string
+	query := `
+        INSERT INTO account_addresses (account_id, type, is_default, name, line1, line2, city, region, postal_code, country)
+        VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)
+        RETURNING id`
+	err = tx.QueryRow(ctx, query, accountID, input.Type, input.IsDefault, input.Name, input.Line1, input.Line2, input.City, input.Region, input.PostalCode, input.Country).Scan(&id)
+	if err != nil {
+		return Address{}, fmt.Errorf("failed to create address: %w", err)
+	}
+
+	return commitAddress(ctx, tx, accountID, id, input.Type)
+}
+
+func (repository *accountRepository) UpdateAddress(ctx context.Context, accountID, addressID string, input AddressInput) (Address, error) {
+	tx, err := repository.db.Begin(ctx)
+	if err != nil {
+		return Address{}, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	if err := lockAccount(ctx, tx, accountID); err != nil {
+		return Address{}, err
+	}
+
+	current, err := getAddress(ctx, tx, accountID, addressID)
+	if err != nil {
+		return Address{}, err
+	}
+
+	if input.IsDefault {
+		if err := clearDefaultAddress(ctx, tx, accountID, input.Type); err != nil {
+			return Address{}, err
+		}
+	}
+
+	query := `
+        UPDATE account_addresses
+        SET type = $3, is_default = $4, name = $5, line1 = $6, line2 = $7, city = $8, region = $9, postal_code = $10, country = $11
+        WHERE id = $1 AND account_id = $2`
+	_, err = tx.Exec(ctx, query, addressID, accountID, input.Type, input.IsDefault, input.Name, input.Line1, input.Line2, input.City, input.Region, input.PostalCode, input.Country)
+	if err != nil {
+		return Address{}, fmt.Errorf("failed to update address: %w", err)
+	}
+
+	if current.Type != input.Type {
+		if err := ensureDefaultAddress(ctx, tx, accountID, current.Type); err != nil {
+			return Address{}, err
+		}
+	}
+
+	return commitAddress(ctx, tx, accountID, addressID, input.Type)
+}
+
+// DeleteAddress promotes the oldest remaining address of the same type when
+// the default address is deleted.
+func (repository *accountRepository) DeleteAddress(ctx context.Context, accountID, addressID string) (Address, error) {
+	tx, err := repository.db.Begin(ctx)
+	if err != nil {
+		return Address{}, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	if err := lockAccount(ctx, tx, accountID); err != nil {
+		return Address{}, err
+	}
+
+	query := `
+        DELETE FROM account_addresses
+        WHERE id = $1 AND account_id = $2
+        RETURNING ` + addressColumns
+	address, err := scanAddress(tx.QueryRow(ctx, query, addressID, accountID))
+	if errors.Is(err, pgx.ErrNoRows) {
+		return Address{}, ErrAddressNotFound
+	}
+	if err != nil {
+		return Address{}, fmt.Errorf("failed to delete address: %w", err)
+	}
+
+	if err := ensureDefaultAddress(ctx, tx, accountID, address.Type); err != nil {
+		return Address{}, err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return Address{}, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return address, nil
+}
+
+// lockAccount serializes address changes of an account, so that the default
+// flags are never updated concurrently.
+func lockAccount(ctx context.Context, tx pgx.Tx, accountID string) error {
+	var id string
+	err := tx.QueryRow(ctx, "SELECT id FROM accounts WHERE id = $1 AND deleted_at IS NULL FOR UPDATE", accountID).Scan(&id)
+	if err != nil {
+		return fmt.Errorf("failed to lock account: %w", err)
+	}
+	return nil
+}
+
+func getAddress(ctx context.Context, tx pgx.Tx, accountID, addressID string) (Address, error) {
+	query := "SELECT " + addressColumns + " FROM account_addresses WHERE id = $1 AND account_id = $2"
+	address, err := scanAddress(tx.QueryRow(ctx, query, addressID, accountID))
+	if errors.Is(err, pgx.ErrNoRows) {
+		return Address{}, ErrAddressNotFound
+	}
+	if err != nil {
+		return Address{}, fmt.Errorf("failed to get address: %w", err)
+	}
+	return address, nil
+}
+
+func clearDefaultAddress(ctx context.Context, tx pgx.Tx, accountID string, addressType AddressType) error {
+	query := "UPDATE account_addresses SET is_default = FALSE WHERE account_id = $1 AND type = $2 AND is_default"
+	if _, err := tx.Exec(ctx, query, accountID, addressType); err != nil {
+		return fmt.Errorf("failed to clear default address: %w", err)
+	}
+	return nil
+}
+
+// ensureDefaultAddress makes the oldest address of the given type the default
+// when the account has addresses of that type but none of them is the
+// default.
+func ensureDefaultAddress(ctx context.Context, tx pgx.Tx, accountID string, addressType AddressType) error {
+	query := `
+        UPDATE account_addresses
+        SET is_default = TRUE
+        WHERE id = (
+            SELECT id FROM account_addresses
+            WHERE account_id = $1 AND type = $2
+            ORDER BY created_at, id
+            LIMIT 1
+        )
+        AND NOT EXISTS (
+            SELECT 1 FROM account_addresses
+            WHERE account_id = $1 AND type = $2 AND is_default
+        )`
+	if _, err := tx.Exec(ctx, query, accountID, addressType); err != nil {
+		return fmt.Errorf("failed to set default address: %w", err)
+	}
+	return nil
+}
+
+// commitAddress keeps a default address for the type of the written address
+// and commits the transaction, returning the address as stored.
+func commitAddress(ctx context.Context, tx pgx.Tx, accountID, addressID string, addressType AddressType) (Address, error) {
+	if err := ensureDefaultAddress(ctx, tx, accountID, addressType); err != nil {
+		return Address{}, err
+	}
+
+	address, err := getAddress(ctx, tx, accountID, addressID)
+	if err != nil {
+		return Address{}, err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return Address{}, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return address, nil
+}
+
+func scanAddress(row pgx.Row) (Address, error) {
+	var address Address
+	err := row.Scan(&address.ID, &address.AccountID, &address.Type, &address.IsDefault, &address.Name, &address.Line1, &address.Line2, &address.City, &address.Region, &address.PostalCode, &address.Country, &address.CreatedAt, &address.UpdatedAt)
+	return address, err
+}
@@ -15,15 +15,27 @@ import (
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/credentials"
 	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/protobuf/types/known/timestamppb"
 )
 
 type AccountClient struct {
 	conn    *grpc.ClientConn
 	service protobuf.AccountServiceClient
 	logger  *zap.Logger
+	timeout time.Duration
 }
 
-func NewAccountClient(url string, secure bool) (*AccountClient, error) {
+// idempotentMethods are the read-only RPCs that are safe to retry.
+var idempotentMethods = []string{
+	"GetAccountByID",
+	"GetAccountByEmail",
+	"ListAccounts",
+	"GetAccountHistory",
+	"ListWishlists",
+	"ListAddresses",
+}
+
+func NewAccountClient(url string, secure bool, config common.ClientConfig) (*AccountClient, error) {
 	logger := common.GetLogger()
 
 	var opts []grpc.DialOption
@@ -39,6 +51,8 @@ func NewAccountClient(url string, secure bool) (*AccountClient, error) {
 	_, cancel := context.WithTimeout(context.Background(), 5*time.Second)
 	defer cancel()
 
+	opts = append(opts, config.DialOptions(protobuf.AccountService_ServiceDesc.ServiceName, idempotentMethods)...)
+
 	conn, err := grpc.NewClient(url, opts...)
 	if err != nil {
 		logger.Error("Failed to connect to gRPC server", zap.String("url", url), zap.String("error", err.Error()))
@@ -49,7 +63,7 @@ func NewAccountClient(url string, secure bool) (*AccountClient, error) {
 
 	client := protobuf.NewAccountServiceClient(conn)
 
-	return &AccountClient{conn: conn, service: client, logger: logger}, nil
+	return &AccountClient{conn: conn, service: client, logger: logger, timeout: config.Timeout}, nil
 }
 
 func (c *AccountClient) Close() error {
@@ -58,7 +72,7 @@ func (c *AccountClient) Close() error {
 }
 
 func (c *AccountClient) CreateAccount(ctx context.Context, email, name string) (*Account, error) {
-	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	ctx, cancel := common.WithDefaultTimeout(withOutgoingActor(ctx), c.timeout)
 	defer cancel()
 
 	c.logger.Info("CreateAccount request received", zap.String("email", email), zap.String("name", name))
@@ -75,16 +89,18 @@ func (c *AccountClient) CreateAccount(ctx context.Context, email, name string) (
 	c.logger.Info("Account created successfully", zap.String("account_id", r.GetAccount().GetId()), zap.String("email", email), zap.String("name", name))
 
 	return &Account{
-		ID:        uuid.MustParse(r.GetAccount().GetId()),
-		Name:      r.GetAccount().GetName(),
-		Email:     r.GetAccount().GetEmail(),
-		CreatedAt: r.GetAccount().GetCreatedAt().AsTime(),
-		UpdatedAt: r.GetAccount().GetUpdatedAt().AsTime(),
+		ID:              uuid.MustParse(r.GetAccount().GetId()),
+		Name:            r.GetAccount().GetName(),
+		Email:           r.GetAccount().GetEmail(),
+		Role:            roleFromProto(r.GetAccount().GetRole()),
+		EmailVerifiedAt: optionalTime(r.GetAccount().GetEmailVerifiedAt()),
+		CreatedAt:       r.GetAccount().GetCreatedAt().AsTime(),
+		UpdatedAt:       r.GetAccount().GetUpdatedAt().AsTime(),
 	}, nil
 }
 
 func (c *AccountClient) GetAccountByID(ctx context.Context, id string) (*Account, error) {
-	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	ctx, cancel := common.WithDefaultTimeout(ctx, c.timeout)
 	defer cancel()
 
 	c.logger.Info("GetAccountByID request received", zap.String("account_id", id))
@@ -98,16 +114,18 @@ func (c *AccountClient) GetAccountByID(ctx context.Context, id string) (*Account
 	c.logger.Info("Account fetched successfully", zap.String("account_id", r.GetAccount().GetId()), zap.String("email", r.GetAccount().GetEmail()), zap.String("name", r.GetAccount().GetName()))
 
 	return &Account{
-		ID:        uuid.MustParse(r.GetAccount().GetId()),
-		Name:      r.GetAccount().GetName(),
-		Email:     r.GetAccount().GetEmail(),
-		CreatedAt: r.GetAccount().GetCreatedAt().AsTime(),
-		UpdatedAt: r.GetAccount().GetUpdatedAt().AsTime(),
+		ID:              uuid.MustParse(r.GetAccount().GetId()),
+		Name:            r.GetAccount().GetName(),
+		Email:           r.GetAccount().GetEmail(),
+		Role:            roleFromProto(r.GetAccount().GetRole()),
+		EmailVerifiedAt: optionalTime(r.GetAccount().GetEmailVerifiedAt()),
+		CreatedAt:       r.GetAccount().GetCreatedAt().AsTime(),
+		UpdatedAt:       r.GetAccount().GetUpdatedAt().AsTime(),
 	}, nil
 }
 
 func (c *AccountClient) GetAccountByEmail(ctx context.Context, email string) (*Account, error) {
-	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	ctx, cancel := common.WithDefaultTimeout(ctx, c.timeout)
 	defer cancel()
 
 	c.logger.Info("GetAccountByEmail request received", zap.String("email", email))
@@ -121,41 +139,560 @@ func (c *AccountClient) GetAccountByEmail(ctx context.Context, email string) (*A
 	c.logger.Info("Account fetched successfully", zap.String("account_id", r.GetAccount().GetId()), zap.String("email", email), zap.String("name", r.GetAccount().GetName()))
 
 	return &Account{
-		ID:        uuid.MustParse(r.GetAccount().GetId()),
-		Name:      r.GetAccount().GetName(),
-		Email:     r.GetAccount().GetEmail(),
-		CreatedAt: r.GetAccount().GetCreatedAt().AsTime(),
-		UpdatedAt: r.GetAccount().GetUpdatedAt().AsTime(),
+		ID:              uuid.MustParse(r.GetAccount().GetId()),
+		Name:            r.GetAccount().GetName(),
+		Email:           r.GetAccount().GetEmail(),
+		Role:            roleFromProto(r.GetAccount().GetRole()),
+		EmailVerifiedAt: optionalTime(r.GetAccount().GetEmailVerifiedAt()),
+		CreatedAt:       r.GetAccount().GetCreatedAt().AsTime(),
+		UpdatedAt:       r.GetAccount().GetUpdatedAt().AsTime(),
 	}, nil
 }
 
-func (c *AccountClient) ListAccounts(ctx context.Context, limit, offset uint32) ([]Account, error) {
-	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+func (c *AccountClient) ListAccounts(ctx context.Context, filter AccountFilter, orderBy AccountOrderBy, limit, offset uint32) ([]Account, uint64, error) {
+	ctx, cancel := common.WithDefaultTimeout(ctx, c.timeout)
 	defer cancel()
 
-	c.logger.Info("ListAccounts request received", zap.Uint32("limit", limit), zap.Uint32("offset", offset))
+	c.logger.Info("ListAccounts request received", zap.Uint32("limit", limit), zap.Uint32("offset", offset), zap.String("name", filter.Name), zap.String("email", filter.Email), zap.String("order_by", string(orderBy.Field)))
+
+	protoFilter := &protobuf.AccountFilter{
+		Name:  filter.Name,
+		Email: filter.Email,
+	}
+	if filter.CreatedAfter != nil {
+		protoFilter.CreatedAfter = timestamppb.New(*filter.CreatedAfter)
+	}
+	if filter.CreatedBefore != nil {
+		protoFilter.CreatedBefore = timestamppb.New(*filter.CreatedBefore)
+	}
+
+	protoOrderBy := &protobuf.AccountOrderBy{
+		Field:     protobuf.AccountOrderField_ACCOUNT_ORDER_FIELD_CREATED_AT,
+		Direction: protobuf.SortDirection_SORT_DIRECTION_ASC,
+	}
+	switch orderBy.Field {
+	case AccountOrderByName:
+		protoOrderBy.Field = protobuf.AccountOrderField_ACCOUNT_ORDER_FIELD_NAME
+	case AccountOrderByEmail:
+		protoOrderBy.Field = protobuf.AccountOrderField_ACCOUNT_ORDER_FIELD_EMAIL
+	}
+	if orderBy.Descending {
+		protoOrderBy.Direction = protobuf.SortDirection_SORT_DIRECTION_DESC
+	}
 
 	r, err := c.service.ListAccounts(ctx, &protobuf.ListAccountsRequest{
-		Limit:  limit,
-		Offset: offset,
+		Limit:   limit,
+		Offset:  offset,
+		Filter:  protoFilter,
+		OrderBy: protoOrderBy,
 	})
 	if err != nil {
 		c.logger.Error("Failed to list accounts", zap.String("error", err.Error()))
-		return nil, err
+		return nil, 0, err
 	}
 
-	c.logger.Info("Accounts listed successfully", zap.Int("account_count", len(r.GetAccounts())))
+	c.logger.Info("Accounts listed successfully", zap.Int("account_count", len(r.GetAccounts())), zap.Uint64("total_count", r.GetTotalCount()))
 
 	var accounts []Account
 	for _, acc := range r.GetAccounts() {
 		accounts = append(accounts, Account{
-			ID:        uuid.MustParse(acc.GetId()),
-			Name:      acc.GetName(),
-			Email:     acc.GetEmail(),
-			CreatedAt: acc.GetCreatedAt().AsTime(),
-			UpdatedAt: acc.GetUpdatedAt().AsTime(),
+			ID:              uuid.MustParse(acc.GetId()),
+			Name:            acc.GetName(),
+			Email:           acc.GetEmail(),
+			Role:            roleFromProto(acc.GetRole()),
+			EmailVerifiedAt: optionalTime(acc.GetEmailVerifiedAt()),
+			CreatedAt:       acc.GetCreatedAt().AsTime(),
+			UpdatedAt:       acc.GetUpdatedAt().AsTime(),
+		})
+	}
+
+	return accounts, r.GetTotalCount(), nil
+}
+
+func (c *AccountClient) UpdateAccount(ctx context.Context, id, email, name string) (*Account, error) {
+	ctx, cancel := common.WithDefaultTimeout(withOutgoingActor(ctx), c.timeout)
+	defer cancel()
+
+	c.logger.Info("UpdateAccount request received", zap.String("account_id", id), zap.String("email", email), zap.String("name", name))
+
+	r, err := c.service.UpdateAccount(ctx, &protobuf.UpdateAccountRequest{
+		Id:    id,
+		Email: email,
+		Name:  name,
+	})
+	if err != nil {
+		c.logger.Error("Failed to update account", zap.String("account_id", id), zap.String("error", err.Error()))
+		return nil, err
+	}
+
+	c.logger.Info("Account updated successfully", zap.String("account_id", r.GetAccount().GetId()), zap.String("email", email), zap.String("name", name))
+
+	return &Account{
+		ID:              uuid.MustParse(r.GetAccount().GetId()),
+		Name:            r.GetAccount().GetName(),
+		Email:           r.GetAccount().GetEmail(),
+		Role:            roleFromProto(r.GetAccount().GetRole()),
+		EmailVerifiedAt: optionalTime(r.GetAccount().GetEmailVerifiedAt()),
+		CreatedAt:       r.GetAccount().GetCreatedAt().AsTime(),
+		UpdatedAt:       r.GetAccount().GetUpdatedAt().AsTime(),
+	}, nil
+}
+
+func (c *AccountClient) DeleteAccount(ctx context.Context, id string) (*Account, error) {
+	ctx, cancel := common.WithDefaultTimeout(withOutgoingActor(ctx), c.timeout)
+	defer cancel()
+
+	c.logger.Info("DeleteAccount request received", zap.String("account_id", id))
+
+	r, err := c.service.DeleteAccount(ctx, &protobuf.DeleteAccountRequest{Id: id})
+	if err != nil {
+		c.logger.Error("Failed to delete account", zap.String("account_id", id), zap.String("error", err.Error()))
+		return nil, err
+	}
+
+	c.logger.Info("Account deleted successfully", zap.String("account_id", r.GetAccount().GetId()))
+
+	deletedAt := r.GetAccount().GetDeletedAt().AsTime()
+	return &Account{
+		ID:              uuid.MustParse(r.GetAccount().GetId()),
+		Name:            r.GetAccount().GetName(),
+		Email:           r.GetAccount().GetEmail(),
+		Role:            roleFromProto(r.GetAccount().GetRole()),
+		EmailVerifiedAt: optionalTime(r.GetAccount().GetEmailVerifiedAt()),
+		CreatedAt:       r.GetAccount().GetCreatedAt().AsTime(),
+		UpdatedAt:       r.GetAccount().GetUpdatedAt().AsTime(),
+		DeletedAt:       &deletedAt,
+	}, nil
+}
+
+func (c *AccountClient) RestoreAccount(ctx context.Context, id string) (*Account, error) {
+	ctx, cancel := common.WithDefaultTimeout(withOutgoingActor(ctx), c.timeout)
+	defer cancel()
+
+	c.logger.Info("RestoreAccount request received", zap.String("account_id", id))
+
+	r, err := c.service.RestoreAccount(ctx, &protobuf.RestoreAccountRequest{Id: id})
+	if err != nil {
+		c.logger.Error("Failed to restore account", zap.String("account_id", id), zap.String("error", err.Error()))
+		return nil, err
+	}
+
+	c.logger.Info("Account restored successfully", zap.String("account_id", r.GetAccount().GetId()))
+
+	return &Account{
+		ID:              uuid.MustParse(r.GetAccount().GetId()),
+		Name:            r.GetAccount().GetName(),
+		Email:           r.GetAccount().GetEmail(),
+		Role:            roleFromProto(r.GetAccount().GetRole()),
+		EmailVerifiedAt: optionalTime(r.GetAccount().GetEmailVerifiedAt()),
+		CreatedAt:       r.GetAccount().GetCreatedAt().AsTime(),
+		UpdatedAt:       r.GetAccount().GetUpdatedAt().AsTime(),
+	}, nil
+}
+
+func (c *AccountClient) SetAccountRole(ctx context.Context, id string, role Role) (*Account, error) {
+	ctx, cancel := common.WithDefaultTimeout(withOutgoingActor(ctx), c.timeout)
+	defer cancel()
+
+	c.logger.Info("SetAccountRole request received", zap.String("account_id", id), zap.String("role", string(role)))
+
+	r, err := c.service.SetAccountRole(ctx, &protobuf.SetAccountRoleRequest{
+		Id:   id,
+		Role: roleToProto(role),
+	})
+	if err != nil {
+		c.logger.Error("Failed to set account role", zap.String("account_id", id), zap.String("error", err.Error()))
+		return nil, err
+	}
+
+	c.logger.Info("Account role set successfully", zap.String("account_id", r.GetAccount().GetId()), zap.String("role", string(role)))
+
+	return &Account{
+		ID:              uuid.MustParse(r.GetAccount().GetId()),
+		Name:            r.GetAccount().GetName(),
+		Email:           r.GetAccount().GetEmail(),
+		Role:            roleFromProto(r.GetAccount().GetRole()),
+		EmailVerifiedAt: optionalTime(r.GetAccount().GetEmailVerifiedAt()),
+		CreatedAt:       r.GetAccount().GetCreatedAt().AsTime(),
+		UpdatedAt:       r.GetAccount().GetUpdatedAt().AsTime(),
+	}, nil
+}
+
+func (c *AccountClient) SendVerification(ctx context.Context, accountID string) error {
+	ctx, cancel := common.WithDefaultTimeout(withOutgoingActor(ctx), c.timeout)
+	defer cancel()
+
+	c.logger.Info("SendVerification request received", zap.String("account_id", accountID))
+
+	if _, err := c.service.SendVerification(ctx, &protobuf.SendVerificationRequest{AccountId: accountID}); err != nil {
+		c.logger.Error("Failed to send verification", zap.String("account_id", accountID), zap.String("error", err.Error()))
+		return err
+	}
+
+	c.logger.Info("Verification sent successfully", zap.String("account_id", accountID))
+	return nil
+}
+
+func (c *AccountClient) VerifyEmail(ctx context.Context, token string) (*Account, error) {
+	ctx, cancel := common.WithDefaultTimeout(ctx, c.timeout)
+	defer cancel()
+
+	c.logger.Info("VerifyEmail request received")
+
+	r, err := c.service.VerifyEmail(ctx, &protobuf.VerifyEmailRequest{Token: token})
+	if err != nil {
+		c.logger.Error("Failed to verify email", zap.String("error", err.Error()))
+		return nil, err
+	}
+
+	c.logger.Info("Email verified successfully", zap.String("account_id", r.GetAccount().GetId()))
+
+	return &Account{
+		ID:              uuid.MustParse(r.GetAccount().GetId()),
+		Name:            r.GetAccount().GetName(),
+		Email:           r.GetAccount().GetEmail(),
+		Role:            roleFromProto(r.GetAccount().GetRole()),
+		EmailVerifiedAt: optionalTime(r.GetAccount().GetEmailVerifiedAt()),
+		CreatedAt:       r.GetAccount().GetCreatedAt().AsTime(),
+		UpdatedAt:       r.GetAccount().GetUpdatedAt().AsTime(),
+	}, nil
+}
+
+func (c *AccountClient) RequestPasswordReset(ctx context.Context, email string) error {
+	ctx, cancel := common.WithDefaultTimeout(ctx, c.timeout)
+	defer cancel()
+
+	c.logger.Info("RequestPasswordReset request received")
+
+	if _, err := c.service.RequestPasswordReset(ctx, &protobuf.RequestPasswordResetRequest{Email: email}); err != nil {
+		c.logger.Error("Failed to request password reset", zap.String("error", err.Error()))
+		return err
+	}
+
+	c.logger.Info("Password reset requested successfully")
+	return nil
+}
+
+func (c *AccountClient) ResetPassword(ctx context.Context, token, password string) error {
+	ctx, cancel := common.WithDefaultTimeout(ctx, c.timeout)
+	defer cancel()
+
+	c.logger.Info("ResetPassword request received")
+
+	if _, err := c.service.ResetPassword(ctx, &protobuf.ResetPasswordRequest{Token: token, Password: password}); err != nil {
+		c.logger.Error("Failed to reset password", zap.String("error", err.Error()))
+		return err
+	}
+
+	c.logger.Info("Password reset successfully")
+	return nil
+}
+
+func (c *AccountClient) GetAccountHistory(ctx context.Context, id string, limit, offset uint32) ([]AuditEntry, error) {
+	ctx, cancel := common.WithDefaultTimeout(withOutgoingActor(ctx), c.timeout)
+	defer cancel()
+
+	c.logger.Info("GetAccountHistory request received", zap.String("account_id", id), zap.Uint32("limit", limit), zap.Uint32("offset", offset))
+
+	r, err := c.service.GetAccountHistory(ctx, &protobuf.GetAccountHistoryRequest{
+		Id:     id,
+		Limit:  limit,
+		Offset: offset,
+	})
+	if err != nil {
+		c.logger.Error("Failed to get account history", zap.String("account_id", id), zap.String("error", err.Error()))
+		return nil, err
+	}
+
+	c.logger.Info("Account history fetched successfully", zap.String("account_id", id), zap.Int("entry_count", len(r.GetEntries())))
+
+	var entries []AuditEntry
+	for _, e := range r.GetEntries() {
+		entries = append(entries, AuditEntry{
+			ID:        uuid.MustParse(e.GetId()),
+			AccountID: uuid.MustParse(e.GetAccountId()),
+			Action:    e.GetAction(),
+			Actor:     e.GetActor(),
+			Before:    []byte(e.GetBefore()),
+			After:     []byte(e.GetAfter()),
+			CreatedAt: e.GetCreatedAt().AsTime(),
+		})
+	}
+
+	return entries, nil
+}
+
+func (c *AccountClient) CreateWishlist(ctx context.Context, accountID, name string) (*Wishlist, error) {
+	ctx, cancel := common.WithDefaultTimeout(ctx, c.timeout)
+	defer cancel()
+
+	c.logger.Info("CreateWishlist request received", zap.String("account_id", accountID), zap.String("name", name))
+
+	r, err := c.service.CreateWishlist(ctx, &protobuf.CreateWishlistRequest{
+		AccountId: accountID,
+		Name:      name,
+	})
+	if err != nil {
+		c.logger.Error("Failed to create wishlist", zap.String("account_id", accountID), zap.String("error", err.Error()))
+		return nil, err
+	}
+
+	c.logger.Info("Wishlist created successfully", zap.String("wishlist_id", r.GetWishlist().GetId()))
+
+	return wishlistFromProto(r.GetWishlist()), nil
+}
+
+func (c *AccountClient) ListWishlists(ctx context.Context, accountID string) ([]Wishlist, error) {
+	ctx, cancel := common.WithDefaultTimeout(ctx, c.timeout)
+	defer cancel()
+
+	c.logger.Info("ListWishlists request received", zap.String("account_id", accountID))
+
+	r, err := c.service.ListWishlists(ctx, &protobuf.ListWishlistsRequest{AccountId: accountID})
+	if err != nil {
+		c.logger.Error("Failed to list wishlists", zap.String("account_id", accountID), zap.String("error", err.Error()))
+		return nil, err
+	}
+
+	c.logger.Info("Wishlists listed successfully", zap.String("account_id", accountID), zap.Int("wishlist_count", len(r.GetWishlists())))
+
+	wishlists := make([]Wishlist, 0, len(r.GetWishlists()))
+	for _, w := range r.GetWishlists() {
+		wishlists = append(wishlists, *wishlistFromProto(w))
+	}
+	return wishlists, nil
+}
+
+func (c *AccountClient) AddWishlistItem(ctx context.Context, accountID, wishlistID, productID string) (*Wishlist, error) {
+	ctx, cancel := common.WithDefaultTimeout(ctx, c.timeout)
+	defer cancel()
+
+	c.logger.Info("AddWishlistItem request received", zap.String("account_id", accountID), zap.String("wishlist_id", wishlistID), zap.String("product_id", productID))
+
+	r, err := c.service.AddWishlistItem(ctx, &protobuf.WishlistItemRequest{
+		AccountId:  accountID,
+		WishlistId: wishlistID,
+		ProductId:  productID,
+	})
+	if err != nil {
+		c.logger.Error("Failed to add wishlist item", zap.String("wishlist_id", wishlistID), zap.String("error", err.Error()))
+		return nil, err
+	}
+
+	c.logger.Info("Wishlist item added successfully", zap.String("wishlist_id", wishlistID), zap.Int("item_count", len(r.GetWishlist().GetItems())))
+
+	return wishlistFromProto(r.GetWishlist()), nil
+}
+
+func (c *AccountClient) RemoveWishlistItem(ctx context.Context, accountID, wishlistID, productID string) (*Wishlist, error) {
+	ctx, cancel := common.WithDefaultTimeout(ctx, c.timeout)
+	defer cancel()
+
+	c.logger.Info("RemoveWishlistItem request received", zap.String("account_id", accountID), zap.String("wishlist_id", wishlistID), zap.String("product_id", productID))
+
+	r, err := c.service.RemoveWishlistItem(ctx, &protobuf.WishlistItemRequest{
+		AccountId:  accountID,
+		WishlistId: wishlistID,
+		ProductId:  productID,
+	})
+	if err != nil {
+		c.logger.Error("Failed to remove wishlist item", zap.String("wishlist_id", wishlistID), zap.String("error", err.Error()))
+		return nil, err
+	}
+
+	c.logger.Info("Wishlist item removed successfully", zap.String("wishlist_id", wishlistID), zap.Int("item_count", len(r.GetWishlist().GetItems())))
+
+	return wishlistFromProto(r.GetWishlist()), nil
+}
+
+func (c *AccountClient) ListAddresses(ctx context.Context, accountID string) ([]Address, error) {
+	ctx, cancel := common.WithDefaultTimeout(ctx, c.timeout)
+	defer cancel()
+
+	c.logger.Info("ListAddresses request received", zap.String("account_id", accountID))
+
+	r, err := c.service.ListAddresses(ctx, &protobuf.ListAddressesRequest{AccountId: accountID})
+	if err != nil {
+		c.logger.Error("Failed to list addresses", zap.String("account_id", accountID), zap.String("error", err.Error()))
+		return nil, err
+	}
+
+	c.logger.Info("Addresses listed successfully", zap.String("account_id", accountID), zap.Int("address_count", len(r.GetAddresses())))
+
+	addresses := make([]Address, 0, len(r.GetAddresses()))
+	for _, a := range r.GetAddresses() {
+		addresses = append(addresses, *addressFromProto(a))
+	}
+	return addresses, nil
+}
+
+func (c *AccountClient) CreateAddress(ctx context.Context, accountID string, input AddressInput) (*Address, error) {
+	ctx, cancel := common.WithDefaultTimeout(ctx, c.timeout)
+	defer cancel()
+
+	c.logger.Info("CreateAddress request received", zap.String("account_id", accountID))
+
+	r, err := c.service.CreateAddress(ctx, &protobuf.CreateAddressRequest{
+		AccountId: accountID,
+		Address:   addressInputToProto(input),
+	})
+	if err != nil {
+		c.logger.Error("Failed to create address", zap.String("account_id", accountID), zap.String("error", err.Error()))
+		return nil, err
+	}
+
+	c.logger.Info("Address created successfully", zap.String("address_id", r.GetAddress().GetId()))
+
+	return addressFromProto(r.GetAddress()), nil
+}
+
+func (c *AccountClient) UpdateAddress(ctx context.Context, accountID, addressID string, input AddressInput) (*Address, error) {
+	ctx, cancel := common.WithDefaultTimeout(ctx, c.timeout)
+	defer cancel()
+
+	c.logger.Info("UpdateAddress request received", zap.String("account_id", accountID), zap.String("address_id", addressID))
+
+	r, err := c.service.UpdateAddress(ctx, &protobuf.UpdateAddressRequest{
+		AccountId: accountID,
+		AddressId: addressID,
+		Address:   addressInputToProto(input),
+	})
+	if err != nil {
+		c.logger.Error("Failed to update address", zap.String("address_id", addressID), zap.String("error", err.Error()))
+		return nil, err
+	}
+
+	c.logger.Info("Address updated successfully", zap.String("address_id", addressID))
+
+	return addressFromProto(r.GetAddress()), nil
+}
+
+func (c *AccountClient) DeleteAddress(ctx context.Context, accountID, addressID string) (*Address, error) {
+	ctx, cancel := common.WithDefaultTimeout(ctx, c.timeout)
+	defer cancel()
+
+	c.logger.Info("DeleteAddress request received", zap.String("account_id", accountID), zap.String("address_id", addressID))
+
+	r, err := c.service.DeleteAddress(ctx, &protobuf.DeleteAddressRequest{
+		AccountId: accountID,
+		AddressId: addressID,
+	})
+	if err != nil {
+		c.logger.Error("Failed to delete address", zap.String("address_id", addressID), zap.String("error", err.Error()))
+		return nil, err
+	}
+
+	c.logger.Info("Address deleted successfully", zap.String("address_id", addressID))
+
+	return addressFromProto(r.GetAddress()), nil
+}
+
+// WatchAccounts streams account events until ctx is done or the stream
+// breaks. The returned channel is closed in both cases.
+func (c *AccountClient) WatchAccounts(ctx context.Context) (<-chan AccountEvent, error) {
+	c.logger.Info("WatchAccounts request received")
+
+	stream, err := c.service.WatchAccounts(ctx, &protobuf.WatchAccountsRequest{})
+	if err != nil {
+		c.logger.Error("Failed to watch accounts", zap.String("error", err.Error()))
+		return nil, err
+	}
+
+	events := make(chan AccountEvent)
+	go func() {
+		defer close(events)
+		for {
+			e, err := stream.Recv()
+			if err != nil {
+				if ctx.Err() == nil {
+					c.logger.Error("Account event stream ended", zap.String("error", err.Error()))
+				}
+				return
+			}
+
+			acc := e.GetAccount()
+			event := AccountEvent{
+				Type: AccountEventCreated,
+				Account: Account{
+					ID:              uuid.MustParse(acc.GetId()),
+					Name:            acc.GetName(),
+					Email:           acc.GetEmail(),
+					Role:            roleFromProto(acc.GetRole()),
+					EmailVerifiedAt: optionalTime(acc.GetEmailVerifiedAt()),
+					CreatedAt:       acc.GetCreatedAt().AsTime(),
+					UpdatedAt:       acc.GetUpdatedAt().AsTime(),
+				},
+			}
+			if e.GetType() == protobuf.AccountEventType_ACCOUNT_EVENT_TYPE_UPDATED {
+				event.Type = AccountEventUpdated
+			}
+
+			select {
+			case events <- event:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	return events, nil
+}
+
+func wishlistFromProto(w *protobuf.Wishlist) *Wishlist {
+	items := make([]WishlistItem, 0, len(w.GetItems()))
+	for _, item := range w.GetItems() {
+		items = append(items, WishlistItem{
+			ProductID: item.GetProductId(),
+			AddedAt:   item.GetAddedAt().AsTime(),
 		})
 	}
 
-	return accounts, nil
+	return &Wishlist{
+		ID:        uuid.MustParse(w.GetId()),
+		AccountID: uuid.MustParse(w.GetAccountId()),
+		Name:      w.GetName(),
+		Items:     items,
+		CreatedAt: w.GetCreatedAt().AsTime(),
+		UpdatedAt: w.GetUpdatedAt().AsTime(),
+	}
+}
+
+func addressFromProto(a *protobuf.Address) *Address {
+	return &Address{
+		ID:         uuid.MustParse(a.GetId()),
+		AccountID:  uuid.MustParse(a.GetAccountId()),
+		Type:       addressTypeFromProto(a.GetType()),
+		IsDefault:  a.GetIsDefault(),
+		Name:       a.GetName(),
+		Line1:      a.GetLine1(),
+		Line2:      a.GetLine2(),
+		City:       a.GetCity(),
+		Region:     a.GetRegion(),
+		PostalCode: a.GetPostalCode(),
+		Country:    a.GetCountry(),
+		CreatedAt:  a.GetCreatedAt().AsTime(),
+		UpdatedAt:  a.GetUpdatedAt().AsTime(),
+	}
+}
+
+func addressInputToProto(input AddressInput) *protobuf.AddressInput {
+	return &protobuf.AddressInput{
+		Type:       addressTypeToProto(input.Type),
+		IsDefault:  input.IsDefault,
+		Name:       input.Name,
+		Line1:      input.Line1,
+		Line2:      input.Line2,
+		City:       input.City,
+		Region:     input.Region,
+		PostalCode: input.PostalCode,
+		Country:    input.Country,
+	}
+}
+
+func optionalTime(t *timestamppb.Timestamp) *time.Time {
+	if t == nil {
+		return nil
+	}
+	converted := t.AsTime()
+	return &converted
 }
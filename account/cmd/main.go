@@ -1,10 +1,13 @@
 package main
 
 import (
+	"context"
+	"errors"
 	"log"
 	"time"
 
 	"graphql-grpc-go-microservice-project/account"
+	"graphql-grpc-go-microservice-project/common"
 
 	"github.com/kelseyhightower/envconfig"
 	"github.com/tinrab/retry"
@@ -13,6 +16,36 @@ import (
 type Config struct {
 	ACCOUNT_GRPC_SERVER_PORT int    `envconfig:"ACCOUNT_GRPC_SERVER_PORT" default:"8080"`
 	ACCOUNT_DATABASE_URL     string `envconfig:"ACCOUNT_DATABASE_URL"`
+
+	ACCOUNT_MAILER        string `envconfig:"ACCOUNT_MAILER" required:"true"`
+	ACCOUNT_MAIL_FROM     string `envconfig:"ACCOUNT_MAIL_FROM" default:"no-reply@localhost"`
+	ACCOUNT_MAIL_DIR      string `envconfig:"ACCOUNT_MAIL_DIR" default:"/tmp/account-mail"`
+	ACCOUNT_SMTP_HOST     string `envconfig:"ACCOUNT_SMTP_HOST"`
+	ACCOUNT_SMTP_PORT     int    `envconfig:"ACCOUNT_SMTP_PORT" default:"587"`
+	ACCOUNT_SMTP_USERNAME string `envconfig:"ACCOUNT_SMTP_USERNAME"`
+	ACCOUNT_SMTP_PASSWORD string `envconfig:"ACCOUNT_SMTP_PASSWORD"`
+
+	ACCOUNT_VERIFICATION_URL         string        `envconfig:"ACCOUNT_VERIFICATION_URL" default:"http://localhost:8080/verify-email"`
+	ACCOUNT_VERIFICATION_TOKEN_TTL   time.Duration `envconfig:"ACCOUNT_VERIFICATION_TOKEN_TTL" default:"24h"`
+	ACCOUNT_PASSWORD_RESET_URL       string        `envconfig:"ACCOUNT_PASSWORD_RESET_URL" default:"http://localhost:8080/reset-password"`
+	ACCOUNT_PASSWORD_RESET_TOKEN_TTL time.Duration `envconfig:"ACCOUNT_PASSWORD_RESET_TOKEN_TTL" default:"1h"`
+
+	ACCOUNT_EVENT_BUS           string        `envconfig:"ACCOUNT_EVENT_BUS" default:"memory"`
+	ACCOUNT_NATS_URL            string        `envconfig:"ACCOUNT_NATS_URL" default:"nats://localhost:4222"`
+	ACCOUNT_NATS_STREAM         string        `envconfig:"ACCOUNT_NATS_STREAM" default:"ACCOUNT_EVENTS"`
+	ACCOUNT_OUTBOX_RELAY_PERIOD time.Duration `envconfig:"ACCOUNT_OUTBOX_RELAY_PERIOD" default:"1s"`
+	ACCOUNT_OUTBOX_BATCH_SIZE   int           `envconfig:"ACCOUNT_OUTBOX_BATCH_SIZE" default:"100"`
+
+	ACCOUNT_RATE_LIMIT       float64 `envconfig:"ACCOUNT_RATE_LIMIT" default:"100"`
+	ACCOUNT_RATE_LIMIT_BURST int     `envconfig:"ACCOUNT_RATE_LIMIT_BURST" default:"200"`
+	// ACCOUNT_RATE_LIMIT_TRUSTED_PEERS lists the addresses of the gateway, whose
+	// calls are limited by the client they are made for.
+	ACCOUNT_RATE_LIMIT_TRUSTED_PEERS []string `envconfig:"ACCOUNT_RATE_LIMIT_TRUSTED_PEERS"`
+	// ACCOUNT_ACTOR_TRUSTED_PEERS lists the addresses of the gateway, whose
+	// calls may act on behalf of the signed-in account they forward.
+	ACCOUNT_ACTOR_TRUSTED_PEERS []string `envconfig:"ACCOUNT_ACTOR_TRUSTED_PEERS"`
+
+	ACCOUNT_SHUTDOWN_TIMEOUT time.Duration `envconfig:"ACCOUNT_SHUTDOWN_TIMEOUT" default:"20s"`
 }
 
 func main() {
@@ -21,6 +54,8 @@ func main() {
 		log.Fatalf("Error processing environment variables: %v", err)
 	}
 
+	lifecycle := common.NewLifecycle(cfg.ACCOUNT_SHUTDOWN_TIMEOUT)
+
 	var repo account.AccountRepository
 	retry.ForeverSleep(2*time.Second, func(_ int) error {
 		var err error
@@ -31,20 +66,82 @@ func main() {
 		return err
 	})
 
-	defer func() {
-		if err := repo.Close(); err != nil {
-			log.Printf("Error closing repository: %v", err)
+	lifecycle.OnClose("account repository", repo)
+
+	var mailer account.Mailer
+	switch cfg.ACCOUNT_MAILER {
+	case "log":
+		mailer = account.NewLogMailer()
+	case "file":
+		var err error
+		mailer, err = account.NewFileMailer(cfg.ACCOUNT_MAIL_DIR, cfg.ACCOUNT_MAIL_FROM)
+		if err != nil {
+			log.Fatalf("Failed to create mailer: %v", err)
 		}
-	}()
+	case "smtp":
+		mailer = account.NewSMTPMailer(cfg.ACCOUNT_SMTP_HOST, cfg.ACCOUNT_SMTP_PORT, cfg.ACCOUNT_SMTP_USERNAME, cfg.ACCOUNT_SMTP_PASSWORD, cfg.ACCOUNT_MAIL_FROM)
+	default:
+		log.Fatalf("Unknown mailer %q", cfg.ACCOUNT_MAILER)
+	}
+
+	var bus common.EventBus
+	retry.ForeverSleep(2*time.Second, func(_ int) error {
+		var err error
+		bus, err = common.NewEventBus(cfg.ACCOUNT_EVENT_BUS, cfg.ACCOUNT_NATS_URL, common.NATSStream{
+			Name:     cfg.ACCOUNT_NATS_STREAM,
+			Subjects: []string{"account.>"},
+		})
+		if errors.Is(err, common.ErrUnknownEventBus) {
+			log.Fatalf("Failed to create event bus: %v", err)
+		}
+		if err != nil {
+			log.Printf("Event bus connection failed: %v", err)
+		}
+		return err
+	})
+
+	lifecycle.OnClose("event bus", bus)
+
+	// Nothing in the account service consumes its own events, so with the
+	// memory bus they stay in the outbox until a NATS bus is configured
+	// rather than being marked as published without reaching anyone.
+	if cfg.ACCOUNT_EVENT_BUS == "memory" {
+		log.Println("Memory event bus in use, outbox relay disabled")
+	} else {
+		relay := account.NewOutboxRelay(repo, bus, cfg.ACCOUNT_OUTBOX_BATCH_SIZE)
+		lifecycle.Go("outbox relay", func(ctx context.Context) {
+			relay.Run(ctx, cfg.ACCOUNT_OUTBOX_RELAY_PERIOD)
+		})
+	}
 
 	log.Println("Initializing account service...")
-	service, err := account.NewAccountService(repo)
+	service, err := account.NewAccountService(repo, mailer, account.ServiceConfig{
+		VerificationURL:       cfg.ACCOUNT_VERIFICATION_URL,
+		VerificationTokenTTL:  cfg.ACCOUNT_VERIFICATION_TOKEN_TTL,
+		PasswordResetURL:      cfg.ACCOUNT_PASSWORD_RESET_URL,
+		PasswordResetTokenTTL: cfg.ACCOUNT_PASSWORD_RESET_TOKEN_TTL,
+	})
 	if err != nil {
 		log.Fatalf("Failed to create account service: %v", err)
 	}
 
+	limiter := common.NewRateLimiter(cfg.ACCOUNT_RATE_LIMIT, cfg.ACCOUNT_RATE_LIMIT_BURST)
+	trustedPeers, err := common.ParseTrustedPeers(cfg.ACCOUNT_RATE_LIMIT_TRUSTED_PEERS)
+	if err != nil {
+		log.Fatalf("Failed to parse trusted peers: %v", err)
+	}
+	actorPeers, err := common.ParseTrustedPeers(cfg.ACCOUNT_ACTOR_TRUSTED_PEERS)
+	if err != nil {
+		log.Fatalf("Failed to parse actor trusted peers: %v", err)
+	}
+
 	log.Printf("Starting gRPC server on port %d...", cfg.ACCOUNT_GRPC_SERVER_PORT)
-	if err := account.ListenGRPC(service, cfg.ACCOUNT_GRPC_SERVER_PORT, false); err != nil {
+	if err := account.ListenGRPC(service, cfg.ACCOUNT_GRPC_SERVER_PORT, false, limiter, trustedPeers, actorPeers, lifecycle); err != nil {
+		lifecycle.Shutdown()
 		log.Fatalf("Failed to start gRPC server: %v", err)
 	}
+
+	if err := lifecycle.Wait(); err != nil {
+		log.Fatalf("gRPC server failed: %v", err)
+	}
 }
@@ -0,0 +1,55 @@
+package account
+
+import (
+	"context"
+	"net/netip"
+
+	"graphql-grpc-go-microservice-project/common"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+)
+
+const actorMetadataKey = "x-actor-id"
+
+type actorContextKey struct{}
+
+// ContextWithActor attaches the identity responsible for a change so it is
+// forwarded to the account service and recorded in the audit log.
+func ContextWithActor(ctx context.Context, actor string) context.Context {
+	return context.WithValue(ctx, actorContextKey{}, actor)
+}
+
+// actorFromContext returns the identity responsible for a call, or "" for an
+// anonymous caller.
+func actorFromContext(ctx context.Context) string {
+	actor, _ := ctx.Value(actorContextKey{}).(string)
+	return actor
+}
+
+func withOutgoingActor(ctx context.Context) context.Context {
+	if actor := actorFromContext(ctx); actor != "" {
+		return metadata.AppendToOutgoingContext(ctx, actorMetadataKey, actor)
+	}
+	return ctx
+}
+
+// actorUnaryInterceptor accepts the actor forwarded with a call only from
+// trustedPeers; calls from anyone else are treated as anonymous.
+func actorUnaryInterceptor(trustedPeers []netip.Prefix) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req any, _ *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		return handler(incomingActor(ctx, trustedPeers), req)
+	}
+}
+
+func incomingActor(ctx context.Context, trustedPeers []netip.Prefix) context.Context {
+	if !common.FromTrustedPeer(ctx, trustedPeers) {
+		return ctx
+	}
+	if md, ok := metadata.FromIncomingContext(ctx); ok {
+		if values := md.Get(actorMetadataKey); len(values) > 0 && values[0] != "" {
+			return ContextWithActor(ctx, values[0])
+		}
+	}
+	return ctx
+}
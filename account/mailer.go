@@ -0,0 +1,162 @@
+package account
+
+import (
+	"context"
+	"crypto/tls"
+	"fmt"
+	"net"
+	"net/smtp"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"graphql-grpc-go-microservice-project/common"
+
+	"go.uber.org/zap"
+)
+
+type Mail struct {
+	To      string
+	Subject string
+	Body    string
+}
+
+// Mailer delivers the verification and password reset emails.
+type Mailer interface {
+	Send(ctx context.Context, mail Mail) error
+}
+
+type smtpMailer struct {
+	host string
+	addr string
+	from string
+	auth smtp.Auth
+}
+
+// NewSMTPMailer sends mail through an SMTP server, authenticating with PLAIN
+// auth when a username is given.
+func NewSMTPMailer(host string, port int, username, password, from string) Mailer {
+	var auth smtp.Auth
+	if username != "" {
+		auth = smtp.PlainAuth("", username, password, host)
+	}
+	return &smtpMailer{
+		host: host,
+		addr: net.JoinHostPort(host, strconv.Itoa(port)),
+		from: from,
+		auth: auth,
+	}
+}
+
+// Send gives up when ctx is done, closing the connection to the server.
+func (m *smtpMailer) Send(ctx context.Context, mail Mail) error {
+	var dialer net.Dialer
+	conn, err := dialer.DialContext(ctx, "tcp", m.addr)
+	if err != nil {
+		return fmt.Errorf("failed to send mail: %w", err)
+	}
+	if deadline, ok := ctx.Deadline(); ok {
+		conn.SetDeadline(deadline)
+	}
+	stop := context.AfterFunc(ctx, func() { conn.Close() })
+	defer stop()
+
+	client, err := smtp.NewClient(conn, m.host)
+	if err != nil {
+		conn.Close()
+		return fmt.Errorf("failed to send mail: %w", err)
+	}
+	defer client.Close()
+
+	if err := m.send(client, mail); err != nil {
+		if ctx.Err() != nil {
+			err = ctx.Err()
+		}
+		return fmt.Errorf("failed to send mail: %w", err)
+	}
+	return nil
+}
+
+// send runs the SMTP conversation of smtp.SendMail on client.
+func (m *smtpMailer) send(client *smtp.Client, mail Mail) error {
+	if ok, _ := client.Extension("STARTTLS"); ok {
+		if err := client.StartTLS(&tls.Config{ServerName: m.host}); err != nil {
+			return err
+		}
+	}
+	if m.auth != nil {
+		if err := client.Auth(m.auth); err != nil {
+			return err
+		}
+	}
+	if err := client.Mail(m.from); err != nil {
+		return err
+	}
+	if err := client.Rcpt(mail.To); err != nil {
+		return err
+	}
+
+	w, err := client.Data()
+	if err != nil {
+		return err
+	}
+	if _, err := w.Write(formatMail(m.from, mail)); err != nil {
+		return err
+	}
+	if err := w.Close(); err != nil {
+		return err
+	}
+	return client.Quit()
+}
+
+type fileMailer struct {
+	dir  string
+	from string
+}
+
+// NewFileMailer writes every mail as an .eml file to dir instead of sending
+// it, for local development.
+func NewFileMailer(dir, from string) (Mailer, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, fmt.Errorf("failed to create mail directory: %w", err)
+	}
+	return &fileMailer{dir: dir, from: from}, nil
+}
+
+func (m *fileMailer) Send(ctx context.Context, mail Mail) error {
+	name := fmt.Sprintf("%d-%s.eml", time.Now().UnixNano(), strings.NewReplacer("@", "_at_", "/", "_").Replace(mail.To))
+	if err := os.WriteFile(filepath.Join(m.dir, name), formatMail(m.from, mail), 0o644); err != nil {
+		return fmt.Errorf("failed to write mail: %w", err)
+	}
+	return nil
+}
+
+type logMailer struct {
+	logger *zap.Logger
+}
+
+// NewLogMailer only logs mails, including their body, for local development.
+// The body carries the verification and reset tokens, so it must not be used
+// where others can read the logs.
+func NewLogMailer() Mailer {
+	return &logMailer{logger: common.GetLogger()}
+}
+
+func (m *logMailer) Send(ctx context.Context, mail Mail) error {
+	m.logger.Info("Mail sent", zap.String("to", mail.To), zap.String("subject", mail.Subject), zap.String("body", mail.Body))
+	return nil
+}
+
+func formatMail(from string, mail Mail) []byte {
+	var b strings.Builder
+	fmt.Fprintf(&b, "From: %s\r\n", from)
+	fmt.Fprintf(&b, "To: %s\r\n", mail.To)
+	fmt.Fprintf(&b, "Subject: %s\r\n", mail.Subject)
+	fmt.Fprintf(&b, "Date: %s\r\n", time.Now().Format(time.RFC1123Z))
+	b.WriteString("MIME-Version: 1.0\r\n")
+	b.WriteString("Content-Type: text/plain; charset=UTF-8\r\n\r\n")
+	b.WriteString(strings.ReplaceAll(mail.Body, "\n", "\r\n"))
+	return []byte(b.String())
+}
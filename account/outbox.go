@@ -0,0 +1,62 @@
+package account
+
+import (
+	"context"
+	"time"
+
+	"graphql-grpc-go-microservice-project/common"
+
+	"go.uber.org/zap"
+)
+
+// OutboxRelay drains the account outbox into an event publisher. An event is
+// only marked as published after the publisher accepted it, so delivery is
+// at least once: a crash between the two publishes the event again.
+type OutboxRelay struct {
+	repository AccountRepository
+	publisher  common.EventPublisher
+	batchSize  int
+	logger     *zap.Logger
+}
+
+func NewOutboxRelay(repository AccountRepository, publisher common.EventPublisher, batchSize int) *OutboxRelay {
+	return &OutboxRelay{
+		repository: repository,
+		publisher:  publisher,
+		batchSize:  batchSize,
+		logger:     common.GetLogger(),
+	}
+}
+
+// Run relays pending events every period until ctx is cancelled. Full
+// batches are followed immediately by the next one.
+func (relay *OutboxRelay) Run(ctx context.Context, period time.Duration) {
+	ticker := time.NewTicker(period)
+	defer ticker.Stop()
+
+	for {
+		published, err := relay.RelayPending(ctx)
+		if err != nil {
+			relay.logger.Error("Failed to relay outbox events", zap.Int("published", published), zap.String("error", err.Error()))
+		}
+		if err == nil && published == relay.batchSize {
+			continue
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// RelayPending publishes one batch of pending events and returns how many
+// were published.
+func (relay *OutboxRelay) RelayPending(ctx context.Context) (int, error) {
+	return relay.repository.RelayOutbox(ctx, relay.batchSize, func(event common.Event) error {
+		publishCtx, cancel := context.WithTimeout(ctx, 5*time.Second)
+		defer cancel()
+		return relay.publisher.Publish(publishCtx, event)
+	})
+}
@@ -0,0 +1,114 @@
+package account
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"graphql-grpc-go-microservice-project/common"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// outboxTopics maps audit actions to the topics of the events they publish.
+var outboxTopics = map[string]string{
+	AuditActionCreate:        TopicAccountCreated,
+	AuditActionUpdate:        TopicAccountUpdated,
+	AuditActionDelete:        TopicAccountDeleted,
+	AuditActionRestore:       TopicAccountRestored,
+	AuditActionSetRole:       TopicAccountRoleChanged,
+	AuditActionVerifyEmail:   TopicAccountEmailVerified,
+	AuditActionResetPassword: TopicAccountPasswordReset,
+}
+
+func writeOutboxEvent(ctx context.Context, tx pgx.Tx, action string, account *Account) error {
+	topic, ok := outboxTopics[action]
+	if !ok {
+		return fmt.Errorf("no outbox topic for action %q", action)
+	}
+
+	payload, err := json.Marshal(account)
+	if err != nil {
+		return fmt.Errorf("failed to encode outbox event: %w", err)
+	}
+
+	query := `
+        INSERT INTO account_outbox (topic, aggregate_id, payload)
+        VALUES ($1, $2, $3)`
+	_, err = tx.Exec(ctx, query, topic, account.ID, payload)
+	if err != nil {
+		return fmt.Errorf("failed to write outbox event: %w", err)
+	}
+	return nil
+}
+
+// outboxRelayLockKey is the advisory lock held by the relay publishing the
+// outbox, so that only one relay publishes at a time and events with the same
+// key keep their order.
+const outboxRelayLockKey = 7_316_201
+
+// RelayOutbox hands up to limit unpublished events to publish in the order
+// they were written and marks each one as published once publish returned.
+// It stops at the first failure, recording it on the event, so that later
+// events are not published ahead of it. Only one relay runs at a time across
+// all instances; the others return 0 until it is done. Events are published
+// outside of any transaction, so a crash after publishing an event but
+// before marking it publishes it again. It returns the number of published
+// events.
+func (repository *accountRepository) RelayOutbox(ctx context.Context, limit int, publish func(common.Event) error) (int, error) {
+	conn, err := repository.db.Acquire(ctx)
+	if err != nil {
+		return 0, fmt.Errorf("failed to acquire connection: %w", err)
+	}
+	defer conn.Release()
+
+	var locked bool
+	if err := conn.QueryRow(ctx, "SELECT pg_try_advisory_lock($1)", outboxRelayLockKey).Scan(&locked); err != nil {
+		return 0, fmt.Errorf("failed to lock outbox: %w", err)
+	}
+	if !locked {
+		return 0, nil
+	}
+	defer conn.Exec(context.WithoutCancel(ctx), "SELECT pg_advisory_unlock($1)", outboxRelayLockKey)
+
+	query := `
+        SELECT id, topic, aggregate_id, payload, created_at
+        FROM account_outbox
+        WHERE published_at IS NULL
+        ORDER BY created_at, id
+        LIMIT $1`
+	rows, err := conn.Query(ctx, query, limit)
+	if err != nil {
+		return 0, fmt.Errorf("failed to list outbox events: %w", err)
+	}
+
+	events, err := pgx.CollectRows(rows, func(row pgx.CollectableRow) (common.Event, error) {
+		var event common.Event
+		var payload []byte
+		err := row.Scan(&event.ID, &event.Topic, &event.Key, &payload, &event.OccurredAt)
+		event.Payload = payload
+		return event, err
+	})
+	if err != nil {
+		return 0, fmt.Errorf("failed to scan outbox event: %w", err)
+	}
+
+	published := 0
+	for _, event := range events {
+		if err := publish(event); err != nil {
+			query := "UPDATE account_outbox SET attempts = attempts + 1, last_error = $2 WHERE id = $1"
+			if _, err := conn.Exec(ctx, query, event.ID, err.Error()); err != nil {
+				return published, fmt.Errorf("failed to record outbox failure: %w", err)
+			}
+			return published, fmt.Errorf("failed to publish outbox event %s: %w", event.ID, err)
+		}
+
+		query := "UPDATE account_outbox SET published_at = CURRENT_TIMESTAMP, attempts = attempts + 1, last_error = NULL WHERE id = $1"
+		if _, err := conn.Exec(ctx, query, event.ID); err != nil {
+			return published, fmt.Errorf("failed to mark outbox event as published: %w", err)
+		}
+		published++
+	}
+
+	return published, nil
+}
@@ -2,9 +2,14 @@ package account
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
+	"strings"
 	"time"
 
+	"graphql-grpc-go-microservice-project/common"
+
+	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgxpool"
 )
 
@@ -13,7 +18,31 @@ type AccountRepository interface {
 	CreateAccount(ctx context.Context, email, name string) error
 	GetAccountByID(ctx context.Context, id string) (Account, error)
 	GetAccountByEmail(ctx context.Context, email string) (Account, error)
-	ListAccounts(ctx context.Context, limit, offset uint32) ([]Account, error)
+	ListAccounts(ctx context.Context, filter AccountFilter, orderBy AccountOrderBy, limit, offset uint32) ([]Account, uint64, error)
+	UpdateAccount(ctx context.Context, id, email, name string) (Account, error)
+	DeleteAccount(ctx context.Context, id string) (Account, error)
+	RestoreAccount(ctx context.Context, id string) (Account, error)
+	SetAccountRole(ctx context.Context, id string, role Role) (Account, error)
+	CreateAccountToken(ctx context.Context, accountID, email string, purpose TokenPurpose, tokenHash string, ttl time.Duration) error
+	VerifyEmail(ctx context.Context, tokenHash string) (Account, error)
+	ResetPassword(ctx context.Context, tokenHash, passwordHash string) (Account, error)
+	GetAccountHistory(ctx context.Context, id string, limit, offset uint32) ([]AuditEntry, error)
+	CreateWishlist(ctx context.Context, accountID, name string) (Wishlist, error)
+	GetWishlist(ctx context.Context, accountID, wishlistID string) (Wishlist, error)
+	ListWishlists(ctx context.Context, accountID string) ([]Wishlist, error)
+	AddWishlistItem(ctx context.Context, wishlistID, productID string) error
+	RemoveWishlistItem(ctx context.Context, wishlistID, productID string) error
+	ListAddresses(ctx context.Context, accountID string) ([]Address, error)
+	CreateAddress(ctx context.Context, accountID string, input AddressInput) (Address, error)
+	UpdateAddress(ctx context.Context, accountID, addressID string, input AddressInput) (Address, error)
+	DeleteAddress(ctx context.Context, accountID, addressID string) (Address, error)
+	RelayOutbox(ctx context.Context, limit int, publish func(common.Event) error) (int, error)
+}
+
+var accountOrderColumns = map[AccountOrderField]string{
+	AccountOrderByCreatedAt: "created_at",
+	AccountOrderByName:      "name",
+	AccountOrderByEmail:     "email",
 }
 
 type accountRepository struct {
@@ -47,20 +76,43 @@ func (repository *accountRepository) Close() error {
 }
 
 func (repository *accountRepository) CreateAccount(ctx context.Context, email, name string) error {
+	tx, err := repository.db.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	var account Account
 	query := `
         INSERT INTO accounts (email, name)
-        VALUES ($1, $2)`
-	_, err := repository.db.Exec(ctx, query, email, name)
+        VALUES ($1, $2)
+        RETURNING id, email, name, role, email_verified_at, created_at, updated_at, deleted_at`
+	err = tx.QueryRow(ctx, query, email, name).Scan(&account.ID, &account.Email, &account.Name, &account.Role, &account.EmailVerifiedAt, &account.CreatedAt, &account.UpdatedAt, &account.DeletedAt)
+	if isUniqueViolation(err) {
+		return ErrEmailTaken
+	}
 	if err != nil {
 		return fmt.Errorf("failed to create account: %w", err)
 	}
+
+	if err := writeAuditEntry(ctx, tx, AuditActionCreate, nil, &account); err != nil {
+		return err
+	}
+
+	if err := writeOutboxEvent(ctx, tx, AuditActionCreate, &account); err != nil {
+		return err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
 	return nil
 }
 
 func (repository *accountRepository) GetAccountByID(ctx context.Context, id string) (Account, error) {
 	var account Account
-	query := "SELECT id, email, name, created_at, updated_at FROM accounts WHERE id = $1"
-	err := repository.db.QueryRow(ctx, query, id).Scan(&account.ID, &account.Email, &account.Name, &account.CreatedAt, &account.UpdatedAt)
+	query := "SELECT id, email, name, role, email_verified_at, created_at, updated_at, deleted_at FROM accounts WHERE id = $1 AND deleted_at IS NULL"
+	err := repository.db.QueryRow(ctx, query, id).Scan(&account.ID, &account.Email, &account.Name, &account.Role, &account.EmailVerifiedAt, &account.CreatedAt, &account.UpdatedAt, &account.DeletedAt)
 	if err != nil {
 		return Account{}, fmt.Errorf("failed to get account by id: %w", err)
 	}
@@ -69,33 +121,287 @@ func (repository *accountRepository) GetAccountByID(ctx context.Context, id stri
 
 func (repository *accountRepository) GetAccountByEmail(ctx context.Context, email string) (Account, error) {
 	var account Account
-	query := "SELECT id, email, name, created_at, updated_at FROM accounts WHERE email = $1"
-	err := repository.db.QueryRow(ctx, query, email).Scan(&account.ID, &account.Email, &account.Name, &account.CreatedAt, &account.UpdatedAt)
+	query := "SELECT id, email, name, role, email_verified_at, created_at, updated_at, deleted_at FROM accounts WHERE email = $1 AND deleted_at IS NULL"
+	err := repository.db.QueryRow(ctx, query, email).Scan(&account.ID, &account.Email, &account.Name, &account.Role, &account.EmailVerifiedAt, &account.CreatedAt, &account.UpdatedAt, &account.DeletedAt)
 	if err != nil {
 		return Account{}, fmt.Errorf("failed to get account by email: %w", err)
 	}
 	return account, nil
 }
 
-func (repository *accountRepository) ListAccounts(ctx context.Context, limit, offset uint32) ([]Account, error) {
-	query := `
-        SELECT id, email, name, created_at, updated_at
+func (repository *accountRepository) ListAccounts(ctx context.Context, filter AccountFilter, orderBy AccountOrderBy, limit, offset uint32) ([]Account, uint64, error) {
+	conditions := []string{"deleted_at IS NULL"}
+	var args []any
+
+	if filter.Name != "" {
+		args = append(args, "%"+escapeLikePattern(filter.Name)+"%")
+		conditions = append(conditions, fmt.Sprintf("name ILIKE $%d", len(args)))
+	}
+	if filter.Email != "" {
+		args = append(args, "%"+escapeLikePattern(filter.Email)+"%")
+		conditions = append(conditions, fmt.Sprintf("email ILIKE $%d", len(args)))
+	}
+	if filter.CreatedAfter != nil {
+		args = append(args, *filter.CreatedAfter)
+		conditions = append(conditions, fmt.Sprintf("created_at >= $%d", len(args)))
+	}
+	if filter.CreatedBefore != nil {
+		args = append(args, *filter.CreatedBefore)
+		conditions = append(conditions, fmt.Sprintf("created_at < $%d", len(args)))
+	}
+	where := strings.Join(conditions, " AND ")
+
+	var total uint64
+	countQuery := "SELECT COUNT(*) FROM accounts WHERE " + where
+	if err := repository.db.QueryRow(ctx, countQuery, args...).Scan(&total); err != nil {
+		return nil, 0, fmt.Errorf("failed to count accounts: %w", err)
+	}
+
+	column, ok := accountOrderColumns[orderBy.Field]
+	if !ok {
+		column = accountOrderColumns[AccountOrderByCreatedAt]
+	}
+	direction := "ASC"
+	if orderBy.Descending {
+		direction = "DESC"
+	}
+
+	args = append(args, limit, offset)
+	query := fmt.Sprintf(`
+        SELECT id, email, name, role, email_verified_at, created_at, updated_at, deleted_at
         FROM accounts
-        ORDER BY created_at ASC
-        LIMIT $1 OFFSET $2`
-	rows, err := repository.db.Query(ctx, query, limit, offset)
+        WHERE %s
+        ORDER BY %s %s, id %s
+        LIMIT $%d OFFSET $%d`, where, column, direction, direction, len(args)-1, len(args))
+	rows, err := repository.db.Query(ctx, query, args...)
 	if err != nil {
-		return nil, fmt.Errorf("failed to list accounts: %w", err)
+		return nil, 0, fmt.Errorf("failed to list accounts: %w", err)
 	}
 	defer rows.Close()
 
 	var accounts []Account
 	for rows.Next() {
 		var account Account
-		if err := rows.Scan(&account.ID, &account.Email, &account.Name, &account.CreatedAt, &account.UpdatedAt); err != nil {
-			return nil, fmt.Errorf("failed to scan account row: %w", err)
+		if err := rows.Scan(&account.ID, &account.Email, &account.Name, &account.Role, &account.EmailVerifiedAt, &account.CreatedAt, &account.UpdatedAt, &account.DeletedAt); err != nil {
+			return nil, 0, fmt.Errorf("failed to scan account row: %w", err)
 		}
 		accounts = append(accounts, account)
 	}
-	return accounts, nil
+	return accounts, total, nil
+}
+
+func (repository *accountRepository) UpdateAccount(ctx context.Context, id, email, name string) (Account, error) {
+	query := `
+        UPDATE accounts
+        SET email = $2, name = $3,
+            email_verified_at = CASE WHEN email = $2 THEN email_verified_at END
+        WHERE id = $1 AND deleted_at IS NULL
+        RETURNING id, email, name, role, email_verified_at, created_at, updated_at, deleted_at`
+	return repository.modifyAccount(ctx, AuditActionUpdate, query, id, email, name)
+}
+
+// DeleteAccount refuses to delete the last admin, like SetAccountRole.
+func (repository *accountRepository) DeleteAccount(ctx context.Context, id string) (Account, error) {
+	tx, err := repository.db.Begin(ctx)
+	if err != nil {
+		return Account{}, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	if err := guardLastAdmin(ctx, tx, id); err != nil {
+		return Account{}, err
+	}
+
+	query := `
+        UPDATE accounts
+        SET deleted_at = CURRENT_TIMESTAMP
+        WHERE id = $1 AND deleted_at IS NULL
+        RETURNING id, email, name, role, email_verified_at, created_at, updated_at, deleted_at`
+	account, err := modifyAccountInTx(ctx, tx, AuditActionDelete, query, id)
+	if err != nil {
+		return Account{}, err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return Account{}, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return account, nil
+}
+
+func (repository *accountRepository) RestoreAccount(ctx context.Context, id string) (Account, error) {
+	query := `
+        UPDATE accounts
+        SET deleted_at = NULL
+        WHERE id = $1 AND deleted_at IS NOT NULL
+        RETURNING id, email, name, role, email_verified_at, created_at, updated_at, deleted_at`
+	return repository.modifyAccount(ctx, AuditActionRestore, query, id)
+}
+
+// SetAccountRole refuses to demote the last admin.
+func (repository *accountRepository) SetAccountRole(ctx context.Context, id string, role Role) (Account, error) {
+	tx, err := repository.db.Begin(ctx)
+	if err != nil {
+		return Account{}, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	if role != RoleAdmin {
+		if err := guardLastAdmin(ctx, tx, id); err != nil {
+			return Account{}, err
+		}
+	}
+
+	query := `
+        UPDATE accounts
+        SET role = $2
+        WHERE id = $1 AND deleted_at IS NULL
+        RETURNING id, email, name, role, email_verified_at, created_at, updated_at, deleted_at`
+	account, err := modifyAccountInTx(ctx, tx, AuditActionSetRole, query, id, role)
+	if err != nil {
+		return Account{}, err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return Account{}, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return account, nil
+}
+
+// guardLastAdmin locks the admin accounts until tx ends and returns
+// ErrLastAdmin when id is the only one, so that there is always someone left
+// who can manage roles.
+func guardLastAdmin(ctx context.Context, tx pgx.Tx, id string) error {
+	rows, err := tx.Query(ctx, "SELECT id::text FROM accounts WHERE role = 'admin' AND deleted_at IS NULL FOR UPDATE")
+	if err != nil {
+		return fmt.Errorf("failed to lock admin accounts: %w", err)
+	}
+	admins, err := pgx.CollectRows(rows, pgx.RowTo[string])
+	if err != nil {
+		return fmt.Errorf("failed to lock admin accounts: %w", err)
+	}
+	if len(admins) == 1 && admins[0] == id {
+		return ErrLastAdmin
+	}
+	return nil
+}
+
+func (repository *accountRepository) GetAccountHistory(ctx context.Context, id string, limit, offset uint32) ([]AuditEntry, error) {
+	query := `
+        SELECT id, account_id, action, COALESCE(actor, ''), before, after, created_at
+        FROM account_audit_log
+        WHERE account_id = $1
+        ORDER BY created_at DESC
+        LIMIT $2 OFFSET $3`
+	rows, err := repository.db.Query(ctx, query, id, limit, offset)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get account history: %w", err)
+	}
+	defer rows.Close()
+
+	var entries []AuditEntry
+	for rows.Next() {
+		var entry AuditEntry
+		if err := rows.Scan(&entry.ID, &entry.AccountID, &entry.Action, &entry.Actor, &entry.Before, &entry.After, &entry.CreatedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan audit log row: %w", err)
+		}
+		entries = append(entries, entry)
+	}
+	return entries, nil
+}
+
+// modifyAccount locks the account row identified by the first argument, applies
+// updateQuery and records the before/after state in the audit log and the
+// outbox within one transaction.
+func (repository *accountRepository) modifyAccount(ctx context.Context, action, updateQuery string, args ...any) (Account, error) {
+	tx, err := repository.db.Begin(ctx)
+	if err != nil {
+		return Account{}, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	after, err := modifyAccountInTx(ctx, tx, action, updateQuery, args...)
+	if err != nil {
+		return Account{}, err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return Account{}, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return after, nil
+}
+
+// modifyAccountInTx locks the account, applies updateQuery and records the
+// change in the audit log and the outbox. It returns ErrEmailTaken when the
+// change would give the account the email of another active account.
+func modifyAccountInTx(ctx context.Context, tx pgx.Tx, action, updateQuery string, args ...any) (Account, error) {
+	var before Account
+	lockQuery := "SELECT id, email, name, role, email_verified_at, created_at, updated_at, deleted_at FROM accounts WHERE id = $1 FOR UPDATE"
+	err := tx.QueryRow(ctx, lockQuery, args[0]).Scan(&before.ID, &before.Email, &before.Name, &before.Role, &before.EmailVerifiedAt, &before.CreatedAt, &before.UpdatedAt, &before.DeletedAt)
+	if err != nil {
+		return Account{}, fmt.Errorf("failed to %s account: %w", action, err)
+	}
+
+	var after Account
+	err = tx.QueryRow(ctx, updateQuery, args...).Scan(&after.ID, &after.Email, &after.Name, &after.Role, &after.EmailVerifiedAt, &after.CreatedAt, &after.UpdatedAt, &after.DeletedAt)
+	if isUniqueViolation(err) {
+		return Account{}, ErrEmailTaken
+	}
+	if err != nil {
+		return Account{}, fmt.Errorf("failed to %s account: %w", action, err)
+	}
+
+	if err := writeAuditEntry(ctx, tx, action, &before, &after); err != nil {
+		return Account{}, err
+	}
+
+	if err := writeOutboxEvent(ctx, tx, action, &after); err != nil {
+		return Account{}, err
+	}
+	return after, nil
+}
+
+func writeAuditEntry(ctx context.Context, tx pgx.Tx, action string, before, after *Account) error {
+	beforeJSON, err := auditSnapshot(before)
+	if err != nil {
+		return err
+	}
+
+	afterJSON, err := auditSnapshot(after)
+	if err != nil {
+		return err
+	}
+
+	accountID := after.ID
+
+	var actor *string
+	if a := actorFromContext(ctx); a != "" {
+		actor = &a
+	}
+
+	query := `
+        INSERT INTO account_audit_log (account_id, action, actor, before, after)
+        VALUES ($1, $2, $3, $4, $5)`
+	_, err = tx.Exec(ctx, query, accountID, action, actor, beforeJSON, afterJSON)
+	if err != nil {
+		return fmt.Errorf("failed to write audit log entry: %w", err)
+	}
+	return nil
+}
+
+func auditSnapshot(account *Account) (*string, error) {
+	if account == nil {
+		return nil, nil
+	}
+
+	data, err := json.Marshal(account)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode audit snapshot: %w", err)
+	}
+
+	snapshot := string(data)
+	return &snapshot, nil
+}
+
+func escapeLikePattern(value string) string {
+	return strings.NewReplacer(`\`, `\\`, "%", `\%`, "_", `\_`).Replace(value)
 }
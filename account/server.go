@@ -3,16 +3,16 @@ package account
 import (
 	"context"
 	"crypto/tls"
+	"errors"
 	"fmt"
 	"net"
-	"os"
-	"os/signal"
-	"syscall"
+	"net/netip"
 	"time"
 
 	"graphql-grpc-go-microservice-project/account/protobuf"
 	"graphql-grpc-go-microservice-project/common"
 
+	"github.com/jackc/pgx/v5"
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/credentials"
 	"google.golang.org/grpc/credentials/insecure"
@@ -31,7 +31,12 @@ type accountGrpcServer struct {
 	logger  *zap.Logger
 }
 
-func ListenGRPC(s AccountService, port int, secure bool) error {
+// ListenGRPC serves the service on port in the background until lifecycle
+// shuts down. limiter bounds the calls of each caller; a nil limiter disables
+// rate limiting. Calls from trustedPeers are limited by the client they are
+// made for, see common.ContextWithRateLimitKey. Only the actors forwarded by
+// actorPeers, see ContextWithActor, are trusted; other calls are anonymous.
+func ListenGRPC(s AccountService, port int, secure bool, limiter *common.RateLimiter, trustedPeers, actorPeers []netip.Prefix, lifecycle *common.Lifecycle) error {
 	logger := common.GetLogger()
 
 	lis, err := net.Listen("tcp", fmt.Sprintf(":%d", port))
@@ -46,6 +51,13 @@ func ListenGRPC(s AccountService, port int, secure bool) error {
 	}
 
 	opts = append(opts, grpc.KeepaliveParams(keepAliveParams))
+	opts = append(opts,
+		grpc.ChainUnaryInterceptor(
+			common.RateLimitUnaryInterceptor(limiter, trustedPeers),
+			actorUnaryInterceptor(actorPeers),
+		),
+		grpc.ChainStreamInterceptor(common.RateLimitStreamInterceptor(limiter, trustedPeers)),
+	)
 
 	if secure {
 		creds := credentials.NewTLS(&tls.Config{
@@ -65,24 +77,7 @@ func ListenGRPC(s AccountService, port int, secure bool) error {
 	protobuf.RegisterAccountServiceServer(serv, accountServer)
 	reflection.Register(serv)
 
-	errChan := make(chan error)
-	go func() {
-		if err := serv.Serve(lis); err != nil {
-			errChan <- fmt.Errorf("failed to serve gRPC server: %v", err)
-		}
-	}()
-
-	signalChan := make(chan os.Signal, 1)
-	signal.Notify(signalChan, os.Interrupt, syscall.SIGTERM)
-
-	select {
-	case sig := <-signalChan:
-		logger.Info("Received signal, shutting down gRPC server", zap.String("signal", sig.String()))
-		serv.GracefulStop()
-	case err := <-errChan:
-		return err
-	}
-
+	lifecycle.ServeGRPC("gRPC server", serv, lis)
 	return nil
 }
 
@@ -94,7 +89,7 @@ func (s *accountGrpcServer) CreateAccount(ctx context.Context, r *protobuf.Creat
 		s.logger.Error("Failed to create account", zap.String("email", r.Email), zap.String("error", err.Error()))
 		return &protobuf.CreateAccountResponse{
 			Result: &protobuf.CreateAccountResponse_Error{Error: err.Error()},
-		}, grpcResponseStatus.Errorf(grpcResponseCodes.Internal, err.Error())
+		}, accountError(err)
 	}
 
 	s.logger.Info("Account created successfully", zap.String("account_id", a.ID.String()), zap.String("email", a.Email), zap.String("name", a.Name))
@@ -102,11 +97,13 @@ func (s *accountGrpcServer) CreateAccount(ctx context.Context, r *protobuf.Creat
 	return &protobuf.CreateAccountResponse{
 		Result: &protobuf.CreateAccountResponse_Account{
 			Account: &protobuf.Account{
-				Id:        a.ID.String(),
-				Email:     a.Email,
-				Name:      a.Name,
-				CreatedAt: timestamppb.New(a.CreatedAt),
-				UpdatedAt: timestamppb.New(a.UpdatedAt),
+				Id:              a.ID.String(),
+				Email:           a.Email,
+				Name:            a.Name,
+				Role:            roleToProto(a.Role),
+				EmailVerifiedAt: optionalTimestamp(a.EmailVerifiedAt),
+				CreatedAt:       timestamppb.New(a.CreatedAt),
+				UpdatedAt:       timestamppb.New(a.UpdatedAt),
 			},
 		},
 	}, nil
@@ -128,11 +125,13 @@ func (s *accountGrpcServer) GetAccountByID(ctx context.Context, r *protobuf.GetA
 	return &protobuf.GetAccountByIDResponse{
 		Result: &protobuf.GetAccountByIDResponse_Account{
 			Account: &protobuf.Account{
-				Id:        a.ID.String(),
-				Email:     a.Email,
-				Name:      a.Name,
-				CreatedAt: timestamppb.New(a.CreatedAt),
-				UpdatedAt: timestamppb.New(a.UpdatedAt),
+				Id:              a.ID.String(),
+				Email:           a.Email,
+				Name:            a.Name,
+				Role:            roleToProto(a.Role),
+				EmailVerifiedAt: optionalTimestamp(a.EmailVerifiedAt),
+				CreatedAt:       timestamppb.New(a.CreatedAt),
+				UpdatedAt:       timestamppb.New(a.UpdatedAt),
 			},
 		},
 	}, nil
@@ -154,20 +153,46 @@ func (s *accountGrpcServer) GetAccountByEmail(ctx context.Context, r *protobuf.G
 	return &protobuf.GetAccountByEmailResponse{
 		Result: &protobuf.GetAccountByEmailResponse_Account{
 			Account: &protobuf.Account{
-				Id:        a.ID.String(),
-				Email:     a.Email,
-				Name:      a.Name,
-				CreatedAt: timestamppb.New(a.CreatedAt),
-				UpdatedAt: timestamppb.New(a.UpdatedAt),
+				Id:              a.ID.String(),
+				Email:           a.Email,
+				Name:            a.Name,
+				Role:            roleToProto(a.Role),
+				EmailVerifiedAt: optionalTimestamp(a.EmailVerifiedAt),
+				CreatedAt:       timestamppb.New(a.CreatedAt),
+				UpdatedAt:       timestamppb.New(a.UpdatedAt),
 			},
 		},
 	}, nil
 }
 
 func (s *accountGrpcServer) ListAccounts(ctx context.Context, r *protobuf.ListAccountsRequest) (*protobuf.ListAccountsResponse, error) {
-	s.logger.Info("ListAccounts request received", zap.Uint32("limit", r.Limit), zap.Uint32("offset", r.Offset))
+	s.logger.Info("ListAccounts request received", zap.Uint32("limit", r.Limit), zap.Uint32("offset", r.Offset), zap.String("name", r.GetFilter().GetName()), zap.String("email", r.GetFilter().GetEmail()), zap.String("order_by", r.GetOrderBy().GetField().String()))
+
+	filter := AccountFilter{
+		Name:  r.GetFilter().GetName(),
+		Email: r.GetFilter().GetEmail(),
+	}
+	if r.GetFilter().GetCreatedAfter() != nil {
+		createdAfter := r.GetFilter().GetCreatedAfter().AsTime()
+		filter.CreatedAfter = &createdAfter
+	}
+	if r.GetFilter().GetCreatedBefore() != nil {
+		createdBefore := r.GetFilter().GetCreatedBefore().AsTime()
+		filter.CreatedBefore = &createdBefore
+	}
+
+	orderBy := AccountOrderBy{
+		Field:      AccountOrderByCreatedAt,
+		Descending: r.GetOrderBy().GetDirection() == protobuf.SortDirection_SORT_DIRECTION_DESC,
+	}
+	switch r.GetOrderBy().GetField() {
+	case protobuf.AccountOrderField_ACCOUNT_ORDER_FIELD_NAME:
+		orderBy.Field = AccountOrderByName
+	case protobuf.AccountOrderField_ACCOUNT_ORDER_FIELD_EMAIL:
+		orderBy.Field = AccountOrderByEmail
+	}
 
-	accountsList, err := s.service.ListAccounts(ctx, r.Limit, r.Offset)
+	accountsList, total, err := s.service.ListAccounts(ctx, filter, orderBy, r.Limit, r.Offset)
 	if err != nil {
 		s.logger.Error("Failed to list accounts", zap.String("error", err.Error()))
 		return &protobuf.ListAccountsResponse{
@@ -178,14 +203,526 @@ func (s *accountGrpcServer) ListAccounts(ctx context.Context, r *protobuf.ListAc
 	var accounts []*protobuf.Account
 	for _, a := range accountsList {
 		accounts = append(accounts, &protobuf.Account{
-			Id:        a.ID.String(),
-			Email:     a.Email,
-			Name:      a.Name,
-			CreatedAt: timestamppb.New(a.CreatedAt),
-			UpdatedAt: timestamppb.New(a.UpdatedAt),
+			Id:              a.ID.String(),
+			Email:           a.Email,
+			Name:            a.Name,
+			Role:            roleToProto(a.Role),
+			EmailVerifiedAt: optionalTimestamp(a.EmailVerifiedAt),
+			CreatedAt:       timestamppb.New(a.CreatedAt),
+			UpdatedAt:       timestamppb.New(a.UpdatedAt),
+		})
+	}
+
+	s.logger.Info("Accounts listed successfully", zap.Int("account_count", len(accounts)), zap.Uint64("total_count", total))
+	return &protobuf.ListAccountsResponse{Accounts: accounts, TotalCount: total}, nil
+}
+
+func (s *accountGrpcServer) UpdateAccount(ctx context.Context, r *protobuf.UpdateAccountRequest) (*protobuf.UpdateAccountResponse, error) {
+	s.logger.Info("UpdateAccount request received", zap.String("account_id", r.Id), zap.String("email", r.Email), zap.String("name", r.Name))
+
+	a, err := s.service.UpdateAccount(ctx, r.Id, r.Email, r.Name)
+	if err != nil {
+		s.logger.Error("Failed to update account", zap.String("account_id", r.Id), zap.String("error", err.Error()))
+		return &protobuf.UpdateAccountResponse{
+			Result: &protobuf.UpdateAccountResponse_Error{Error: err.Error()},
+		}, accountError(err)
+	}
+
+	s.logger.Info("Account updated successfully", zap.String("account_id", a.ID.String()), zap.String("email", a.Email), zap.String("name", a.Name))
+
+	return &protobuf.UpdateAccountResponse{
+		Result: &protobuf.UpdateAccountResponse_Account{
+			Account: &protobuf.Account{
+				Id:              a.ID.String(),
+				Email:           a.Email,
+				Name:            a.Name,
+				Role:            roleToProto(a.Role),
+				EmailVerifiedAt: optionalTimestamp(a.EmailVerifiedAt),
+				CreatedAt:       timestamppb.New(a.CreatedAt),
+				UpdatedAt:       timestamppb.New(a.UpdatedAt),
+			},
+		},
+	}, nil
+}
+
+func (s *accountGrpcServer) DeleteAccount(ctx context.Context, r *protobuf.DeleteAccountRequest) (*protobuf.DeleteAccountResponse, error) {
+	s.logger.Info("DeleteAccount request received", zap.String("account_id", r.Id))
+
+	a, err := s.service.DeleteAccount(ctx, r.Id)
+	if err != nil {
+		s.logger.Error("Failed to delete account", zap.String("account_id", r.Id), zap.String("error", err.Error()))
+		return &protobuf.DeleteAccountResponse{
+			Result: &protobuf.DeleteAccountResponse_Error{Error: err.Error()},
+		}, accountError(err)
+	}
+
+	s.logger.Info("Account deleted successfully", zap.String("account_id", a.ID.String()))
+
+	return &protobuf.DeleteAccountResponse{
+		Result: &protobuf.DeleteAccountResponse_Account{
+			Account: &protobuf.Account{
+				Id:              a.ID.String(),
+				Email:           a.Email,
+				Name:            a.Name,
+				Role:            roleToProto(a.Role),
+				EmailVerifiedAt: optionalTimestamp(a.EmailVerifiedAt),
+				CreatedAt:       timestamppb.New(a.CreatedAt),
+				UpdatedAt:       timestamppb.New(a.UpdatedAt),
+				DeletedAt:       timestamppb.New(*a.DeletedAt),
+			},
+		},
+	}, nil
+}
+
+func (s *accountGrpcServer) RestoreAccount(ctx context.Context, r *protobuf.RestoreAccountRequest) (*protobuf.RestoreAccountResponse, error) {
+	s.logger.Info("RestoreAccount request received", zap.String("account_id", r.Id))
+
+	a, err := s.service.RestoreAccount(ctx, r.Id)
+	if err != nil {
+		s.logger.Error("Failed to restore account", zap.String("account_id", r.Id), zap.String("error", err.Error()))
+		return &protobuf.RestoreAccountResponse{
+			Result: &protobuf.RestoreAccountResponse_Error{Error: err.Error()},
+		}, accountError(err)
+	}
+
+	s.logger.Info("Account restored successfully", zap.String("account_id", a.ID.String()), zap.String("email", a.Email), zap.String("name", a.Name))
+
+	return &protobuf.RestoreAccountResponse{
+		Result: &protobuf.RestoreAccountResponse_Account{
+			Account: &protobuf.Account{
+				Id:              a.ID.String(),
+				Email:           a.Email,
+				Name:            a.Name,
+				Role:            roleToProto(a.Role),
+				EmailVerifiedAt: optionalTimestamp(a.EmailVerifiedAt),
+				CreatedAt:       timestamppb.New(a.CreatedAt),
+				UpdatedAt:       timestamppb.New(a.UpdatedAt),
+			},
+		},
+	}, nil
+}
+
+func (s *accountGrpcServer) SetAccountRole(ctx context.Context, r *protobuf.SetAccountRoleRequest) (*protobuf.SetAccountRoleResponse, error) {
+	s.logger.Info("SetAccountRole request received", zap.String("account_id", r.Id), zap.String("role", r.Role.String()), zap.String("actor", actorFromContext(ctx)))
+
+	a, err := s.service.SetAccountRole(ctx, r.Id, roleFromProto(r.Role))
+	if err != nil {
+		s.logger.Error("Failed to set account role", zap.String("account_id", r.Id), zap.String("error", err.Error()))
+		return &protobuf.SetAccountRoleResponse{
+			Result: &protobuf.SetAccountRoleResponse_Error{Error: err.Error()},
+		}, accountError(err)
+	}
+
+	s.logger.Info("Account role set successfully", zap.String("account_id", a.ID.String()), zap.String("role", string(a.Role)))
+
+	return &protobuf.SetAccountRoleResponse{
+		Result: &protobuf.SetAccountRoleResponse_Account{
+			Account: &protobuf.Account{
+				Id:              a.ID.String(),
+				Email:           a.Email,
+				Name:            a.Name,
+				Role:            roleToProto(a.Role),
+				EmailVerifiedAt: optionalTimestamp(a.EmailVerifiedAt),
+				CreatedAt:       timestamppb.New(a.CreatedAt),
+				UpdatedAt:       timestamppb.New(a.UpdatedAt),
+			},
+		},
+	}, nil
+}
+
+func (s *accountGrpcServer) SendVerification(ctx context.Context, r *protobuf.SendVerificationRequest) (*protobuf.SendVerificationResponse, error) {
+	s.logger.Info("SendVerification request received", zap.String("account_id", r.AccountId))
+
+	if err := s.service.SendVerification(ctx, r.AccountId); err != nil {
+		s.logger.Error("Failed to send verification", zap.String("account_id", r.AccountId), zap.String("error", err.Error()))
+		return &protobuf.SendVerificationResponse{Error: err.Error()}, accountError(err)
+	}
+
+	s.logger.Info("Verification sent successfully", zap.String("account_id", r.AccountId))
+	return &protobuf.SendVerificationResponse{}, nil
+}
+
+func (s *accountGrpcServer) VerifyEmail(ctx context.Context, r *protobuf.VerifyEmailRequest) (*protobuf.VerifyEmailResponse, error) {
+	s.logger.Info("VerifyEmail request received")
+
+	a, err := s.service.VerifyEmail(ctx, r.Token)
+	if err != nil {
+		s.logger.Error("Failed to verify email", zap.String("error", err.Error()))
+		return &protobuf.VerifyEmailResponse{
+			Result: &protobuf.VerifyEmailResponse_Error{Error: err.Error()},
+		}, accountError(err)
+	}
+
+	s.logger.Info("Email verified successfully", zap.String("account_id", a.ID.String()), zap.String("email", a.Email))
+
+	return &protobuf.VerifyEmailResponse{
+		Result: &protobuf.VerifyEmailResponse_Account{
+			Account: &protobuf.Account{
+				Id:              a.ID.String(),
+				Email:           a.Email,
+				Name:            a.Name,
+				Role:            roleToProto(a.Role),
+				EmailVerifiedAt: optionalTimestamp(a.EmailVerifiedAt),
+				CreatedAt:       timestamppb.New(a.CreatedAt),
+				UpdatedAt:       timestamppb.New(a.UpdatedAt),
+			},
+		},
+	}, nil
+}
+
+func (s *accountGrpcServer) RequestPasswordReset(ctx context.Context, r *protobuf.RequestPasswordResetRequest) (*protobuf.RequestPasswordResetResponse, error) {
+	s.logger.Info("RequestPasswordReset request received")
+
+	if err := s.service.RequestPasswordReset(ctx, r.Email); err != nil {
+		s.logger.Error("Failed to request password reset", zap.String("error", err.Error()))
+		return &protobuf.RequestPasswordResetResponse{Error: err.Error()}, accountError(err)
+	}
+
+	s.logger.Info("Password reset requested successfully")
+	return &protobuf.RequestPasswordResetResponse{}, nil
+}
+
+func (s *accountGrpcServer) ResetPassword(ctx context.Context, r *protobuf.ResetPasswordRequest) (*protobuf.ResetPasswordResponse, error) {
+	s.logger.Info("ResetPassword request received")
+
+	if err := s.service.ResetPassword(ctx, r.Token, r.Password); err != nil {
+		s.logger.Error("Failed to reset password", zap.String("error", err.Error()))
+		return &protobuf.ResetPasswordResponse{Error: err.Error()}, accountError(err)
+	}
+
+	s.logger.Info("Password reset successfully")
+	return &protobuf.ResetPasswordResponse{}, nil
+}
+
+func (s *accountGrpcServer) GetAccountHistory(ctx context.Context, r *protobuf.GetAccountHistoryRequest) (*protobuf.GetAccountHistoryResponse, error) {
+	s.logger.Info("GetAccountHistory request received", zap.String("account_id", r.Id), zap.Uint32("limit", r.Limit), zap.Uint32("offset", r.Offset))
+
+	history, err := s.service.GetAccountHistory(ctx, r.Id, r.Limit, r.Offset)
+	if err != nil {
+		s.logger.Error("Failed to get account history", zap.String("account_id", r.Id), zap.String("error", err.Error()))
+		return &protobuf.GetAccountHistoryResponse{
+			Error: err.Error(),
+		}, grpcResponseStatus.Errorf(grpcResponseCodes.Internal, err.Error())
+	}
+
+	var entries []*protobuf.AccountAuditEntry
+	for _, e := range history {
+		entries = append(entries, &protobuf.AccountAuditEntry{
+			Id:        e.ID.String(),
+			AccountId: e.AccountID.String(),
+			Action:    e.Action,
+			Actor:     e.Actor,
+			Before:    string(e.Before),
+			After:     string(e.After),
+			CreatedAt: timestamppb.New(e.CreatedAt),
+		})
+	}
+
+	s.logger.Info("Account history fetched successfully", zap.String("account_id", r.Id), zap.Int("entry_count", len(entries)))
+	return &protobuf.GetAccountHistoryResponse{Entries: entries}, nil
+}
+
+func (s *accountGrpcServer) CreateWishlist(ctx context.Context, r *protobuf.CreateWishlistRequest) (*protobuf.CreateWishlistResponse, error) {
+	s.logger.Info("CreateWishlist request received", zap.String("account_id", r.AccountId), zap.String("name", r.Name))
+
+	w, err := s.service.CreateWishlist(ctx, r.AccountId, r.Name)
+	if err != nil {
+		s.logger.Error("Failed to create wishlist", zap.String("account_id", r.AccountId), zap.String("error", err.Error()))
+		return &protobuf.CreateWishlistResponse{
+			Result: &protobuf.CreateWishlistResponse_Error{Error: err.Error()},
+		}, accountError(err)
+	}
+
+	s.logger.Info("Wishlist created successfully", zap.String("wishlist_id", w.ID.String()), zap.String("account_id", r.AccountId))
+
+	return &protobuf.CreateWishlistResponse{
+		Result: &protobuf.CreateWishlistResponse_Wishlist{Wishlist: wishlistToProto(w)},
+	}, nil
+}
+
+func (s *accountGrpcServer) ListWishlists(ctx context.Context, r *protobuf.ListWishlistsRequest) (*protobuf.ListWishlistsResponse, error) {
+	s.logger.Info("ListWishlists request received", zap.String("account_id", r.AccountId))
+
+	wishlists, err := s.service.ListWishlists(ctx, r.AccountId)
+	if err != nil {
+		s.logger.Error("Failed to list wishlists", zap.String("account_id", r.AccountId), zap.String("error", err.Error()))
+		return &protobuf.ListWishlistsResponse{
+			Error: err.Error(),
+		}, accountError(err)
+	}
+
+	protoWishlists := make([]*protobuf.Wishlist, 0, len(wishlists))
+	for _, w := range wishlists {
+		protoWishlists = append(protoWishlists, wishlistToProto(&w))
+	}
+
+	s.logger.Info("Wishlists listed successfully", zap.String("account_id", r.AccountId), zap.Int("wishlist_count", len(wishlists)))
+	return &protobuf.ListWishlistsResponse{Wishlists: protoWishlists}, nil
+}
+
+func (s *accountGrpcServer) AddWishlistItem(ctx context.Context, r *protobuf.WishlistItemRequest) (*protobuf.WishlistItemResponse, error) {
+	s.logger.Info("AddWishlistItem request received", zap.String("account_id", r.AccountId), zap.String("wishlist_id", r.WishlistId), zap.String("product_id", r.ProductId))
+
+	w, err := s.service.AddWishlistItem(ctx, r.AccountId, r.WishlistId, r.ProductId)
+	if err != nil {
+		s.logger.Error("Failed to add wishlist item", zap.String("wishlist_id", r.WishlistId), zap.String("error", err.Error()))
+		return &protobuf.WishlistItemResponse{
+			Result: &protobuf.WishlistItemResponse_Error{Error: err.Error()},
+		}, accountError(err)
+	}
+
+	s.logger.Info("Wishlist item added successfully", zap.String("wishlist_id", r.WishlistId), zap.Int("item_count", len(w.Items)))
+
+	return &protobuf.WishlistItemResponse{
+		Result: &protobuf.WishlistItemResponse_Wishlist{Wishlist: wishlistToProto(w)},
+	}, nil
+}
+
+func (s *accountGrpcServer) RemoveWishlistItem(ctx context.Context, r *protobuf.WishlistItemRequest) (*protobuf.WishlistItemResponse, error) {
+	s.logger.Info("RemoveWishlistItem request received", zap.String("account_id", r.AccountId), zap.String("wishlist_id", r.WishlistId), zap.String("product_id", r.ProductId))
+
+	w, err := s.service.RemoveWishlistItem(ctx, r.AccountId, r.WishlistId, r.ProductId)
+	if err != nil {
+		s.logger.Error("Failed to remove wishlist item", zap.String("wishlist_id", r.WishlistId), zap.String("error", err.Error()))
+		return &protobuf.WishlistItemResponse{
+			Result: &protobuf.WishlistItemResponse_Error{Error: err.Error()},
+		}, accountError(err)
+	}
+
+	s.logger.Info("Wishlist item removed successfully", zap.String("wishlist_id", r.WishlistId), zap.Int("item_count", len(w.Items)))
+
+	return &protobuf.WishlistItemResponse{
+		Result: &protobuf.WishlistItemResponse_Wishlist{Wishlist: wishlistToProto(w)},
+	}, nil
+}
+
+func (s *accountGrpcServer) ListAddresses(ctx context.Context, r *protobuf.ListAddressesRequest) (*protobuf.ListAddressesResponse, error) {
+	s.logger.Info("ListAddresses request received", zap.String("account_id", r.AccountId))
+
+	addresses, err := s.service.ListAddresses(ctx, r.AccountId)
+	if err != nil {
+		s.logger.Error("Failed to list addresses", zap.String("account_id", r.AccountId), zap.String("error", err.Error()))
+		return &protobuf.ListAddressesResponse{
+			Error: err.Error(),
+		}, accountError(err)
+	}
+
+	protoAddresses := make([]*protobuf.Address, 0, len(addresses))
+	for _, a := range addresses {
+		protoAddresses = append(protoAddresses, addressToProto(&a))
+	}
+
+	s.logger.Info("Addresses listed successfully", zap.String("account_id", r.AccountId), zap.Int("address_count", len(addresses)))
+	return &protobuf.ListAddressesResponse{Addresses: protoAddresses}, nil
+}
+
+func (s *accountGrpcServer) CreateAddress(ctx context.Context, r *protobuf.CreateAddressRequest) (*protobuf.CreateAddressResponse, error) {
+	s.logger.Info("CreateAddress request received", zap.String("account_id", r.AccountId))
+
+	a, err := s.service.CreateAddress(ctx, r.AccountId, addressInputFromProto(r.GetAddress()))
+	if err != nil {
+		s.logger.Error("Failed to create address", zap.String("account_id", r.AccountId), zap.String("error", err.Error()))
+		return &protobuf.CreateAddressResponse{
+			Result: &protobuf.CreateAddressResponse_Error{Error: err.Error()},
+		}, accountError(err)
+	}
+
+	s.logger.Info("Address created successfully", zap.String("address_id", a.ID.String()), zap.String("account_id", r.AccountId))
+
+	return &protobuf.CreateAddressResponse{
+		Result: &protobuf.CreateAddressResponse_Address{Address: addressToProto(a)},
+	}, nil
+}
+
+func (s *accountGrpcServer) UpdateAddress(ctx context.Context, r *protobuf.UpdateAddressRequest) (*protobuf.UpdateAddressResponse, error) {
+	s.logger.Info("UpdateAddress request received", zap.String("account_id", r.AccountId), zap.String("address_id", r.AddressId))
+
+	a, err := s.service.UpdateAddress(ctx, r.AccountId, r.AddressId, addressInputFromProto(r.GetAddress()))
+	if err != nil {
+		s.logger.Error("Failed to update address", zap.String("address_id", r.AddressId), zap.String("error", err.Error()))
+		return &protobuf.UpdateAddressResponse{
+			Result: &protobuf.UpdateAddressResponse_Error{Error: err.Error()},
+		}, accountError(err)
+	}
+
+	s.logger.Info("Address updated successfully", zap.String("address_id", r.AddressId))
+
+	return &protobuf.UpdateAddressResponse{
+		Result: &protobuf.UpdateAddressResponse_Address{Address: addressToProto(a)},
+	}, nil
+}
+
+func (s *accountGrpcServer) DeleteAddress(ctx context.Context, r *protobuf.DeleteAddressRequest) (*protobuf.DeleteAddressResponse, error) {
+	s.logger.Info("DeleteAddress request received", zap.String("account_id", r.AccountId), zap.String("address_id", r.AddressId))
+
+	a, err := s.service.DeleteAddress(ctx, r.AccountId, r.AddressId)
+	if err != nil {
+		s.logger.Error("Failed to delete address", zap.String("address_id", r.AddressId), zap.String("error", err.Error()))
+		return &protobuf.DeleteAddressResponse{
+			Result: &protobuf.DeleteAddressResponse_Error{Error: err.Error()},
+		}, accountError(err)
+	}
+
+	s.logger.Info("Address deleted successfully", zap.String("address_id", r.AddressId))
+
+	return &protobuf.DeleteAddressResponse{
+		Result: &protobuf.DeleteAddressResponse_Address{Address: addressToProto(a)},
+	}, nil
+}
+
+func (s *accountGrpcServer) WatchAccounts(r *protobuf.WatchAccountsRequest, stream grpc.ServerStreamingServer[protobuf.AccountEvent]) error {
+	s.logger.Info("WatchAccounts request received")
+
+	events, unsubscribe := s.service.WatchAccounts()
+	defer unsubscribe()
+
+	for {
+		select {
+		case <-stream.Context().Done():
+			s.logger.Info("WatchAccounts stream closed")
+			return nil
+		case event, ok := <-events:
+			if !ok {
+				s.logger.Warn("WatchAccounts stream fell behind")
+				return grpcResponseStatus.Error(grpcResponseCodes.ResourceExhausted, "watcher fell behind")
+			}
+			if err := stream.Send(accountEventToProto(&event)); err != nil {
+				s.logger.Error("Failed to send account event", zap.String("account_id", event.Account.ID.String()), zap.String("error", err.Error()))
+				return err
+			}
+		}
+	}
+}
+
+func accountError(err error) error {
+	var validationErr *common.ValidationError
+	if errors.As(err, &validationErr) {
+		return validationErr.GRPCStatus().Err()
+	}
+	if errors.Is(err, pgx.ErrNoRows) || errors.Is(err, ErrWishlistNotFound) || errors.Is(err, ErrWishlistItemNotFound) || errors.Is(err, ErrAddressNotFound) {
+		return grpcResponseStatus.Errorf(grpcResponseCodes.NotFound, err.Error())
+	}
+	if errors.Is(err, ErrPermissionDenied) || errors.Is(err, ErrUpdateDenied) || errors.Is(err, ErrDeleteDenied) || errors.Is(err, ErrRestoreDenied) || errors.Is(err, ErrHistoryDenied) || errors.Is(err, ErrVerificationDenied) {
+		return grpcResponseStatus.Errorf(grpcResponseCodes.PermissionDenied, err.Error())
+	}
+	if errors.Is(err, ErrInvalidToken) {
+		return grpcResponseStatus.Errorf(grpcResponseCodes.InvalidArgument, err.Error())
+	}
+	if errors.Is(err, ErrLastAdmin) || errors.Is(err, ErrEmailAlreadyVerified) || errors.Is(err, ErrEmailTaken) {
+		return grpcResponseStatus.Errorf(grpcResponseCodes.FailedPrecondition, err.Error())
+	}
+	return grpcResponseStatus.Errorf(grpcResponseCodes.Internal, err.Error())
+}
+
+func wishlistToProto(w *Wishlist) *protobuf.Wishlist {
+	items := make([]*protobuf.WishlistItem, 0, len(w.Items))
+	for _, item := range w.Items {
+		items = append(items, &protobuf.WishlistItem{
+			ProductId: item.ProductID,
+			AddedAt:   timestamppb.New(item.AddedAt),
 		})
 	}
 
-	s.logger.Info("Accounts listed successfully", zap.Int("account_count", len(accounts)))
-	return &protobuf.ListAccountsResponse{Accounts: accounts}, nil
+	return &protobuf.Wishlist{
+		Id:        w.ID.String(),
+		AccountId: w.AccountID.String(),
+		Name:      w.Name,
+		Items:     items,
+		CreatedAt: timestamppb.New(w.CreatedAt),
+		UpdatedAt: timestamppb.New(w.UpdatedAt),
+	}
+}
+
+func addressToProto(a *Address) *protobuf.Address {
+	return &protobuf.Address{
+		Id:         a.ID.String(),
+		AccountId:  a.AccountID.String(),
+		Type:       addressTypeToProto(a.Type),
+		IsDefault:  a.IsDefault,
+		Name:       a.Name,
+		Line1:      a.Line1,
+		Line2:      a.Line2,
+		City:       a.City,
+		Region:     a.Region,
+		PostalCode: a.PostalCode,
+		Country:    a.Country,
+		CreatedAt:  timestamppb.New(a.CreatedAt),
+		UpdatedAt:  timestamppb.New(a.UpdatedAt),
+	}
+}
+
+func addressInputFromProto(a *protobuf.AddressInput) AddressInput {
+	return AddressInput{
+		Type:       addressTypeFromProto(a.GetType()),
+		IsDefault:  a.GetIsDefault(),
+		Name:       a.GetName(),
+		Line1:      a.GetLine1(),
+		Line2:      a.GetLine2(),
+		City:       a.GetCity(),
+		Region:     a.GetRegion(),
+		PostalCode: a.GetPostalCode(),
+		Country:    a.GetCountry(),
+	}
+}
+
+func addressTypeToProto(t AddressType) protobuf.AddressType {
+	if t == AddressTypeBilling {
+		return protobuf.AddressType_ADDRESS_TYPE_BILLING
+	}
+	return protobuf.AddressType_ADDRESS_TYPE_SHIPPING
+}
+
+func addressTypeFromProto(t protobuf.AddressType) AddressType {
+	if t == protobuf.AddressType_ADDRESS_TYPE_BILLING {
+		return AddressTypeBilling
+	}
+	return AddressTypeShipping
+}
+
+func optionalTimestamp(t *time.Time) *timestamppb.Timestamp {
+	if t == nil {
+		return nil
+	}
+	return timestamppb.New(*t)
+}
+
+func roleToProto(role Role) protobuf.AccountRole {
+	switch role {
+	case RoleStaff:
+		return protobuf.AccountRole_ACCOUNT_ROLE_STAFF
+	case RoleAdmin:
+		return protobuf.AccountRole_ACCOUNT_ROLE_ADMIN
+	}
+	return protobuf.AccountRole_ACCOUNT_ROLE_CUSTOMER
+}
+
+func roleFromProto(role protobuf.AccountRole) Role {
+	switch role {
+	case protobuf.AccountRole_ACCOUNT_ROLE_STAFF:
+		return RoleStaff
+	case protobuf.AccountRole_ACCOUNT_ROLE_ADMIN:
+		return RoleAdmin
+	}
+	return RoleCustomer
+}
+
+func accountEventToProto(e *AccountEvent) *protobuf.AccountEvent {
+	eventType := protobuf.AccountEventType_ACCOUNT_EVENT_TYPE_CREATED
+	if e.Type == AccountEventUpdated {
+		eventType = protobuf.AccountEventType_ACCOUNT_EVENT_TYPE_UPDATED
+	}
+
+	a := &e.Account
+	return &protobuf.AccountEvent{
+		Type: eventType,
+		Account: &protobuf.Account{
+			Id:              a.ID.String(),
+			Email:           a.Email,
+			Name:            a.Name,
+			Role:            roleToProto(a.Role),
+			EmailVerifiedAt: optionalTimestamp(a.EmailVerifiedAt),
+			CreatedAt:       timestamppb.New(a.CreatedAt),
+			UpdatedAt:       timestamppb.New(a.UpdatedAt),
+		},
+	}
 }
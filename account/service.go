@@ -2,34 +2,99 @@ package account
 
 import (
 	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/url"
+	"time"
+
+	"graphql-grpc-go-microservice-project/common"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"golang.org/x/crypto/bcrypt"
 )
 
 type AccountService interface {
 	CreateAccount(ctx context.Context, email, name string) (*Account, error)
 	GetAccountByID(ctx context.Context, id string) (*Account, error)
 	GetAccountByEmail(ctx context.Context, email string) (*Account, error)
-	ListAccounts(ctx context.Context, limit, offset uint32) ([]Account, error)
+	ListAccounts(ctx context.Context, filter AccountFilter, orderBy AccountOrderBy, limit, offset uint32) ([]Account, uint64, error)
+	UpdateAccount(ctx context.Context, id, email, name string) (*Account, error)
+	DeleteAccount(ctx context.Context, id string) (*Account, error)
+	RestoreAccount(ctx context.Context, id string) (*Account, error)
+	SetAccountRole(ctx context.Context, id string, role Role) (*Account, error)
+	SendVerification(ctx context.Context, accountID string) error
+	VerifyEmail(ctx context.Context, token string) (*Account, error)
+	RequestPasswordReset(ctx context.Context, email string) error
+	ResetPassword(ctx context.Context, token, password string) error
+	GetAccountHistory(ctx context.Context, id string, limit, offset uint32) ([]AuditEntry, error)
+	CreateWishlist(ctx context.Context, accountID, name string) (*Wishlist, error)
+	ListWishlists(ctx context.Context, accountID string) ([]Wishlist, error)
+	AddWishlistItem(ctx context.Context, accountID, wishlistID, productID string) (*Wishlist, error)
+	RemoveWishlistItem(ctx context.Context, accountID, wishlistID, productID string) (*Wishlist, error)
+	ListAddresses(ctx context.Context, accountID string) ([]Address, error)
+	CreateAddress(ctx context.Context, accountID string, input AddressInput) (*Address, error)
+	UpdateAddress(ctx context.Context, accountID, addressID string, input AddressInput) (*Address, error)
+	DeleteAddress(ctx context.Context, accountID, addressID string) (*Address, error)
+	WatchAccounts() (<-chan AccountEvent, func())
+}
+
+// ServiceConfig configures the emails sent by the account service. The
+// token is appended to VerificationURL and PasswordResetURL as the token
+// query parameter.
+type ServiceConfig struct {
+	VerificationURL       string
+	VerificationTokenTTL  time.Duration
+	PasswordResetURL      string
+	PasswordResetTokenTTL time.Duration
 }
 
+// watchBufferSize is the number of events a WatchAccounts stream may lag
+// behind before it is dropped.
+const watchBufferSize = 64
+
 type accountService struct {
 	repository AccountRepository
+	mailer     Mailer
+	config     ServiceConfig
+	events     *common.Broadcaster[AccountEvent]
 }
 
-func NewAccountService(repository AccountRepository) (AccountService, error) {
-	return &accountService{repository: repository}, nil
+func NewAccountService(repository AccountRepository, mailer Mailer, config ServiceConfig) (AccountService, error) {
+	for _, link := range []string{config.VerificationURL, config.PasswordResetURL} {
+		if _, err := url.Parse(link); err != nil {
+			return nil, fmt.Errorf("invalid email link %q: %w", link, err)
+		}
+	}
+	return &accountService{
+		repository: repository,
+		mailer:     mailer,
+		config:     config,
+		events:     common.NewBroadcaster[AccountEvent](),
+	}, nil
 }
 
 func (service *accountService) CreateAccount(ctx context.Context, email, name string) (*Account, error) {
-	if err := service.repository.CreateAccount(ctx, email, name); err != nil {
+	email, name, err := validateAccountInput(email, name)
+	if err != nil {
 		return nil, err
 	}
 
+	if err := service.repository.CreateAccount(ctx, email, name); err != nil {
+		return nil, emailTakenViolation(err)
+	}
+
 	var account Account
-	account, err := service.repository.GetAccountByEmail(ctx, email)
+	account, err = service.repository.GetAccountByEmail(ctx, email)
 	if err != nil {
 		return nil, err
 	}
 
+	service.events.Publish(AccountEvent{Type: AccountEventCreated, Account: account})
 	return &account, nil
 }
 
@@ -42,17 +107,399 @@ func (service *accountService) GetAccountByID(ctx context.Context, id string) (*
 }
 
 func (service *accountService) GetAccountByEmail(ctx context.Context, email string) (*Account, error) {
-	account, err := service.repository.GetAccountByEmail(ctx, email)
+	account, err := service.repository.GetAccountByEmail(ctx, normalizeEmail(email))
+	if err != nil {
+		return nil, err
+	}
+	return &account, nil
+}
+
+func (service *accountService) ListAccounts(ctx context.Context, filter AccountFilter, orderBy AccountOrderBy, limit, offset uint32) ([]Account, uint64, error) {
+	accounts, total, err := service.repository.ListAccounts(ctx, filter, orderBy, limit, offset)
+	if err != nil {
+		return nil, 0, err
+	}
+	return accounts, total, nil
+}
+
+// UpdateAccount is allowed when the actor of the request is the account
+// itself or an admin.
+func (service *accountService) UpdateAccount(ctx context.Context, id, email, name string) (*Account, error) {
+	if actor := actorFromContext(ctx); actor == "" || actor != id {
+		if err := service.authorizeAdmin(ctx, ErrUpdateDenied); err != nil {
+			return nil, err
+		}
+	}
+
+	email, name, err := validateAccountInput(email, name)
+	if err != nil {
+		return nil, err
+	}
+
+	account, err := service.repository.UpdateAccount(ctx, id, email, name)
+	if err != nil {
+		return nil, emailTakenViolation(err)
+	}
+
+	service.events.Publish(AccountEvent{Type: AccountEventUpdated, Account: account})
+	return &account, nil
+}
+
+// emailTakenViolation reports ErrEmailTaken as a violation of the email field.
+func emailTakenViolation(err error) error {
+	if !errors.Is(err, ErrEmailTaken) {
+		return err
+	}
+	violations := &common.ValidationError{}
+	violations.Add("email", "is already used by another account")
+	return violations.Err()
+}
+
+// DeleteAccount is allowed when the actor of the request is the account
+// itself or an admin.
+func (service *accountService) DeleteAccount(ctx context.Context, id string) (*Account, error) {
+	if actor := actorFromContext(ctx); actor == "" || actor != id {
+		if err := service.authorizeAdmin(ctx, ErrDeleteDenied); err != nil {
+			return nil, err
+		}
+	}
+
+	account, err := service.repository.DeleteAccount(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	return &account, nil
+}
+
+// RestoreAccount is only allowed for admins.
+func (service *accountService) RestoreAccount(ctx context.Context, id string) (*Account, error) {
+	if err := service.authorizeAdmin(ctx, ErrRestoreDenied); err != nil {
+		return nil, err
+	}
+
+	account, err := service.repository.RestoreAccount(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	return &account, nil
+}
+
+// SetAccountRole is only allowed when the actor of the request is the ID of
+// an admin account.
+func (service *accountService) SetAccountRole(ctx context.Context, id string, role Role) (*Account, error) {
+	if err := validateSetAccountRole(id, role); err != nil {
+		return nil, err
+	}
+
+	if err := service.authorizeAdmin(ctx, ErrPermissionDenied); err != nil {
+		return nil, err
+	}
+
+	account, err := service.repository.SetAccountRole(ctx, id, role)
 	if err != nil {
 		return nil, err
 	}
 	return &account, nil
 }
 
-func (service *accountService) ListAccounts(ctx context.Context, limit, offset uint32) ([]Account, error) {
-	accounts, err := service.repository.ListAccounts(ctx, limit, offset)
+// authorizeAdmin returns denied unless the actor of the request is an admin.
+func (service *accountService) authorizeAdmin(ctx context.Context, denied error) error {
+	return service.authorizeRole(ctx, denied, RoleAdmin)
+}
+
+// authorizeRole returns denied unless the actor of the request has one of
+// roles.
+func (service *accountService) authorizeRole(ctx context.Context, denied error, roles ...Role) error {
+	actor := actorFromContext(ctx)
+	if _, err := uuid.Parse(actor); err != nil {
+		return denied
+	}
+
+	caller, err := service.repository.GetAccountByID(ctx, actor)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return denied
+	}
+	if err != nil {
+		return err
+	}
+
+	for _, role := range roles {
+		if caller.Role == role {
+			return nil
+		}
+	}
+	return denied
+}
+
+// GetAccountHistory is allowed when the actor of the request is the account
+// itself, staff or an admin.
+func (service *accountService) GetAccountHistory(ctx context.Context, id string, limit, offset uint32) ([]AuditEntry, error) {
+	if actor := actorFromContext(ctx); actor == "" || actor != id {
+		if err := service.authorizeRole(ctx, ErrHistoryDenied, RoleStaff, RoleAdmin); err != nil {
+			return nil, err
+		}
+	}
+
+	entries, err := service.repository.GetAccountHistory(ctx, id, limit, offset)
 	if err != nil {
 		return nil, err
 	}
-	return accounts, nil
+	return entries, nil
+}
+
+func (service *accountService) CreateWishlist(ctx context.Context, accountID, name string) (*Wishlist, error) {
+	name, err := validateWishlistInput(accountID, name)
+	if err != nil {
+		return nil, err
+	}
+
+	if _, err := service.repository.GetAccountByID(ctx, accountID); err != nil {
+		return nil, err
+	}
+
+	wishlist, err := service.repository.CreateWishlist(ctx, accountID, name)
+	if errors.Is(err, ErrWishlistNameTaken) {
+		violations := &common.ValidationError{}
+		violations.Add("name", "is already used by another wishlist of this account")
+		return nil, violations.Err()
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &wishlist, nil
+}
+
+func (service *accountService) ListWishlists(ctx context.Context, accountID string) ([]Wishlist, error) {
+	if err := validateAccountID(accountID); err != nil {
+		return nil, err
+	}
+
+	return service.repository.ListWishlists(ctx, accountID)
+}
+
+// AddWishlistItem does not check that the product exists, since products are
+// owned by the product service; callers are expected to do so.
+func (service *accountService) AddWishlistItem(ctx context.Context, accountID, wishlistID, productID string) (*Wishlist, error) {
+	productID, err := validateWishlistItem(accountID, wishlistID, productID)
+	if err != nil {
+		return nil, err
+	}
+
+	if _, err := service.repository.GetWishlist(ctx, accountID, wishlistID); err != nil {
+		return nil, err
+	}
+
+	if err := service.repository.AddWishlistItem(ctx, wishlistID, productID); err != nil {
+		return nil, err
+	}
+
+	wishlist, err := service.repository.GetWishlist(ctx, accountID, wishlistID)
+	if err != nil {
+		return nil, err
+	}
+	return &wishlist, nil
+}
+
+func (service *accountService) RemoveWishlistItem(ctx context.Context, accountID, wishlistID, productID string) (*Wishlist, error) {
+	productID, err := validateWishlistItem(accountID, wishlistID, productID)
+	if err != nil {
+		return nil, err
+	}
+
+	if _, err := service.repository.GetWishlist(ctx, accountID, wishlistID); err != nil {
+		return nil, err
+	}
+
+	if err := service.repository.RemoveWishlistItem(ctx, wishlistID, productID); err != nil {
+		return nil, err
+	}
+
+	wishlist, err := service.repository.GetWishlist(ctx, accountID, wishlistID)
+	if err != nil {
+		return nil, err
+	}
+	return &wishlist, nil
+}
+
+func (service *accountService) ListAddresses(ctx context.Context, accountID string) ([]Address, error) {
+	if err := validateAccountID(accountID); err != nil {
+		return nil, err
+	}
+
+	return service.repository.ListAddresses(ctx, accountID)
+}
+
+func (service *accountService) CreateAddress(ctx context.Context, accountID string, input AddressInput) (*Address, error) {
+	input, err := validateAddressInput(accountID, input)
+	if err != nil {
+		return nil, err
+	}
+
+	address, err := service.repository.CreateAddress(ctx, accountID, input)
+	if err != nil {
+		return nil, err
+	}
+	return &address, nil
+}
+
+func (service *accountService) UpdateAddress(ctx context.Context, accountID, addressID string, input AddressInput) (*Address, error) {
+	if err := validateAddressOwner(accountID, addressID); err != nil {
+		return nil, err
+	}
+
+	input, err := validateAddressInput(accountID, input)
+	if err != nil {
+		return nil, err
+	}
+
+	address, err := service.repository.UpdateAddress(ctx, accountID, addressID, input)
+	if err != nil {
+		return nil, err
+	}
+	return &address, nil
+}
+
+func (service *accountService) DeleteAddress(ctx context.Context, accountID, addressID string) (*Address, error) {
+	if err := validateAddressOwner(accountID, addressID); err != nil {
+		return nil, err
+	}
+
+	address, err := service.repository.DeleteAddress(ctx, accountID, addressID)
+	if err != nil {
+		return nil, err
+	}
+	return &address, nil
+}
+
+// SendVerification is only allowed when the actor of the request is the
+// account itself.
+func (service *accountService) SendVerification(ctx context.Context, accountID string) error {
+	if err := validateAccountID(accountID); err != nil {
+		return err
+	}
+	if actorFromContext(ctx) != accountID {
+		return ErrVerificationDenied
+	}
+
+	account, err := service.repository.GetAccountByID(ctx, accountID)
+	if err != nil {
+		return err
+	}
+	if account.EmailVerifiedAt != nil {
+		return ErrEmailAlreadyVerified
+	}
+
+	link, err := service.issueToken(ctx, &account, TokenPurposeEmailVerification, service.config.VerificationURL, service.config.VerificationTokenTTL)
+	if err != nil {
+		return err
+	}
+
+	return service.mailer.Send(ctx, Mail{
+		To:      account.Email,
+		Subject: "Verify your email address",
+		Body: fmt.Sprintf("Hi %s,\n\nPlease confirm your email address by opening the link below:\n\n%s\n\nThe link expires in %s.\n",
+			account.Name, link, formatTTL(service.config.VerificationTokenTTL)),
+	})
+}
+
+func (service *accountService) VerifyEmail(ctx context.Context, token string) (*Account, error) {
+	if err := validateToken(token); err != nil {
+		return nil, err
+	}
+
+	account, err := service.repository.VerifyEmail(ctx, hashToken(token))
+	if err != nil {
+		return nil, err
+	}
+	return &account, nil
+}
+
+// RequestPasswordReset succeeds without sending anything when no account
+// uses the email, so that it cannot be used to find out which emails are
+// registered.
+func (service *accountService) RequestPasswordReset(ctx context.Context, email string) error {
+	email = normalizeEmail(email)
+
+	violations := &common.ValidationError{}
+	validateEmail(violations, email)
+	if err := violations.Err(); err != nil {
+		return err
+	}
+
+	account, err := service.repository.GetAccountByEmail(ctx, email)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	link, err := service.issueToken(ctx, &account, TokenPurposePasswordReset, service.config.PasswordResetURL, service.config.PasswordResetTokenTTL)
+	if err != nil {
+		return err
+	}
+
+	return service.mailer.Send(ctx, Mail{
+		To:      account.Email,
+		Subject: "Reset your password",
+		Body: fmt.Sprintf("Hi %s,\n\nA password reset was requested for your account. Choose a new password by opening the link below:\n\n%s\n\nThe link expires in %s. If you did not request a reset, you can ignore this email.\n",
+			account.Name, link, formatTTL(service.config.PasswordResetTokenTTL)),
+	})
+}
+
+func (service *accountService) ResetPassword(ctx context.Context, token, password string) error {
+	if err := validatePasswordReset(token, password); err != nil {
+		return err
+	}
+
+	passwordHash, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+	if err != nil {
+		return fmt.Errorf("failed to hash password: %w", err)
+	}
+
+	_, err = service.repository.ResetPassword(ctx, hashToken(token), string(passwordHash))
+	return err
+}
+
+// issueToken stores a new token for the account and returns the link to
+// mail to it.
+func (service *accountService) issueToken(ctx context.Context, account *Account, purpose TokenPurpose, link string, ttl time.Duration) (string, error) {
+	random := make([]byte, 32)
+	if _, err := rand.Read(random); err != nil {
+		return "", fmt.Errorf("failed to generate token: %w", err)
+	}
+	token := base64.RawURLEncoding.EncodeToString(random)
+
+	if err := service.repository.CreateAccountToken(ctx, account.ID.String(), account.Email, purpose, hashToken(token), ttl); err != nil {
+		return "", err
+	}
+
+	u, _ := url.Parse(link)
+	query := u.Query()
+	query.Set("token", token)
+	u.RawQuery = query.Encode()
+	return u.String(), nil
+}
+
+func hashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+// WatchAccounts subscribes to the accounts created and updated by this
+// instance of the service. The channel is closed when the subscriber falls
+// behind by more than watchBufferSize events.
+func (service *accountService) WatchAccounts() (<-chan AccountEvent, func()) {
+	return service.events.Subscribe(watchBufferSize)
+}
+
+func formatTTL(ttl time.Duration) string {
+	unit, count := "minute", int(ttl.Minutes())
+	if ttl >= time.Hour && ttl%time.Hour == 0 {
+		unit, count = "hour", int(ttl.Hours())
+	}
+	if count == 1 {
+		return "1 " + unit
+	}
+	return fmt.Sprintf("%d %ss", count, unit)
 }
@@ -1 +1,7 @@
-DROP TABLE IF EXISTS accounts;
\ No newline at end of file
+DROP TABLE IF EXISTS account_outbox;
+DROP TABLE IF EXISTS account_tokens;
+DROP TABLE IF EXISTS account_addresses;
+DROP TABLE IF EXISTS wishlist_items;
+DROP TABLE IF EXISTS wishlists;
+DROP TABLE IF EXISTS account_audit_log;
+DROP TABLE IF EXISTS accounts;
@@ -0,0 +1,13 @@
+ALTER TABLE accounts ADD COLUMN IF NOT EXISTS deleted_at TIMESTAMP;
+
+CREATE TABLE IF NOT EXISTS account_audit_log (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4 (),
+    account_id UUID NOT NULL REFERENCES accounts (id),
+    action VARCHAR(32) NOT NULL,
+    actor VARCHAR(255),
+    before JSONB,
+    after JSONB,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_account_audit_log_account_id ON account_audit_log (account_id, created_at);
@@ -0,0 +1,7 @@
+CREATE EXTENSION IF NOT EXISTS pg_trgm;
+
+CREATE INDEX IF NOT EXISTS idx_accounts_name_trgm ON accounts USING GIN (name gin_trgm_ops);
+
+CREATE INDEX IF NOT EXISTS idx_accounts_email_trgm ON accounts USING GIN (email gin_trgm_ops);
+
+CREATE INDEX IF NOT EXISTS idx_accounts_created_at ON accounts (created_at);
@@ -0,0 +1,19 @@
+CREATE TABLE IF NOT EXISTS wishlists (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4 (),
+    account_id UUID NOT NULL REFERENCES accounts (id),
+    name VARCHAR(255) NOT NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE (account_id, name)
+);
+
+CREATE OR REPLACE TRIGGER set_updated_at BEFORE
+UPDATE ON wishlists FOR EACH ROW
+EXECUTE FUNCTION update_updated_at_column ();
+
+CREATE TABLE IF NOT EXISTS wishlist_items (
+    wishlist_id UUID NOT NULL REFERENCES wishlists (id) ON DELETE CASCADE,
+    product_id VARCHAR(64) NOT NULL,
+    added_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (wishlist_id, product_id)
+);
@@ -0,0 +1,25 @@
+CREATE TABLE IF NOT EXISTS account_addresses (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4 (),
+    account_id UUID NOT NULL REFERENCES accounts (id),
+    type VARCHAR(16) NOT NULL CHECK (type IN ('shipping', 'billing')),
+    is_default BOOLEAN NOT NULL DEFAULT FALSE,
+    name VARCHAR(255) NOT NULL,
+    line1 VARCHAR(255) NOT NULL,
+    line2 VARCHAR(255) NOT NULL DEFAULT '',
+    city VARCHAR(255) NOT NULL,
+    region VARCHAR(255) NOT NULL DEFAULT '',
+    postal_code VARCHAR(16) NOT NULL DEFAULT '',
+    country CHAR(2) NOT NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_account_addresses_account_id ON account_addresses (account_id, created_at);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_account_addresses_default ON account_addresses (account_id, type)
+WHERE
+    is_default;
+
+CREATE OR REPLACE TRIGGER set_updated_at BEFORE
+UPDATE ON account_addresses FOR EACH ROW
+EXECUTE FUNCTION update_updated_at_column ();
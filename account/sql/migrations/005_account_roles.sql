@@ -0,0 +1,2 @@
+ALTER TABLE accounts
+ADD COLUMN IF NOT EXISTS role VARCHAR(16) NOT NULL DEFAULT 'customer' CHECK (role IN ('customer', 'staff', 'admin'));
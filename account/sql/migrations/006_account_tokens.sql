@@ -0,0 +1,18 @@
+ALTER TABLE accounts ADD COLUMN IF NOT EXISTS email_verified_at TIMESTAMP;
+
+ALTER TABLE accounts ADD COLUMN IF NOT EXISTS password_hash VARCHAR(255);
+
+CREATE TABLE IF NOT EXISTS account_tokens (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4 (),
+    account_id UUID NOT NULL REFERENCES accounts (id),
+    purpose VARCHAR(32) NOT NULL CHECK (purpose IN ('email_verification', 'password_reset')),
+    token_hash CHAR(64) NOT NULL UNIQUE,
+    email VARCHAR(255) NOT NULL,
+    expires_at TIMESTAMP NOT NULL,
+    used_at TIMESTAMP,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_account_tokens_account_id ON account_tokens (account_id, purpose)
+WHERE
+    used_at IS NULL;
@@ -0,0 +1,14 @@
+CREATE TABLE IF NOT EXISTS account_outbox (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4 (),
+    topic VARCHAR(64) NOT NULL,
+    aggregate_id UUID NOT NULL,
+    payload JSONB NOT NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT clock_timestamp(),
+    published_at TIMESTAMP,
+    attempts INT NOT NULL DEFAULT 0,
+    last_error TEXT
+);
+
+CREATE INDEX IF NOT EXISTS idx_account_outbox_pending ON account_outbox (created_at)
+WHERE
+    published_at IS NULL;
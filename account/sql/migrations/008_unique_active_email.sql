@@ -0,0 +1,5 @@
+ALTER TABLE accounts DROP CONSTRAINT IF EXISTS accounts_email_key;
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_accounts_email_active ON accounts (email)
+WHERE
+    deleted_at IS NULL;
@@ -0,0 +1,100 @@
+package account
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// CreateAccountToken stores the hash of a new token and invalidates the
+// unused tokens issued earlier to the account for the same purpose, so that
+// only the most recent email works.
+func (repository *accountRepository) CreateAccountToken(ctx context.Context, accountID, email string, purpose TokenPurpose, tokenHash string, ttl time.Duration) error {
+	tx, err := repository.db.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	query := `
+        UPDATE account_tokens
+        SET used_at = CURRENT_TIMESTAMP
+        WHERE account_id = $1 AND purpose = $2 AND used_at IS NULL`
+	if _, err := tx.Exec(ctx, query, accountID, purpose); err != nil {
+		return fmt.Errorf("failed to invalidate tokens: %w", err)
+	}
+
+	query = `
+        INSERT INTO account_tokens (account_id, purpose, token_hash, email, expires_at)
+        VALUES ($1, $2, $3, $4, CURRENT_TIMESTAMP + make_interval(secs => $5))`
+	if _, err := tx.Exec(ctx, query, accountID, purpose, tokenHash, email, ttl.Seconds()); err != nil {
+		return fmt.Errorf("failed to create token: %w", err)
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return nil
+}
+
+func (repository *accountRepository) VerifyEmail(ctx context.Context, tokenHash string) (Account, error) {
+	query := `
+        UPDATE accounts
+        SET email_verified_at = CURRENT_TIMESTAMP
+        WHERE id = $1 AND email = $2 AND deleted_at IS NULL
+        RETURNING id, email, name, role, email_verified_at, created_at, updated_at, deleted_at`
+	return repository.redeemToken(ctx, TokenPurposeEmailVerification, AuditActionVerifyEmail, tokenHash, query)
+}
+
+func (repository *accountRepository) ResetPassword(ctx context.Context, tokenHash, passwordHash string) (Account, error) {
+	query := `
+        UPDATE accounts
+        SET password_hash = $3
+        WHERE id = $1 AND email = $2 AND deleted_at IS NULL
+        RETURNING id, email, name, role, email_verified_at, created_at, updated_at, deleted_at`
+	return repository.redeemToken(ctx, TokenPurposePasswordReset, AuditActionResetPassword, tokenHash, query, passwordHash)
+}
+
+// redeemToken marks the token as used and applies updateQuery to the account
+// it was issued to, with the account ID and the email the token was sent to
+// as the first two arguments. Tokens sent to an email the account no longer
+// has are rejected like unknown ones.
+func (repository *accountRepository) redeemToken(ctx context.Context, purpose TokenPurpose, action, tokenHash, updateQuery string, args ...any) (Account, error) {
+	tx, err := repository.db.Begin(ctx)
+	if err != nil {
+		return Account{}, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	var accountID, email string
+	query := `
+        UPDATE account_tokens
+        SET used_at = CURRENT_TIMESTAMP
+        WHERE token_hash = $1 AND purpose = $2 AND used_at IS NULL AND expires_at > CURRENT_TIMESTAMP
+        RETURNING account_id::text, email`
+	err = tx.QueryRow(ctx, query, tokenHash, purpose).Scan(&accountID, &email)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return Account{}, ErrInvalidToken
+	}
+	if err != nil {
+		return Account{}, fmt.Errorf("failed to redeem token: %w", err)
+	}
+
+	// Holding the token proves the request comes from the account itself.
+	ctx = ContextWithActor(ctx, accountID)
+	account, err := modifyAccountInTx(ctx, tx, action, updateQuery, append([]any{accountID, email}, args...)...)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return Account{}, ErrInvalidToken
+	}
+	if err != nil {
+		return Account{}, err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return Account{}, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return account, nil
+}
@@ -1,15 +1,176 @@
 package account
 
 import (
+	"encoding/json"
+	"errors"
 	"time"
 
 	"github.com/google/uuid"
 )
 
+var (
+	ErrWishlistNotFound     = errors.New("wishlist not found")
+	ErrWishlistNameTaken    = errors.New("wishlist name is already used")
+	ErrWishlistItemNotFound = errors.New("wishlist item not found")
+	ErrAddressNotFound      = errors.New("address not found")
+	ErrPermissionDenied     = errors.New("only admins can change account roles")
+	ErrUpdateDenied         = errors.New("only the account itself or an admin can update an account")
+	ErrDeleteDenied         = errors.New("only the account itself or an admin can delete an account")
+	ErrRestoreDenied        = errors.New("only admins can restore accounts")
+	ErrHistoryDenied        = errors.New("only the account itself, staff and admins can read the history of an account")
+	ErrVerificationDenied   = errors.New("only the account itself can request a verification email")
+	ErrLastAdmin            = errors.New("the last admin cannot be demoted or deleted")
+	ErrEmailAlreadyVerified = errors.New("email is already verified")
+	ErrEmailTaken           = errors.New("email is already used by another account")
+	ErrInvalidToken         = errors.New("token is invalid, expired or already used")
+)
+
+type Role string
+
+const (
+	RoleCustomer Role = "customer"
+	RoleStaff    Role = "staff"
+	RoleAdmin    Role = "admin"
+)
+
 type Account struct {
-	ID        uuid.UUID `json:"id"`
-	Email     string    `json:"email"`
-	Name      string    `json:"name"`
-	CreatedAt time.Time `json:"created_at"`
-	UpdatedAt time.Time `json:"updated_at"`
+	ID              uuid.UUID  `json:"id"`
+	Email           string     `json:"email"`
+	Name            string     `json:"name"`
+	Role            Role       `json:"role"`
+	EmailVerifiedAt *time.Time `json:"email_verified_at,omitempty"`
+	CreatedAt       time.Time  `json:"created_at"`
+	UpdatedAt       time.Time  `json:"updated_at"`
+	DeletedAt       *time.Time `json:"deleted_at,omitempty"`
+}
+
+const (
+	AuditActionCreate  = "create"
+	AuditActionUpdate  = "update"
+	AuditActionDelete  = "delete"
+	AuditActionRestore = "restore"
+	AuditActionSetRole = "set_role"
+
+	AuditActionVerifyEmail   = "verify_email"
+	AuditActionResetPassword = "reset_password"
+)
+
+// Topics of the domain events published through the outbox. The payload of
+// every event is the account after the change.
+const (
+	TopicAccountCreated       = "account.created"
+	TopicAccountUpdated       = "account.updated"
+	TopicAccountDeleted       = "account.deleted"
+	TopicAccountRestored      = "account.restored"
+	TopicAccountRoleChanged   = "account.role_changed"
+	TopicAccountEmailVerified = "account.email_verified"
+	TopicAccountPasswordReset = "account.password_reset"
+)
+
+type AuditEntry struct {
+	ID        uuid.UUID       `json:"id"`
+	AccountID uuid.UUID       `json:"account_id"`
+	Action    string          `json:"action"`
+	Actor     string          `json:"actor"`
+	Before    json.RawMessage `json:"before,omitempty"`
+	After     json.RawMessage `json:"after,omitempty"`
+	CreatedAt time.Time       `json:"created_at"`
+}
+
+type AccountFilter struct {
+	Name          string
+	Email         string
+	CreatedAfter  *time.Time
+	CreatedBefore *time.Time
+}
+
+type AccountOrderField string
+
+const (
+	AccountOrderByCreatedAt AccountOrderField = "created_at"
+	AccountOrderByName      AccountOrderField = "name"
+	AccountOrderByEmail     AccountOrderField = "email"
+)
+
+type AccountOrderBy struct {
+	Field      AccountOrderField
+	Descending bool
+}
+
+// Wishlist is a named list of products kept by an account. Items only hold
+// product IDs; the products themselves live in the product service and may
+// have been removed since they were added.
+type Wishlist struct {
+	ID        uuid.UUID      `json:"id"`
+	AccountID uuid.UUID      `json:"account_id"`
+	Name      string         `json:"name"`
+	Items     []WishlistItem `json:"items"`
+	CreatedAt time.Time      `json:"created_at"`
+	UpdatedAt time.Time      `json:"updated_at"`
+}
+
+type WishlistItem struct {
+	ProductID string    `json:"product_id"`
+	AddedAt   time.Time `json:"added_at"`
+}
+
+type AddressType string
+
+const (
+	AddressTypeShipping AddressType = "shipping"
+	AddressTypeBilling  AddressType = "billing"
+)
+
+// Address is a postal address of an account. An account has at most one
+// default address per type; the first address of a type becomes the default
+// automatically.
+type Address struct {
+	ID         uuid.UUID   `json:"id"`
+	AccountID  uuid.UUID   `json:"account_id"`
+	Type       AddressType `json:"type"`
+	IsDefault  bool        `json:"is_default"`
+	Name       string      `json:"name"`
+	Line1      string      `json:"line1"`
+	Line2      string      `json:"line2"`
+	City       string      `json:"city"`
+	Region     string      `json:"region"`
+	PostalCode string      `json:"postal_code"`
+	Country    string      `json:"country"`
+	CreatedAt  time.Time   `json:"created_at"`
+	UpdatedAt  time.Time   `json:"updated_at"`
+}
+
+// AddressInput holds the writable fields of an address. Country is an
+// ISO 3166-1 alpha-2 code.
+type AddressInput struct {
+	Type       AddressType
+	IsDefault  bool
+	Name       string
+	Line1      string
+	Line2      string
+	City       string
+	Region     string
+	PostalCode string
+	Country    string
+}
+
+type TokenPurpose string
+
+const (
+	TokenPurposeEmailVerification TokenPurpose = "email_verification"
+	TokenPurposePasswordReset     TokenPurpose = "password_reset"
+)
+
+type AccountEventType string
+
+const (
+	AccountEventCreated AccountEventType = "created"
+	AccountEventUpdated AccountEventType = "updated"
+)
+
+// AccountEvent is published to the WatchAccounts streams after an account
+// was created or updated.
+type AccountEvent struct {
+	Type    AccountEventType
+	Account Account
 }
@@ -0,0 +1,206 @@
+package account
+
+import (
+	"net/mail"
+	"strings"
+	"unicode/utf8"
+
+	"graphql-grpc-go-microservice-project/common"
+
+	"github.com/google/uuid"
+)
+
+// maxFieldLength matches the VARCHAR(255) columns of the accounts table.
+const maxFieldLength = 255
+
+// Passwords are hashed with bcrypt, which ignores everything after 72 bytes.
+const (
+	minPasswordLength = 8
+	maxPasswordLength = 72
+)
+
+// maxProductIDLength matches the VARCHAR(64) product_id column of the
+// wishlist_items table.
+const maxProductIDLength = 64
+
+func normalizeEmail(email string) string {
+	return strings.ToLower(strings.TrimSpace(email))
+}
+
+// validateAccountInput normalizes the email and name of an account and
+// reports every field that does not fit the accounts table.
+func validateAccountInput(email, name string) (string, string, error) {
+	email = normalizeEmail(email)
+	name = strings.TrimSpace(name)
+
+	violations := &common.ValidationError{}
+	validateEmail(violations, email)
+
+	switch {
+	case name == "":
+		violations.Add("name", "must not be empty")
+	case utf8.RuneCountInString(name) > maxFieldLength:
+		violations.Add("name", "must be at most 255 characters")
+	}
+
+	return email, name, violations.Err()
+}
+
+func validateEmail(violations *common.ValidationError, email string) {
+	if email == "" {
+		violations.Add("email", "must not be empty")
+		return
+	}
+
+	if utf8.RuneCountInString(email) > maxFieldLength {
+		violations.Add("email", "must be at most 255 characters")
+		return
+	}
+
+	address, err := mail.ParseAddress(email)
+	if err != nil || address.Address != email || !strings.Contains(email[strings.LastIndex(email, "@")+1:], ".") {
+		violations.Add("email", "must be a valid email address")
+	}
+}
+
+func validateWishlistInput(accountID, name string) (string, error) {
+	name = strings.TrimSpace(name)
+
+	violations := &common.ValidationError{}
+	validateUUID(violations, "accountId", accountID)
+
+	switch {
+	case name == "":
+		violations.Add("name", "must not be empty")
+	case utf8.RuneCountInString(name) > maxFieldLength:
+		violations.Add("name", "must be at most 255 characters")
+	}
+
+	return name, violations.Err()
+}
+
+func validateSetAccountRole(id string, role Role) error {
+	violations := &common.ValidationError{}
+	validateUUID(violations, "id", id)
+
+	switch role {
+	case RoleCustomer, RoleStaff, RoleAdmin:
+	default:
+		violations.Add("role", "must be customer, staff or admin")
+	}
+
+	return violations.Err()
+}
+
+func validateToken(token string) error {
+	violations := &common.ValidationError{}
+	if token == "" {
+		violations.Add("token", "must not be empty")
+	}
+	return violations.Err()
+}
+
+func validatePasswordReset(token, password string) error {
+	violations := &common.ValidationError{}
+	if token == "" {
+		violations.Add("token", "must not be empty")
+	}
+
+	switch {
+	case utf8.RuneCountInString(password) < minPasswordLength:
+		violations.Add("password", "must be at least 8 characters")
+	case len(password) > maxPasswordLength:
+		violations.Add("password", "must be at most 72 bytes")
+	}
+
+	return violations.Err()
+}
+
+func validateAccountID(accountID string) error {
+	violations := &common.ValidationError{}
+	validateUUID(violations, "accountId", accountID)
+	return violations.Err()
+}
+
+func validateWishlistItem(accountID, wishlistID, productID string) (string, error) {
+	productID = strings.TrimSpace(productID)
+
+	violations := &common.ValidationError{}
+	validateUUID(violations, "accountId", accountID)
+	validateUUID(violations, "wishlistId", wishlistID)
+
+	switch {
+	case productID == "":
+		violations.Add("productId", "must not be empty")
+	case len(productID) > maxProductIDLength:
+		violations.Add("productId", "must be at most 64 characters")
+	}
+
+	return productID, violations.Err()
+}
+
+// validateAddressInput normalizes an address and reports every field that
+// does not fit the account_addresses table. The postal code is checked
+// against the format of the given country when one is known.
+func validateAddressInput(accountID string, input AddressInput) (AddressInput, error) {
+	input.Name = strings.TrimSpace(input.Name)
+	input.Line1 = strings.TrimSpace(input.Line1)
+	input.Line2 = strings.TrimSpace(input.Line2)
+	input.City = strings.TrimSpace(input.City)
+	input.Region = strings.TrimSpace(input.Region)
+	input.PostalCode = strings.ToUpper(strings.TrimSpace(input.PostalCode))
+	input.Country = strings.ToUpper(strings.TrimSpace(input.Country))
+
+	violations := &common.ValidationError{}
+	validateUUID(violations, "accountId", accountID)
+
+	if input.Type != AddressTypeShipping && input.Type != AddressTypeBilling {
+		violations.Add("type", "must be shipping or billing")
+	}
+
+	validateAddressField(violations, "name", input.Name, true)
+	validateAddressField(violations, "line1", input.Line1, true)
+	validateAddressField(violations, "line2", input.Line2, false)
+	validateAddressField(violations, "city", input.City, true)
+	validateAddressField(violations, "region", input.Region, false)
+
+	if !countryCodes[input.Country] {
+		violations.Add("country", "must be an ISO 3166-1 alpha-2 country code")
+		return input, violations.Err()
+	}
+
+	switch format, ok := postalCodeFormats[input.Country]; {
+	case input.PostalCode == "":
+		if !countriesWithoutPostalCodes[input.Country] {
+			violations.Add("postalCode", "must not be empty")
+		}
+	case ok && !format.MatchString(input.PostalCode):
+		violations.Add("postalCode", "is not a valid postal code for "+input.Country)
+	case !ok && !postalCodeFormat.MatchString(input.PostalCode):
+		violations.Add("postalCode", "must be 3 to 16 letters, digits, spaces or dashes")
+	}
+
+	return input, violations.Err()
+}
+
+func validateAddressField(violations *common.ValidationError, field, value string, required bool) {
+	switch {
+	case required && value == "":
+		violations.Add(field, "must not be empty")
+	case utf8.RuneCountInString(value) > maxFieldLength:
+		violations.Add(field, "must be at most 255 characters")
+	}
+}
+
+func validateAddressOwner(accountID, addressID string) error {
+	violations := &common.ValidationError{}
+	validateUUID(violations, "accountId", accountID)
+	validateUUID(violations, "addressId", addressID)
+	return violations.Err()
+}
+
+func validateUUID(violations *common.ValidationError, field, value string) {
+	if _, err := uuid.Parse(value); err != nil {
+		violations.Add(field, "must be a valid UUID")
+	}
+}
@@ -0,0 +1,159 @@
+package account
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+// uniqueViolation is the PostgreSQL error code for a violated unique
+// constraint.
+const uniqueViolation = "23505"
+
+func isUniqueViolation(err error) bool {
+	var pgErr *pgconn.PgError
+	return errors.As(err, &pgErr) && pgErr.Code == uniqueViolation
+}
+
+func (repository *accountRepository) CreateWishlist(ctx context.Context, accountID, name string) (Wishlist, error) {
+	wishlist := Wishlist{Items: []WishlistItem{}}
+	query := `
+        INSERT INTO wishlists (account_id, name)
+        VALUES ($1, $2)
+        RETURNING id, account_id, name, created_at, updated_at`
+	err := repository.db.QueryRow(ctx, query, accountID, name).Scan(&wishlist.ID, &wishlist.AccountID, &wishlist.Name, &wishlist.CreatedAt, &wishlist.UpdatedAt)
+	if isUniqueViolation(err) {
+		return Wishlist{}, ErrWishlistNameTaken
+	}
+	if err != nil {
+		return Wishlist{}, fmt.Errorf("failed to create wishlist: %w", err)
+	}
+	return wishlist, nil
+}
+
+// GetWishlist returns ErrWishlistNotFound unless the wishlist belongs to the
+// account.
+func (repository *accountRepository) GetWishlist(ctx context.Context, accountID, wishlistID string) (Wishlist, error) {
+	var wishlist Wishlist
+	query := `
+        SELECT id, account_id, name, created_at, updated_at
+        FROM wishlists
+        WHERE id = $1 AND account_id = $2`
+	err := repository.db.QueryRow(ctx, query, wishlistID, accountID).Scan(&wishlist.ID, &wishlist.AccountID, &wishlist.Name, &wishlist.CreatedAt, &wishlist.UpdatedAt)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return Wishlist{}, ErrWishlistNotFound
+	}
+	if err != nil {
+		return Wishlist{}, fmt.Errorf("failed to get wishlist: %w", err)
+	}
+
+	lists := []Wishlist{wishlist}
+	if err := repository.loadWishlistItems(ctx, lists); err != nil {
+		return Wishlist{}, err
+	}
+	return lists[0], nil
+}
+
+func (repository *accountRepository) ListWishlists(ctx context.Context, accountID string) ([]Wishlist, error) {
+	query := `
+        SELECT id, account_id, name, created_at, updated_at
+        FROM wishlists
+        WHERE account_id = $1
+        ORDER BY created_at, id`
+	rows, err := repository.db.Query(ctx, query, accountID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list wishlists: %w", err)
+	}
+	defer rows.Close()
+
+	wishlists := []Wishlist{}
+	for rows.Next() {
+		var wishlist Wishlist
+		if err := rows.Scan(&wishlist.ID, &wishlist.AccountID, &wishlist.Name, &wishlist.CreatedAt, &wishlist.UpdatedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan wishlist row: %w", err)
+		}
+		wishlists = append(wishlists, wishlist)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating over wishlist rows: %w", err)
+	}
+
+	if err := repository.loadWishlistItems(ctx, wishlists); err != nil {
+		return nil, err
+	}
+	return wishlists, nil
+}
+
+// AddWishlistItem is a no-op when the product is already on the list.
+func (repository *accountRepository) AddWishlistItem(ctx context.Context, wishlistID, productID string) error {
+	query := `
+        INSERT INTO wishlist_items (wishlist_id, product_id)
+        VALUES ($1, $2)
+        ON CONFLICT (wishlist_id, product_id) DO NOTHING`
+	if _, err := repository.db.Exec(ctx, query, wishlistID, productID); err != nil {
+		return fmt.Errorf("failed to add wishlist item: %w", err)
+	}
+	return repository.touchWishlist(ctx, wishlistID)
+}
+
+func (repository *accountRepository) RemoveWishlistItem(ctx context.Context, wishlistID, productID string) error {
+	tag, err := repository.db.Exec(ctx, `DELETE FROM wishlist_items WHERE wishlist_id = $1 AND product_id = $2`, wishlistID, productID)
+	if err != nil {
+		return fmt.Errorf("failed to remove wishlist item: %w", err)
+	}
+	if tag.RowsAffected() == 0 {
+		return ErrWishlistItemNotFound
+	}
+	return repository.touchWishlist(ctx, wishlistID)
+}
+
+func (repository *accountRepository) touchWishlist(ctx context.Context, wishlistID string) error {
+	if _, err := repository.db.Exec(ctx, `UPDATE wishlists SET updated_at = CURRENT_TIMESTAMP WHERE id = $1`, wishlistID); err != nil {
+		return fmt.Errorf("failed to update wishlist: %w", err)
+	}
+	return nil
+}
+
+// loadWishlistItems fills in the items of all given wishlists with a single
+// query.
+func (repository *accountRepository) loadWishlistItems(ctx context.Context, wishlists []Wishlist) error {
+	if len(wishlists) == 0 {
+		return nil
+	}
+
+	ids := make([]string, 0, len(wishlists))
+	indexes := make(map[string]int, len(wishlists))
+	for i := range wishlists {
+		wishlists[i].Items = []WishlistItem{}
+		ids = append(ids, wishlists[i].ID.String())
+		indexes[wishlists[i].ID.String()] = i
+	}
+
+	query := `
+        SELECT wishlist_id, product_id, added_at
+        FROM wishlist_items
+        WHERE wishlist_id = ANY($1::uuid[])
+        ORDER BY added_at, product_id`
+	rows, err := repository.db.Query(ctx, query, ids)
+	if err != nil {
+		return fmt.Errorf("failed to get wishlist items: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var wishlistID string
+		var item WishlistItem
+		if err := rows.Scan(&wishlistID, &item.ProductID, &item.AddedAt); err != nil {
+			return fmt.Errorf("failed to scan wishlist item row: %w", err)
+		}
+		i := indexes[wishlistID]
+		wishlists[i].Items = append(wishlists[i].Items, item)
+	}
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("error iterating over wishlist item rows: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,213 @@
+package cart
+
+import (
+	"context"
+	"crypto/tls"
+	"time"
+
+	"go.uber.org/zap"
+
+	"graphql-grpc-go-microservice-project/cart/protobuf"
+
+	"graphql-grpc-go-microservice-project/common"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/credentials/insecure"
+)
+
+type CartClient struct {
+	conn    *grpc.ClientConn
+	service protobuf.CartServiceClient
+	logger  *zap.Logger
+}
+
+func NewCartClient(url string, secure bool) (*CartClient, error) {
+	logger := common.GetLogger()
+
+	var opts []grpc.DialOption
+	if secure {
+		creds := credentials.NewTLS(&tls.Config{
+			InsecureSkipVerify: false,
+		})
+		opts = append(opts, grpc.WithTransportCredentials(creds))
+	} else {
+		opts = append(opts, grpc.WithTransportCredentials(insecure.NewCredentials()))
+	}
+
+	opts = append(opts, grpc.WithChainUnaryInterceptor(common.ServiceErrorUnaryInterceptor()))
+
+	conn, err := grpc.NewClient(url, opts...)
+	if err != nil {
+		logger.Error("Failed to connect to gRPC server", zap.String("url", url), zap.String("error", err.Error()))
+		return nil, err
+	}
+
+	logger.Info("Connected to gRPC server", zap.String("url", url))
+
+	client := protobuf.NewCartServiceClient(conn)
+
+	return &CartClient{conn: conn, service: client, logger: logger}, nil
+}
+
+func (c *CartClient) Close() error {
+	c.logger.Info("Closing gRPC connection")
+	return c.conn.Close()
+}
+
+func (c *CartClient) GetCart(ctx context.Context, owner CartOwner) (*Cart, error) {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	c.logger.Info("GetCart request received", zap.String("account_id", owner.AccountID), zap.String("cart_id", owner.CartID))
+
+	r, err := c.service.GetCart(ctx, &protobuf.GetCartRequest{
+		Owner: ownerToProto(owner),
+	})
+	if err != nil {
+		c.logger.Error("Failed to fetch cart", zap.String("account_id", owner.AccountID), zap.String("cart_id", owner.CartID), zap.String("error", err.Error()))
+		return nil, err
+	}
+
+	c.logger.Info("Cart fetched successfully", zap.String("cart_id", r.GetCart().GetId()), zap.Int("item_count", len(r.GetCart().GetItems())))
+
+	return cartFromProto(r.GetCart()), nil
+}
+
+func (c *CartClient) AddItem(ctx context.Context, owner CartOwner, productID string, quantity int32) (*Cart, error) {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	c.logger.Info("AddCartItem request received", zap.String("account_id", owner.AccountID), zap.String("cart_id", owner.CartID), zap.String("product_id", productID), zap.Int32("quantity", quantity))
+
+	r, err := c.service.AddCartItem(ctx, &protobuf.AddCartItemRequest{
+		Owner:     ownerToProto(owner),
+		ProductId: productID,
+		Quantity:  quantity,
+	})
+	if err != nil {
+		c.logger.Error("Failed to add cart item", zap.String("account_id", owner.AccountID), zap.String("cart_id", owner.CartID), zap.String("error", err.Error()))
+		return nil, err
+	}
+
+	c.logger.Info("Cart item added successfully", zap.String("cart_id", r.GetCart().GetId()), zap.Int("item_count", len(r.GetCart().GetItems())))
+
+	return cartFromProto(r.GetCart()), nil
+}
+
+func (c *CartClient) UpdateItem(ctx context.Context, owner CartOwner, productID string, quantity int32) (*Cart, error) {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	c.logger.Info("UpdateCartItem request received", zap.String("account_id", owner.AccountID), zap.String("cart_id", owner.CartID), zap.String("product_id", productID), zap.Int32("quantity", quantity))
+
+	r, err := c.service.UpdateCartItem(ctx, &protobuf.UpdateCartItemRequest{
+		Owner:     ownerToProto(owner),
+		ProductId: productID,
+		Quantity:  quantity,
+	})
+	if err != nil {
+		c.logger.Error("Failed to update cart item", zap.String("account_id", owner.AccountID), zap.String("cart_id", owner.CartID), zap.String("error", err.Error()))
+		return nil, err
+	}
+
+	c.logger.Info("Cart item updated successfully", zap.String("cart_id", r.GetCart().GetId()), zap.Int("item_count", len(r.GetCart().GetItems())))
+
+	return cartFromProto(r.GetCart()), nil
+}
+
+func (c *CartClient) RemoveItem(ctx context.Context, owner CartOwner, productID string) (*Cart, error) {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	c.logger.Info("RemoveCartItem request received", zap.String("account_id", owner.AccountID), zap.String("cart_id", owner.CartID), zap.String("product_id", productID))
+
+	r, err := c.service.RemoveCartItem(ctx, &protobuf.RemoveCartItemRequest{
+		Owner:     ownerToProto(owner),
+		ProductId: productID,
+	})
+	if err != nil {
+		c.logger.Error("Failed to remove cart item", zap.String("account_id", owner.AccountID), zap.String("cart_id", owner.CartID), zap.String("error", err.Error()))
+		return nil, err
+	}
+
+	c.logger.Info("Cart item removed successfully", zap.String("cart_id", r.GetCart().GetId()), zap.Int("item_count", len(r.GetCart().GetItems())))
+
+	return cartFromProto(r.GetCart()), nil
+}
+
+func (c *CartClient) ClearCart(ctx context.Context, owner CartOwner) (*Cart, error) {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	c.logger.Info("ClearCart request received", zap.String("account_id", owner.AccountID), zap.String("cart_id", owner.CartID))
+
+	r, err := c.service.ClearCart(ctx, &protobuf.ClearCartRequest{
+		Owner: ownerToProto(owner),
+	})
+	if err != nil {
+		c.logger.Error("Failed to clear cart", zap.String("account_id", owner.AccountID), zap.String("cart_id", owner.CartID), zap.String("error", err.Error()))
+		return nil, err
+	}
+
+	c.logger.Info("Cart cleared successfully", zap.String("cart_id", r.GetCart().GetId()), zap.Int("item_count", len(r.GetCart().GetItems())))
+
+	return cartFromProto(r.GetCart()), nil
+}
+
+func (c *CartClient) MergeCarts(ctx context.Context, cartID, accountID string) (*Cart, error) {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	c.logger.Info("MergeCarts request received", zap.String("cart_id", cartID), zap.String("account_id", accountID))
+
+	r, err := c.service.MergeCarts(ctx, &protobuf.MergeCartsRequest{
+		CartId:    cartID,
+		AccountId: accountID,
+	})
+	if err != nil {
+		c.logger.Error("Failed to merge carts", zap.String("cart_id", cartID), zap.String("account_id", accountID), zap.String("error", err.Error()))
+		return nil, err
+	}
+
+	c.logger.Info("Carts merged successfully", zap.String("cart_id", r.GetCart().GetId()), zap.Int("item_count", len(r.GetCart().GetItems())))
+
+	return cartFromProto(r.GetCart()), nil
+}
+
+func ownerToProto(o CartOwner) *protobuf.CartOwner {
+	return &protobuf.CartOwner{AccountId: o.AccountID, CartId: o.CartID}
+}
+
+func moneyFromProto(m *protobuf.Money) common.Money {
+	return common.Money{Amount: m.GetAmount(), CurrencyCode: m.GetCurrencyCode()}
+}
+
+func cartFromProto(c *protobuf.Cart) *Cart {
+	items := make([]CartItem, 0, len(c.GetItems()))
+	for _, item := range c.GetItems() {
+		items = append(items, CartItem{
+			ProductID: item.GetProductId(),
+			Quantity:  item.GetQuantity(),
+			AddedAt:   item.GetAddedAt().AsTime(),
+			Name:      item.GetName(),
+			UnitPrice: moneyFromProto(item.GetUnitPrice()),
+			Subtotal:  moneyFromProto(item.GetSubtotal()),
+			Available: item.GetAvailable(),
+		})
+	}
+
+	totals := make([]common.Money, 0, len(c.GetTotals()))
+	for _, total := range c.GetTotals() {
+		totals = append(totals, moneyFromProto(total))
+	}
+
+	return &Cart{
+		ID:        c.GetId(),
+		AccountID: c.GetAccountId(),
+		Items:     items,
+		Totals:    totals,
+		CreatedAt: c.GetCreatedAt().AsTime(),
+		UpdatedAt: c.GetUpdatedAt().AsTime(),
+	}
+}
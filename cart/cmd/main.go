@@ -0,0 +1,59 @@
+package main
+
+import (
+	"log"
+	"time"
+
+	"graphql-grpc-go-microservice-project/cart"
+	"graphql-grpc-go-microservice-project/common"
+	"graphql-grpc-go-microservice-project/product"
+
+	"github.com/kelseyhightower/envconfig"
+	"github.com/tinrab/retry"
+)
+
+type Config struct {
+	CART_GRPC_SERVER_PORT int    `envconfig:"CART_GRPC_SERVER_PORT" default:"8080"`
+	CART_DATABASE_URL     string `envconfig:"CART_DATABASE_URL"`
+	PRODUCT_SERVICE_URL   string `envconfig:"PRODUCT_SERVICE_URL" required:"true"`
+}
+
+func main() {
+	var cfg Config
+	if err := envconfig.Process("", &cfg); err != nil {
+		log.Fatalf("Error processing environment variables: %v", err)
+	}
+
+	var repo cart.CartRepository
+	retry.ForeverSleep(2*time.Second, func(_ int) error {
+		var err error
+		repo, err = cart.NewCartRepository(cfg.CART_DATABASE_URL)
+		if err != nil {
+			log.Printf("Database connection failed: %v", err)
+		}
+		return err
+	})
+
+	defer func() {
+		if err := repo.Close(); err != nil {
+			log.Printf("Error closing repository: %v", err)
+		}
+	}()
+
+	productClient, err := product.NewProductClient(cfg.PRODUCT_SERVICE_URL, false, common.DefaultClientConfig())
+	if err != nil {
+		log.Fatalf("Failed to create product client: %v", err)
+	}
+	defer productClient.Close()
+
+	log.Println("Initializing cart service...")
+	service, err := cart.NewCartService(repo, productClient)
+	if err != nil {
+		log.Fatalf("Failed to create cart service: %v", err)
+	}
+
+	log.Printf("Starting gRPC server on port %d...", cfg.CART_GRPC_SERVER_PORT)
+	if err := cart.ListenGRPC(service, cfg.CART_GRPC_SERVER_PORT, false); err != nil {
+		log.Fatalf("Failed to start gRPC server: %v", err)
+	}
+}
@@ -0,0 +1,242 @@
+package cart
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// maxItemQuantity caps the quantity of a single product in a cart, including
+// after carts are merged.
+const maxItemQuantity = 999
+
+type CartRepository interface {
+	Close() error
+	GetCart(ctx context.Context, owner CartOwner) (Cart, error)
+	EnsureCart(ctx context.Context, owner CartOwner) (Cart, error)
+	AddItem(ctx context.Context, cartID, productID string, quantity int32) error
+	SetItemQuantity(ctx context.Context, cartID, productID string, quantity int32) error
+	RemoveItem(ctx context.Context, cartID, productID string) error
+	ClearCart(ctx context.Context, cartID string) error
+	MergeCarts(ctx context.Context, cartID, accountID string) error
+}
+
+type cartRepository struct {
+	db *pgxpool.Pool
+}
+
+func NewCartRepository(connString string) (CartRepository, error) {
+	config, err := pgxpool.ParseConfig(connString)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse database connection string: %w", err)
+	}
+
+	config.MaxConns = 25
+	config.MaxConnIdleTime = 5 * time.Minute
+
+	db, err := pgxpool.NewWithConfig(context.Background(), config)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create connection pool: %w", err)
+	}
+
+	if err := db.Ping(context.Background()); err != nil {
+		return nil, fmt.Errorf("failed to ping database: %w", err)
+	}
+
+	return &cartRepository{db}, nil
+}
+
+func (repository *cartRepository) Close() error {
+	repository.db.Close()
+	return nil
+}
+
+// GetCart returns ErrCartNotFound when the owner has no cart yet.
+func (repository *cartRepository) GetCart(ctx context.Context, owner CartOwner) (Cart, error) {
+	query := `
+        SELECT id, COALESCE(account_id::text, ''), created_at, updated_at
+        FROM carts
+        WHERE id = $1 AND account_id IS NULL`
+	key := owner.CartID
+	if !owner.IsAnonymous() {
+		query = `
+        SELECT id, COALESCE(account_id::text, ''), created_at, updated_at
+        FROM carts
+        WHERE account_id = $1`
+		key = owner.AccountID
+	}
+
+	var cart Cart
+	err := repository.db.QueryRow(ctx, query, key).Scan(&cart.ID, &cart.AccountID, &cart.CreatedAt, &cart.UpdatedAt)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return Cart{}, ErrCartNotFound
+	}
+	if err != nil {
+		return Cart{}, fmt.Errorf("failed to get cart: %w", err)
+	}
+
+	cart.Items, err = repository.cartItems(ctx, cart.ID)
+	if err != nil {
+		return Cart{}, err
+	}
+
+	return cart, nil
+}
+
+// EnsureCart returns the owner's cart, creating the account cart on first use
+// or a new anonymous cart when neither an account nor a cart is given.
+func (repository *cartRepository) EnsureCart(ctx context.Context, owner CartOwner) (Cart, error) {
+	switch {
+	case !owner.IsAnonymous():
+		query := `
+            INSERT INTO carts (account_id)
+            VALUES ($1)
+            ON CONFLICT (account_id) DO NOTHING`
+		if _, err := repository.db.Exec(ctx, query, owner.AccountID); err != nil {
+			return Cart{}, fmt.Errorf("failed to create cart: %w", err)
+		}
+	case owner.CartID == "":
+		var id string
+		if err := repository.db.QueryRow(ctx, `INSERT INTO carts DEFAULT VALUES RETURNING id`).Scan(&id); err != nil {
+			return Cart{}, fmt.Errorf("failed to create cart: %w", err)
+		}
+		owner.CartID = id
+	}
+
+	return repository.GetCart(ctx, owner)
+}
+
+func (repository *cartRepository) AddItem(ctx context.Context, cartID, productID string, quantity int32) error {
+	query := `
+        INSERT INTO cart_items (cart_id, product_id, quantity)
+        VALUES ($1, $2, LEAST($3, $4))
+        ON CONFLICT (cart_id, product_id)
+        DO UPDATE SET quantity = LEAST(cart_items.quantity + EXCLUDED.quantity, $4)`
+	if _, err := repository.db.Exec(ctx, query, cartID, productID, quantity, maxItemQuantity); err != nil {
+		return fmt.Errorf("failed to add cart item: %w", err)
+	}
+
+	return repository.touchCart(ctx, cartID)
+}
+
+func (repository *cartRepository) SetItemQuantity(ctx context.Context, cartID, productID string, quantity int32) error {
+	tag, err := repository.db.Exec(ctx, `UPDATE cart_items SET quantity = $3 WHERE cart_id = $1 AND product_id = $2`, cartID, productID, quantity)
+	if err != nil {
+		return fmt.Errorf("failed to update cart item: %w", err)
+	}
+	if tag.RowsAffected() == 0 {
+		return ErrCartItemNotFound
+	}
+
+	return repository.touchCart(ctx, cartID)
+}
+
+func (repository *cartRepository) RemoveItem(ctx context.Context, cartID, productID string) error {
+	tag, err := repository.db.Exec(ctx, `DELETE FROM cart_items WHERE cart_id = $1 AND product_id = $2`, cartID, productID)
+	if err != nil {
+		return fmt.Errorf("failed to remove cart item: %w", err)
+	}
+	if tag.RowsAffected() == 0 {
+		return ErrCartItemNotFound
+	}
+
+	return repository.touchCart(ctx, cartID)
+}
+
+func (repository *cartRepository) ClearCart(ctx context.Context, cartID string) error {
+	if _, err := repository.db.Exec(ctx, `DELETE FROM cart_items WHERE cart_id = $1`, cartID); err != nil {
+		return fmt.Errorf("failed to clear cart: %w", err)
+	}
+
+	return repository.touchCart(ctx, cartID)
+}
+
+// MergeCarts moves the items of an anonymous cart into the account's cart,
+// adding up quantities of products found in both, and deletes the anonymous
+// cart.
+func (repository *cartRepository) MergeCarts(ctx context.Context, cartID, accountID string) error {
+	tx, err := repository.db.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	var anonymousID string
+	err = tx.QueryRow(ctx, `SELECT id FROM carts WHERE id = $1 AND account_id IS NULL FOR UPDATE`, cartID).Scan(&anonymousID)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return ErrCartNotFound
+	}
+	if err != nil {
+		return fmt.Errorf("failed to get cart: %w", err)
+	}
+
+	var accountCartID string
+	query := `
+        INSERT INTO carts (account_id)
+        VALUES ($1)
+        ON CONFLICT (account_id) DO UPDATE SET updated_at = CURRENT_TIMESTAMP
+        RETURNING id`
+	if err := tx.QueryRow(ctx, query, accountID).Scan(&accountCartID); err != nil {
+		return fmt.Errorf("failed to create cart: %w", err)
+	}
+
+	query = `
+        INSERT INTO cart_items (cart_id, product_id, quantity, added_at)
+        SELECT $1, product_id, quantity, added_at
+        FROM cart_items
+        WHERE cart_id = $2
+        ON CONFLICT (cart_id, product_id)
+        DO UPDATE SET quantity = LEAST(cart_items.quantity + EXCLUDED.quantity, $3)`
+	if _, err := tx.Exec(ctx, query, accountCartID, anonymousID, maxItemQuantity); err != nil {
+		return fmt.Errorf("failed to merge cart items: %w", err)
+	}
+
+	if _, err := tx.Exec(ctx, `DELETE FROM carts WHERE id = $1`, anonymousID); err != nil {
+		return fmt.Errorf("failed to delete cart: %w", err)
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return nil
+}
+
+func (repository *cartRepository) touchCart(ctx context.Context, cartID string) error {
+	if _, err := repository.db.Exec(ctx, `UPDATE carts SET updated_at = CURRENT_TIMESTAMP WHERE id = $1`, cartID); err != nil {
+		return fmt.Errorf("failed to update cart: %w", err)
+	}
+	return nil
+}
+
+func (repository *cartRepository) cartItems(ctx context.Context, cartID string) ([]CartItem, error) {
+	query := `
+        SELECT product_id, quantity, added_at
+        FROM cart_items
+        WHERE cart_id = $1
+        ORDER BY added_at, product_id`
+	rows, err := repository.db.Query(ctx, query, cartID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get cart items: %w", err)
+	}
+	defer rows.Close()
+
+	items := []CartItem{}
+	for rows.Next() {
+		var item CartItem
+		if err := rows.Scan(&item.ProductID, &item.Quantity, &item.AddedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan cart item: %w", err)
+		}
+		items = append(items, item)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating over cart item rows: %w", err)
+	}
+
+	return items, nil
+}
@@ -0,0 +1,244 @@
+package cart
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"graphql-grpc-go-microservice-project/cart/protobuf"
+	"graphql-grpc-go-microservice-project/common"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/keepalive"
+	"google.golang.org/grpc/reflection"
+	"google.golang.org/protobuf/types/known/timestamppb"
+
+	"go.uber.org/zap"
+	grpcResponseCodes "google.golang.org/grpc/codes"
+	grpcResponseStatus "google.golang.org/grpc/status"
+)
+
+type cartGrpcServer struct {
+	protobuf.UnimplementedCartServiceServer
+	service CartService
+	logger  *zap.Logger
+}
+
+func ListenGRPC(s CartService, port int, secure bool) error {
+	logger := common.GetLogger()
+
+	lis, err := net.Listen("tcp", fmt.Sprintf(":%d", port))
+	if err != nil {
+		return fmt.Errorf("failed to listen on port %d: %v", port, err)
+	}
+
+	var opts []grpc.ServerOption
+	keepAliveParams := keepalive.ServerParameters{
+		Time:    5 * time.Minute,
+		Timeout: 20 * time.Second,
+	}
+
+	opts = append(opts, grpc.KeepaliveParams(keepAliveParams))
+
+	if secure {
+		creds := credentials.NewTLS(&tls.Config{
+			InsecureSkipVerify: false,
+		})
+		opts = append(opts, grpc.Creds(creds))
+	} else {
+		opts = append(opts, grpc.Creds(insecure.NewCredentials()))
+	}
+
+	serv := grpc.NewServer(opts...)
+	cartServer := &cartGrpcServer{
+		UnimplementedCartServiceServer: protobuf.UnimplementedCartServiceServer{},
+		service:                        s,
+		logger:                         logger,
+	}
+	protobuf.RegisterCartServiceServer(serv, cartServer)
+	reflection.Register(serv)
+
+	errChan := make(chan error)
+	go func() {
+		if err := serv.Serve(lis); err != nil {
+			errChan <- fmt.Errorf("failed to serve gRPC server: %v", err)
+		}
+	}()
+
+	signalChan := make(chan os.Signal, 1)
+	signal.Notify(signalChan, os.Interrupt, syscall.SIGTERM)
+
+	select {
+	case sig := <-signalChan:
+		logger.Info("Received signal, shutting down gRPC server", zap.String("signal", sig.String()))
+		serv.GracefulStop()
+	case err := <-errChan:
+		return err
+	}
+
+	return nil
+}
+
+func (s *cartGrpcServer) GetCart(ctx context.Context, r *protobuf.GetCartRequest) (*protobuf.GetCartResponse, error) {
+	s.logger.Info("GetCart request received", zap.String("account_id", r.GetOwner().GetAccountId()), zap.String("cart_id", r.GetOwner().GetCartId()))
+
+	c, err := s.service.GetCart(ctx, ownerFromProto(r.Owner))
+	if err != nil {
+		s.logger.Error("Failed to fetch cart", zap.String("account_id", r.GetOwner().GetAccountId()), zap.String("cart_id", r.GetOwner().GetCartId()), zap.String("error", err.Error()))
+		return &protobuf.GetCartResponse{
+			Result: &protobuf.GetCartResponse_Error{Error: err.Error()},
+		}, cartError(err)
+	}
+
+	s.logger.Info("Cart fetched successfully", zap.String("cart_id", c.ID), zap.Int("item_count", len(c.Items)))
+
+	return &protobuf.GetCartResponse{
+		Result: &protobuf.GetCartResponse_Cart{Cart: cartToProto(c)},
+	}, nil
+}
+
+func (s *cartGrpcServer) AddCartItem(ctx context.Context, r *protobuf.AddCartItemRequest) (*protobuf.AddCartItemResponse, error) {
+	s.logger.Info("AddCartItem request received", zap.String("account_id", r.GetOwner().GetAccountId()), zap.String("cart_id", r.GetOwner().GetCartId()), zap.String("product_id", r.ProductId), zap.Int32("quantity", r.Quantity))
+
+	c, err := s.service.AddItem(ctx, ownerFromProto(r.Owner), r.ProductId, r.Quantity)
+	if err != nil {
+		s.logger.Error("Failed to add cart item", zap.String("account_id", r.GetOwner().GetAccountId()), zap.String("cart_id", r.GetOwner().GetCartId()), zap.String("error", err.Error()))
+		return &protobuf.AddCartItemResponse{
+			Result: &protobuf.AddCartItemResponse_Error{Error: err.Error()},
+		}, cartError(err)
+	}
+
+	s.logger.Info("Cart item added successfully", zap.String("cart_id", c.ID), zap.Int("item_count", len(c.Items)))
+
+	return &protobuf.AddCartItemResponse{
+		Result: &protobuf.AddCartItemResponse_Cart{Cart: cartToProto(c)},
+	}, nil
+}
+
+func (s *cartGrpcServer) UpdateCartItem(ctx context.Context, r *protobuf.UpdateCartItemRequest) (*protobuf.UpdateCartItemResponse, error) {
+	s.logger.Info("UpdateCartItem request received", zap.String("account_id", r.GetOwner().GetAccountId()), zap.String("cart_id", r.GetOwner().GetCartId()), zap.String("product_id", r.ProductId), zap.Int32("quantity", r.Quantity))
+
+	c, err := s.service.UpdateItem(ctx, ownerFromProto(r.Owner), r.ProductId, r.Quantity)
+	if err != nil {
+		s.logger.Error("Failed to update cart item", zap.String("account_id", r.GetOwner().GetAccountId()), zap.String("cart_id", r.GetOwner().GetCartId()), zap.String("error", err.Error()))
+		return &protobuf.UpdateCartItemResponse{
+			Result: &protobuf.UpdateCartItemResponse_Error{Error: err.Error()},
+		}, cartError(err)
+	}
+
+	s.logger.Info("Cart item updated successfully", zap.String("cart_id", c.ID), zap.Int("item_count", len(c.Items)))
+
+	return &protobuf.UpdateCartItemResponse{
+		Result: &protobuf.UpdateCartItemResponse_Cart{Cart: cartToProto(c)},
+	}, nil
+}
+
+func (s *cartGrpcServer) RemoveCartItem(ctx context.Context, r *protobuf.RemoveCartItemRequest) (*protobuf.RemoveCartItemResponse, error) {
+	s.logger.Info("RemoveCartItem request received", zap.String("account_id", r.GetOwner().GetAccountId()), zap.String("cart_id", r.GetOwner().GetCartId()), zap.String("product_id", r.ProductId))
+
+	c, err := s.service.RemoveItem(ctx, ownerFromProto(r.Owner), r.ProductId)
+	if err != nil {
+		s.logger.Error("Failed to remove cart item", zap.String("account_id", r.GetOwner().GetAccountId()), zap.String("cart_id", r.GetOwner().GetCartId()), zap.String("error", err.Error()))
+		return &protobuf.RemoveCartItemResponse{
+			Result: &protobuf.RemoveCartItemResponse_Error{Error: err.Error()},
+		}, cartError(err)
+	}
+
+	s.logger.Info("Cart item removed successfully", zap.String("cart_id", c.ID), zap.Int("item_count", len(c.Items)))
+
+	return &protobuf.RemoveCartItemResponse{
+		Result: &protobuf.RemoveCartItemResponse_Cart{Cart: cartToProto(c)},
+	}, nil
+}
+
+func (s *cartGrpcServer) ClearCart(ctx context.Context, r *protobuf.ClearCartRequest) (*protobuf.ClearCartResponse, error) {
+	s.logger.Info("ClearCart request received", zap.String("account_id", r.GetOwner().GetAccountId()), zap.String("cart_id", r.GetOwner().GetCartId()))
+
+	c, err := s.service.ClearCart(ctx, ownerFromProto(r.Owner))
+	if err != nil {
+		s.logger.Error("Failed to clear cart", zap.String("account_id", r.GetOwner().GetAccountId()), zap.String("cart_id", r.GetOwner().GetCartId()), zap.String("error", err.Error()))
+		return &protobuf.ClearCartResponse{
+			Result: &protobuf.ClearCartResponse_Error{Error: err.Error()},
+		}, cartError(err)
+	}
+
+	s.logger.Info("Cart cleared successfully", zap.String("cart_id", c.ID), zap.Int("item_count", len(c.Items)))
+
+	return &protobuf.ClearCartResponse{
+		Result: &protobuf.ClearCartResponse_Cart{Cart: cartToProto(c)},
+	}, nil
+}
+
+func (s *cartGrpcServer) MergeCarts(ctx context.Context, r *protobuf.MergeCartsRequest) (*protobuf.MergeCartsResponse, error) {
+	s.logger.Info("MergeCarts request received", zap.String("cart_id", r.CartId), zap.String("account_id", r.AccountId))
+
+	c, err := s.service.MergeCarts(ctx, r.CartId, r.AccountId)
+	if err != nil {
+		s.logger.Error("Failed to merge carts", zap.String("cart_id", r.CartId), zap.String("account_id", r.AccountId), zap.String("error", err.Error()))
+		return &protobuf.MergeCartsResponse{
+			Result: &protobuf.MergeCartsResponse_Error{Error: err.Error()},
+		}, cartError(err)
+	}
+
+	s.logger.Info("Carts merged successfully", zap.String("cart_id", c.ID), zap.Int("item_count", len(c.Items)))
+
+	return &protobuf.MergeCartsResponse{
+		Result: &protobuf.MergeCartsResponse_Cart{Cart: cartToProto(c)},
+	}, nil
+}
+
+func cartError(err error) error {
+	var validationErr *common.ValidationError
+	switch {
+	case errors.As(err, &validationErr):
+		return validationErr.GRPCStatus().Err()
+	case errors.Is(err, ErrCartNotFound), errors.Is(err, ErrCartItemNotFound):
+		return grpcResponseStatus.Errorf(grpcResponseCodes.NotFound, err.Error())
+	}
+	return grpcResponseStatus.Errorf(grpcResponseCodes.Internal, err.Error())
+}
+
+func ownerFromProto(o *protobuf.CartOwner) CartOwner {
+	return CartOwner{AccountID: o.GetAccountId(), CartID: o.GetCartId()}
+}
+
+func moneyToProto(m common.Money) *protobuf.Money {
+	return &protobuf.Money{Amount: m.Amount, CurrencyCode: m.CurrencyCode}
+}
+
+func cartToProto(c *Cart) *protobuf.Cart {
+	items := make([]*protobuf.CartItem, 0, len(c.Items))
+	for _, item := range c.Items {
+		items = append(items, &protobuf.CartItem{
+			ProductId: item.ProductID,
+			Quantity:  item.Quantity,
+			Name:      item.Name,
+			UnitPrice: moneyToProto(item.UnitPrice),
+			Subtotal:  moneyToProto(item.Subtotal),
+			Available: item.Available,
+			AddedAt:   timestamppb.New(item.AddedAt),
+		})
+	}
+
+	totals := make([]*protobuf.Money, 0, len(c.Totals))
+	for _, total := range c.Totals {
+		totals = append(totals, moneyToProto(total))
+	}
+
+	return &protobuf.Cart{
+		Id:        c.ID,
+		AccountId: c.AccountID,
+		Items:     items,
+		Totals:    totals,
+		CreatedAt: timestamppb.New(c.CreatedAt),
+		UpdatedAt: timestamppb.New(c.UpdatedAt),
+	}
+}
@@ -0,0 +1,242 @@
+package cart
+
+import (
+	"context"
+	"errors"
+
+	"graphql-grpc-go-microservice-project/common"
+	"graphql-grpc-go-microservice-project/product"
+
+	"github.com/google/uuid"
+)
+
+// ProductCatalog looks up the current name and price of cart items.
+// *product.ProductClient satisfies it.
+type ProductCatalog interface {
+	ListProductsWithIDs(ctx context.Context, ids []string, limit, offset uint32) ([]*product.Product, error)
+}
+
+type CartService interface {
+	GetCart(ctx context.Context, owner CartOwner) (*Cart, error)
+	AddItem(ctx context.Context, owner CartOwner, productID string, quantity int32) (*Cart, error)
+	UpdateItem(ctx context.Context, owner CartOwner, productID string, quantity int32) (*Cart, error)
+	RemoveItem(ctx context.Context, owner CartOwner, productID string) (*Cart, error)
+	ClearCart(ctx context.Context, owner CartOwner) (*Cart, error)
+	MergeCarts(ctx context.Context, cartID, accountID string) (*Cart, error)
+}
+
+type cartService struct {
+	repository CartRepository
+	catalog    ProductCatalog
+}
+
+func NewCartService(repository CartRepository, catalog ProductCatalog) (CartService, error) {
+	return &cartService{repository: repository, catalog: catalog}, nil
+}
+
+// GetCart returns an empty cart for accounts that have not added anything yet.
+func (service *cartService) GetCart(ctx context.Context, owner CartOwner) (*Cart, error) {
+	violations := &common.ValidationError{}
+	owner = validateOwner(violations, owner, false)
+	if err := violations.Err(); err != nil {
+		return nil, err
+	}
+
+	cart, err := service.repository.GetCart(ctx, owner)
+	if errors.Is(err, ErrCartNotFound) && !owner.IsAnonymous() {
+		cart, err = Cart{AccountID: owner.AccountID, Items: []CartItem{}}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return service.price(ctx, cart)
+}
+
+// AddItem adds quantity units of a product, on top of any already in the
+// cart. Without an owner a new anonymous cart is created; its ID is returned
+// and addresses the cart from then on.
+func (service *cartService) AddItem(ctx context.Context, owner CartOwner, productID string, quantity int32) (*Cart, error) {
+	violations := &common.ValidationError{}
+	owner = validateOwner(violations, owner, true)
+	productID = validateProductID(violations, productID)
+	validateQuantity(violations, quantity, false)
+	if err := violations.Err(); err != nil {
+		return nil, err
+	}
+
+	products, err := service.catalog.ListProductsWithIDs(ctx, []string{productID}, 1, 0)
+	if err != nil {
+		return nil, err
+	}
+	if len(products) == 0 {
+		violations.Add("productId", "must reference an existing product")
+		return nil, violations.Err()
+	}
+
+	cart, err := service.repository.EnsureCart(ctx, owner)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := service.repository.AddItem(ctx, cart.ID, productID, quantity); err != nil {
+		return nil, err
+	}
+
+	return service.reload(ctx, cart)
+}
+
+// UpdateItem sets the quantity of a product already in the cart. A quantity
+// of zero removes it.
+func (service *cartService) UpdateItem(ctx context.Context, owner CartOwner, productID string, quantity int32) (*Cart, error) {
+	violations := &common.ValidationError{}
+	owner = validateOwner(violations, owner, false)
+	productID = validateProductID(violations, productID)
+	validateQuantity(violations, quantity, true)
+	if err := violations.Err(); err != nil {
+		return nil, err
+	}
+
+	cart, err := service.repository.GetCart(ctx, owner)
+	if err != nil {
+		return nil, err
+	}
+
+	if quantity == 0 {
+		err = service.repository.RemoveItem(ctx, cart.ID, productID)
+	} else {
+		err = service.repository.SetItemQuantity(ctx, cart.ID, productID, quantity)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return service.reload(ctx, cart)
+}
+
+func (service *cartService) RemoveItem(ctx context.Context, owner CartOwner, productID string) (*Cart, error) {
+	violations := &common.ValidationError{}
+	owner = validateOwner(violations, owner, false)
+	productID = validateProductID(violations, productID)
+	if err := violations.Err(); err != nil {
+		return nil, err
+	}
+
+	cart, err := service.repository.GetCart(ctx, owner)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := service.repository.RemoveItem(ctx, cart.ID, productID); err != nil {
+		return nil, err
+	}
+
+	return service.reload(ctx, cart)
+}
+
+func (service *cartService) ClearCart(ctx context.Context, owner CartOwner) (*Cart, error) {
+	violations := &common.ValidationError{}
+	owner = validateOwner(violations, owner, false)
+	if err := violations.Err(); err != nil {
+		return nil, err
+	}
+
+	cart, err := service.repository.GetCart(ctx, owner)
+	if errors.Is(err, ErrCartNotFound) && !owner.IsAnonymous() {
+		return service.price(ctx, Cart{AccountID: owner.AccountID, Items: []CartItem{}})
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if err := service.repository.ClearCart(ctx, cart.ID); err != nil {
+		return nil, err
+	}
+
+	return service.reload(ctx, cart)
+}
+
+// MergeCarts folds an anonymous cart into the account's cart, typically
+// right after the shopper signs in.
+func (service *cartService) MergeCarts(ctx context.Context, cartID, accountID string) (*Cart, error) {
+	violations := &common.ValidationError{}
+	if _, err := uuid.Parse(cartID); err != nil {
+		violations.Add("cartId", "must be a valid UUID")
+	}
+	if _, err := uuid.Parse(accountID); err != nil {
+		violations.Add("accountId", "must be a valid UUID")
+	}
+	if err := violations.Err(); err != nil {
+		return nil, err
+	}
+
+	if err := service.repository.MergeCarts(ctx, cartID, accountID); err != nil {
+		return nil, err
+	}
+
+	return service.GetCart(ctx, CartOwner{AccountID: accountID})
+}
+
+// reload reads a cart again after a change, addressing it by account for
+// account carts and by ID for anonymous ones.
+func (service *cartService) reload(ctx context.Context, cart Cart) (*Cart, error) {
+	owner := CartOwner{AccountID: cart.AccountID}
+	if owner.IsAnonymous() {
+		owner.CartID = cart.ID
+	}
+
+	cart, err := service.repository.GetCart(ctx, owner)
+	if err != nil {
+		return nil, err
+	}
+
+	return service.price(ctx, cart)
+}
+
+// price fills in the current name and price of every item from the catalog
+// and totals the cart once per currency.
+func (service *cartService) price(ctx context.Context, cart Cart) (*Cart, error) {
+	cart.Totals = []common.Money{}
+	if len(cart.Items) == 0 {
+		return &cart, nil
+	}
+
+	ids := make([]string, 0, len(cart.Items))
+	for _, item := range cart.Items {
+		ids = append(ids, item.ProductID)
+	}
+
+	products, err := service.catalog.ListProductsWithIDs(ctx, ids, uint32(len(ids)), 0)
+	if err != nil {
+		return nil, err
+	}
+
+	byID := make(map[string]*product.Product, len(products))
+	for _, p := range products {
+		byID[p.ID] = p
+	}
+
+	totals := map[string]int{}
+	for i, item := range cart.Items {
+		p, ok := byID[item.ProductID]
+		if !ok {
+			continue
+		}
+
+		item.Available = true
+		item.Name = p.Name
+		item.UnitPrice = p.Price
+		item.Subtotal = common.Money{Amount: p.Price.Amount * int64(item.Quantity), CurrencyCode: p.Price.CurrencyCode}
+		cart.Items[i] = item
+
+		index, ok := totals[item.Subtotal.CurrencyCode]
+		if !ok {
+			index = len(cart.Totals)
+			totals[item.Subtotal.CurrencyCode] = index
+			cart.Totals = append(cart.Totals, common.Money{CurrencyCode: item.Subtotal.CurrencyCode})
+		}
+		cart.Totals[index].Amount += item.Subtotal.Amount
+	}
+
+	return &cart, nil
+}
@@ -0,0 +1,3 @@
+DROP TABLE IF EXISTS cart_items;
+DROP TABLE IF EXISTS carts;
+DROP FUNCTION IF EXISTS update_updated_at_column;
@@ -0,0 +1,29 @@
+CREATE EXTENSION IF NOT EXISTS "uuid-ossp";
+
+CREATE OR REPLACE FUNCTION update_updated_at_column()
+RETURNS TRIGGER AS $$
+BEGIN
+    NEW.updated_at = CURRENT_TIMESTAMP;
+    RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+
+-- Anonymous carts have no account and are addressed by their ID.
+CREATE TABLE carts (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4 (),
+    account_id UUID UNIQUE,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE TRIGGER set_updated_at BEFORE
+UPDATE ON carts FOR EACH ROW
+EXECUTE FUNCTION update_updated_at_column ();
+
+CREATE TABLE cart_items (
+    cart_id UUID NOT NULL REFERENCES carts (id) ON DELETE CASCADE,
+    product_id VARCHAR(64) NOT NULL,
+    quantity INTEGER NOT NULL CHECK (quantity > 0),
+    added_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (cart_id, product_id)
+);
@@ -0,0 +1,45 @@
+package cart
+
+import (
+	"errors"
+	"time"
+
+	"graphql-grpc-go-microservice-project/common"
+)
+
+var (
+	ErrCartNotFound     = errors.New("cart not found")
+	ErrCartItemNotFound = errors.New("cart item not found")
+)
+
+// CartOwner identifies a cart by account, or by cart ID for anonymous carts.
+type CartOwner struct {
+	AccountID string
+	CartID    string
+}
+
+func (o CartOwner) IsAnonymous() bool {
+	return o.AccountID == ""
+}
+
+type Cart struct {
+	ID        string         `json:"id"`
+	AccountID string         `json:"account_id,omitempty"`
+	Items     []CartItem     `json:"items"`
+	Totals    []common.Money `json:"totals"`
+	CreatedAt time.Time      `json:"created_at"`
+	UpdatedAt time.Time      `json:"updated_at"`
+}
+
+// CartItem is a product in a cart. Name, UnitPrice and Subtotal are filled in
+// from the catalog whenever the cart is read, so they always reflect current
+// prices. Available is false when the product no longer exists.
+type CartItem struct {
+	ProductID string       `json:"product_id"`
+	Quantity  int32        `json:"quantity"`
+	AddedAt   time.Time    `json:"added_at"`
+	Name      string       `json:"name"`
+	UnitPrice common.Money `json:"unit_price"`
+	Subtotal  common.Money `json:"subtotal"`
+	Available bool         `json:"available"`
+}
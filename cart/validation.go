@@ -0,0 +1,60 @@
+package cart
+
+import (
+	"strings"
+
+	"graphql-grpc-go-microservice-project/common"
+
+	"github.com/google/uuid"
+)
+
+// maxProductIDLength matches the VARCHAR(64) product_id column.
+const maxProductIDLength = 64
+
+// validateOwner checks that a cart is addressed by an account or a cart ID.
+// allowNew permits neither, which starts a new anonymous cart.
+func validateOwner(violations *common.ValidationError, owner CartOwner, allowNew bool) CartOwner {
+	owner.AccountID = strings.TrimSpace(owner.AccountID)
+	owner.CartID = strings.TrimSpace(owner.CartID)
+
+	switch {
+	case owner.AccountID != "" && owner.CartID != "":
+		violations.Add("owner", "must set either accountId or cartId, not both")
+	case owner.AccountID == "" && owner.CartID == "" && !allowNew:
+		violations.Add("owner", "must set accountId or cartId")
+	}
+
+	if owner.AccountID != "" {
+		if _, err := uuid.Parse(owner.AccountID); err != nil {
+			violations.Add("owner.accountId", "must be a valid UUID")
+		}
+	}
+	if owner.CartID != "" {
+		if _, err := uuid.Parse(owner.CartID); err != nil {
+			violations.Add("owner.cartId", "must be a valid UUID")
+		}
+	}
+
+	return owner
+}
+
+func validateProductID(violations *common.ValidationError, productID string) string {
+	productID = strings.TrimSpace(productID)
+	switch {
+	case productID == "":
+		violations.Add("productId", "must not be empty")
+	case len(productID) > maxProductIDLength:
+		violations.Add("productId", "must be at most 64 characters")
+	}
+	return productID
+}
+
+func validateQuantity(violations *common.ValidationError, quantity int32, allowZero bool) {
+	switch {
+	case quantity == 0 && allowZero:
+	case quantity < 1:
+		violations.Add("quantity", "must be greater than 0")
+	case quantity > maxItemQuantity:
+		violations.Add("quantity", "must be at most 999")
+	}
+}
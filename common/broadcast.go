@@ -0,0 +1,52 @@
+package common
+
+import "sync"
+
+// Broadcaster fans values out to every current subscriber. Publish never
+// blocks: a subscriber whose buffer is full is dropped and its channel
+// closed, so that one slow stream cannot hold up the writes that publish.
+type Broadcaster[T any] struct {
+	mu          sync.Mutex
+	subscribers map[chan T]struct{}
+}
+
+func NewBroadcaster[T any]() *Broadcaster[T] {
+	return &Broadcaster[T]{subscribers: map[chan T]struct{}{}}
+}
+
+// Subscribe returns a channel receiving the values published from now on and
+// a function that ends the subscription. The channel is closed when the
+// subscription ends, either by calling the function or by falling behind.
+func (b *Broadcaster[T]) Subscribe(buffer int) (<-chan T, func()) {
+	ch := make(chan T, buffer)
+
+	b.mu.Lock()
+	b.subscribers[ch] = struct{}{}
+	b.mu.Unlock()
+
+	return ch, func() {
+		b.mu.Lock()
+		defer b.mu.Unlock()
+		b.remove(ch)
+	}
+}
+
+func (b *Broadcaster[T]) Publish(value T) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	for ch := range b.subscribers {
+		select {
+		case ch <- value:
+		default:
+			b.remove(ch)
+		}
+	}
+}
+
+func (b *Broadcaster[T]) remove(ch chan T) {
+	if _, ok := b.subscribers[ch]; ok {
+		delete(b.subscribers, ch)
+		close(ch)
+	}
+}
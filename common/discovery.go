@@ -0,0 +1,123 @@
+package common
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// Discovery finds the addresses of the running instances of a service.
+type Discovery interface {
+	Lookup(ctx context.Context, service string) ([]string, error)
+}
+
+var ErrUnknownDiscovery = errors.New("unknown service discovery")
+
+// NewDiscovery creates the discovery named by kind, either "file", which
+// reads path, or "consul", which asks the Consul agent at consulURL.
+func NewDiscovery(kind, path, consulURL string) (Discovery, error) {
+	switch kind {
+	case "file":
+		return NewFileDiscovery(path), nil
+	case "consul":
+		return NewConsulDiscovery(consulURL)
+	default:
+		return nil, fmt.Errorf("%w %q", ErrUnknownDiscovery, kind)
+	}
+}
+
+// FileDiscovery reads the instances of each service from a JSON file that
+// maps service names to addresses:
+//
+//	{"product": ["10.0.0.1:8080", "10.0.0.2:8080"]}
+//
+// The file is read on every lookup, so it can be changed while clients run.
+type FileDiscovery struct {
+	path string
+}
+
+func NewFileDiscovery(path string) *FileDiscovery {
+	return &FileDiscovery{path: path}
+}
+
+func (d *FileDiscovery) Lookup(_ context.Context, service string) ([]string, error) {
+	data, err := os.ReadFile(d.path)
+	if err != nil {
+		return nil, err
+	}
+
+	var services map[string][]string
+	if err := json.Unmarshal(data, &services); err != nil {
+		return nil, fmt.Errorf("invalid service discovery file %s: %w", d.path, err)
+	}
+	if len(services[service]) == 0 {
+		return nil, fmt.Errorf("no instances of %s in %s", service, d.path)
+	}
+	return services[service], nil
+}
+
+// ConsulDiscovery looks up the instances of a service that pass their
+// health checks in the Consul catalog.
+type ConsulDiscovery struct {
+	url    string
+	client *http.Client
+}
+
+func NewConsulDiscovery(rawURL string) (*ConsulDiscovery, error) {
+	u, err := url.Parse(rawURL)
+	if err != nil || u.Host == "" {
+		return nil, fmt.Errorf("invalid Consul URL %q", rawURL)
+	}
+	return &ConsulDiscovery{url: strings.TrimSuffix(u.String(), "/"), client: &http.Client{}}, nil
+}
+
+type consulServiceEntry struct {
+	Node struct {
+		Address string
+	}
+	Service struct {
+		Address string
+		Port    int
+	}
+}
+
+func (d *ConsulDiscovery) Lookup(ctx context.Context, service string) ([]string, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, d.url+"/v1/health/service/"+url.PathEscape(service)+"?passing=true", nil)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := d.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("consul lookup of %s failed: %s", service, resp.Status)
+	}
+
+	var entries []consulServiceEntry
+	if err := json.NewDecoder(resp.Body).Decode(&entries); err != nil {
+		return nil, fmt.Errorf("invalid consul response for %s: %w", service, err)
+	}
+
+	addresses := make([]string, 0, len(entries))
+	for _, entry := range entries {
+		host := entry.Service.Address
+		if host == "" {
+			host = entry.Node.Address
+		}
+		addresses = append(addresses, net.JoinHostPort(host, strconv.Itoa(entry.Service.Port)))
+	}
+	if len(addresses) == 0 {
+		return nil, fmt.Errorf("no healthy instances of %s in consul", service)
+	}
+	return addresses, nil
+}
@@ -0,0 +1,223 @@
+package common
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// Event is a domain event published to other teams. Delivery is at least
+// once, so consumers should use ID to drop duplicates. Key is the ID of the
+// changed entity; events with the same key are published in order.
+type Event struct {
+	ID         string          `json:"id"`
+	Topic      string          `json:"topic"`
+	Key        string          `json:"key"`
+	Payload    json.RawMessage `json:"payload"`
+	OccurredAt time.Time       `json:"occurred_at"`
+}
+
+// NewEvent creates an event with a random ID, encoding payload as JSON.
+func NewEvent(topic, key string, payload any) (Event, error) {
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return Event{}, fmt.Errorf("failed to encode event payload: %w", err)
+	}
+
+	id := make([]byte, 16)
+	if _, err := rand.Read(id); err != nil {
+		return Event{}, fmt.Errorf("failed to generate event id: %w", err)
+	}
+
+	return Event{
+		ID:         hex.EncodeToString(id),
+		Topic:      topic,
+		Key:        key,
+		Payload:    data,
+		OccurredAt: time.Now().UTC(),
+	}, nil
+}
+
+type EventHandler func(ctx context.Context, event Event) error
+
+type EventPublisher interface {
+	Publish(ctx context.Context, event Event) error
+}
+
+// EventBus delivers events to the subscribers of their topic. Topics are
+// dot-separated; in subscriptions "*" matches one token and a trailing ">"
+// matches all remaining tokens, as in NATS subjects.
+type EventBus interface {
+	EventPublisher
+	Subscribe(topic string, handler EventHandler) (func(), error)
+	Close() error
+}
+
+type memorySubscription struct {
+	topic   string
+	handler EventHandler
+}
+
+// MemoryEventBus delivers events synchronously within the process, for
+// local development and single-binary setups.
+type MemoryEventBus struct {
+	mu            sync.RWMutex
+	nextID        int
+	subscriptions map[int]memorySubscription
+}
+
+func NewMemoryEventBus() *MemoryEventBus {
+	return &MemoryEventBus{subscriptions: map[int]memorySubscription{}}
+}
+
+// Publish calls every matching handler and returns their joined errors.
+func (b *MemoryEventBus) Publish(ctx context.Context, event Event) error {
+	b.mu.RLock()
+	var handlers []EventHandler
+	for _, subscription := range b.subscriptions {
+		if topicMatches(subscription.topic, event.Topic) {
+			handlers = append(handlers, subscription.handler)
+		}
+	}
+	b.mu.RUnlock()
+
+	var errs []error
+	for _, handler := range handlers {
+		if err := handler(ctx, event); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+func (b *MemoryEventBus) Subscribe(topic string, handler EventHandler) (func(), error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	id := b.nextID
+	b.nextID++
+	b.subscriptions[id] = memorySubscription{topic: topic, handler: handler}
+
+	return func() {
+		b.mu.Lock()
+		defer b.mu.Unlock()
+		delete(b.subscriptions, id)
+	}, nil
+}
+
+func (b *MemoryEventBus) Close() error {
+	return nil
+}
+
+func topicMatches(pattern, topic string) bool {
+	patternTokens := strings.Split(pattern, ".")
+	topicTokens := strings.Split(topic, ".")
+
+	for i, token := range patternTokens {
+		if token == ">" {
+			return i < len(topicTokens)
+		}
+		if i >= len(topicTokens) || (token != "*" && token != topicTokens[i]) {
+			return false
+		}
+	}
+	return len(patternTokens) == len(topicTokens)
+}
+
+var (
+	ErrUnknownEventBus = errors.New("unknown event bus")
+	ErrEventQueueFull  = errors.New("event queue is full")
+)
+
+// AsyncPublisher queues events and publishes them in the background, one at
+// a time in the order they were queued, so that callers do not wait for the
+// event bus. Events that cannot be published within timeout are logged and
+// dropped.
+type AsyncPublisher struct {
+	publisher EventPublisher
+	timeout   time.Duration
+	logger    *zap.Logger
+
+	mu     sync.RWMutex
+	queue  chan Event
+	closed bool
+	done   chan struct{}
+}
+
+// NewAsyncPublisher starts publishing to publisher, queueing up to size
+// events.
+func NewAsyncPublisher(publisher EventPublisher, size int, timeout time.Duration) *AsyncPublisher {
+	p := &AsyncPublisher{
+		publisher: publisher,
+		timeout:   timeout,
+		logger:    GetLogger(),
+		queue:     make(chan Event, size),
+		done:      make(chan struct{}),
+	}
+	go p.run()
+	return p
+}
+
+// Publish queues event without waiting for it to be published. It fails
+// with ErrEventQueueFull when the event bus has fallen too far behind.
+func (p *AsyncPublisher) Publish(_ context.Context, event Event) error {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+
+	if p.closed {
+		return fmt.Errorf("event publisher is closed")
+	}
+	select {
+	case p.queue <- event:
+		return nil
+	default:
+		return ErrEventQueueFull
+	}
+}
+
+// Close publishes the events still queued and stops.
+func (p *AsyncPublisher) Close() error {
+	p.mu.Lock()
+	if !p.closed {
+		p.closed = true
+		close(p.queue)
+	}
+	p.mu.Unlock()
+
+	<-p.done
+	return nil
+}
+
+func (p *AsyncPublisher) run() {
+	defer close(p.done)
+
+	for event := range p.queue {
+		ctx, cancel := context.WithTimeout(context.Background(), p.timeout)
+		err := p.publisher.Publish(ctx, event)
+		cancel()
+		if err != nil {
+			p.logger.Error("Failed to publish event", zap.String("topic", event.Topic), zap.String("event_id", event.ID), zap.String("error", err.Error()))
+		}
+	}
+}
+
+// NewEventBus creates the event bus named by kind, either "memory" or "nats".
+// natsStream is only used by the NATS bus.
+func NewEventBus(kind, natsURL string, natsStream NATSStream) (EventBus, error) {
+	switch kind {
+	case "memory":
+		return NewMemoryEventBus(), nil
+	case "nats":
+		return NewNATSEventBus(natsURL, natsStream)
+	default:
+		return nil, fmt.Errorf("%w %q", ErrUnknownEventBus, kind)
+	}
+}
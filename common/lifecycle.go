@@ -0,0 +1,216 @@
+package common
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"os"
+	"os/signal"
+	"sync"
+	"syscall"
+	"time"
+
+	"go.uber.org/zap"
+	"google.golang.org/grpc"
+)
+
+type lifecycleServer struct {
+	name string
+	stop func(ctx context.Context) error
+}
+
+type shutdownHook struct {
+	name string
+	run  func(ctx context.Context) error
+}
+
+// Lifecycle runs the servers and background workers of a binary until it
+// receives SIGINT or SIGTERM, or a server fails, and then shuts down in
+// order:
+//
+//  1. The servers stop accepting work and finish what is in flight, for at
+//     most the drain timeout.
+//  2. The shutdown hooks run in reverse order of registration, like deferred
+//     calls, so that workers stop before the clients and repositories they
+//     use are closed.
+//  3. The logger is flushed.
+//
+// A second signal during shutdown exits at once.
+type Lifecycle struct {
+	drainTimeout time.Duration
+	logger       *zap.Logger
+	failed       chan error
+
+	mu      sync.Mutex
+	servers []lifecycleServer
+	hooks   []shutdownHook
+}
+
+func NewLifecycle(drainTimeout time.Duration) *Lifecycle {
+	return &Lifecycle{
+		drainTimeout: drainTimeout,
+		logger:       GetLogger(),
+		failed:       make(chan error, 1),
+	}
+}
+
+// Serve runs serve in the background. stop is called at shutdown and must
+// make serve return.
+func (l *Lifecycle) Serve(name string, serve func() error, stop func(ctx context.Context) error) {
+	l.mu.Lock()
+	l.servers = append(l.servers, lifecycleServer{name: name, stop: stop})
+	l.mu.Unlock()
+
+	go func() {
+		if err := serve(); err != nil {
+			select {
+			case l.failed <- err:
+			default:
+			}
+		}
+	}()
+}
+
+// ServeHTTP runs srv. At shutdown it waits for the requests in flight, and
+// closes the connections still open after the drain timeout.
+func (l *Lifecycle) ServeHTTP(name string, srv *http.Server) {
+	l.Serve(name, func() error {
+		if err := srv.ListenAndServe(); !errors.Is(err, http.ErrServerClosed) {
+			return err
+		}
+		return nil
+	}, func(ctx context.Context) error {
+		if err := srv.Shutdown(ctx); err != nil {
+			srv.Close()
+			return err
+		}
+		return nil
+	})
+}
+
+// ServeGRPC runs srv on lis. At shutdown it waits for the calls in flight,
+// and stops the calls and streams still running after the drain timeout.
+func (l *Lifecycle) ServeGRPC(name string, srv *grpc.Server, lis net.Listener) {
+	l.Serve(name, func() error {
+		return srv.Serve(lis)
+	}, func(ctx context.Context) error {
+		stopped := make(chan struct{})
+		go func() {
+			srv.GracefulStop()
+			close(stopped)
+		}()
+
+		select {
+		case <-stopped:
+			return nil
+		case <-ctx.Done():
+			srv.Stop()
+			return ctx.Err()
+		}
+	})
+}
+
+// Go runs worker in the background until shutdown, when its context is
+// cancelled and the hooks registered before it wait for it to return.
+func (l *Lifecycle) Go(name string, worker func(ctx context.Context)) {
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		worker(ctx)
+	}()
+
+	l.OnShutdown(name, func(shutdownCtx context.Context) error {
+		cancel()
+		select {
+		case <-done:
+			return nil
+		case <-shutdownCtx.Done():
+			return shutdownCtx.Err()
+		}
+	})
+}
+
+// OnShutdown registers hook to run at shutdown, such as flushing telemetry.
+func (l *Lifecycle) OnShutdown(name string, hook func(ctx context.Context) error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.hooks = append(l.hooks, shutdownHook{name: name, run: hook})
+}
+
+// OnClose registers closer, such as a client or a repository, to be closed
+// at shutdown.
+func (l *Lifecycle) OnClose(name string, closer io.Closer) {
+	l.OnShutdown(name, func(context.Context) error {
+		return closer.Close()
+	})
+}
+
+// Wait blocks until a signal arrives or a server fails, shuts down and
+// returns the error of the failed server.
+func (l *Lifecycle) Wait() error {
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, os.Interrupt, syscall.SIGTERM)
+	defer signal.Stop(signals)
+
+	var err error
+	select {
+	case sig := <-signals:
+		l.logger.Info("Received signal, shutting down", zap.String("signal", sig.String()), zap.Duration("drain_timeout", l.drainTimeout))
+	case err = <-l.failed:
+		l.logger.Error("Server failed, shutting down", zap.Error(err))
+	}
+
+	go func() {
+		sig := <-signals
+		l.logger.Warn("Received second signal, exiting", zap.String("signal", sig.String()))
+		l.logger.Sync()
+		os.Exit(1)
+	}()
+
+	l.Shutdown()
+	return err
+}
+
+// Shutdown stops the servers, runs the shutdown hooks and flushes the
+// logger. Wait calls it, so binaries only call it to shut down without a
+// signal.
+func (l *Lifecycle) Shutdown() {
+	l.mu.Lock()
+	servers := l.servers
+	hooks := l.hooks
+	l.servers, l.hooks = nil, nil
+	l.mu.Unlock()
+
+	drainCtx, cancel := context.WithTimeout(context.Background(), l.drainTimeout)
+	defer cancel()
+
+	var wg sync.WaitGroup
+	for _, server := range servers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if err := server.stop(drainCtx); err != nil {
+				l.logger.Warn("Server did not drain in time", zap.String("server", server.name), zap.Error(err))
+				return
+			}
+			l.logger.Info("Server stopped", zap.String("server", server.name))
+		}()
+	}
+	wg.Wait()
+
+	hookCtx, cancelHooks := context.WithTimeout(context.Background(), l.drainTimeout)
+	defer cancelHooks()
+
+	for i := len(hooks) - 1; i >= 0; i-- {
+		if err := hooks[i].run(hookCtx); err != nil {
+			l.logger.Error("Shutdown hook failed", zap.String("hook", hooks[i].name), zap.Error(err))
+		}
+	}
+
+	l.logger.Info("Shutdown complete")
+	// Syncing stdout fails on some platforms, which is not worth reporting.
+	_ = l.logger.Sync()
+}
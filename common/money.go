@@ -0,0 +1,102 @@
+package common
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// Money is an amount in the minor unit of an ISO 4217 currency, e.g. 14999
+// with "USD" for $149.99, so prices never go through floating point.
+type Money struct {
+	Amount       int64  `json:"amount"`
+	CurrencyCode string `json:"currency_code"`
+}
+
+// currencyExponents maps ISO 4217 codes to the number of digits after the
+// decimal separator of their minor unit.
+var currencyExponents = map[string]int{
+	"AED": 2, "ARS": 2, "AUD": 2, "BDT": 2, "BGN": 2, "BHD": 3, "BRL": 2,
+	"CAD": 2, "CHF": 2, "CLP": 0, "CNY": 2, "COP": 2, "CZK": 2, "DKK": 2,
+	"EGP": 2, "EUR": 2, "GBP": 2, "HKD": 2, "HUF": 2, "IDR": 2, "ILS": 2,
+	"INR": 2, "IQD": 3, "ISK": 0, "JOD": 3, "JPY": 0, "KES": 2, "KRW": 0,
+	"KWD": 3, "LKR": 2, "MAD": 2, "MXN": 2, "MYR": 2, "NGN": 2, "NOK": 2,
+	"NPR": 2, "NZD": 2, "OMR": 3, "PEN": 2, "PHP": 2, "PKR": 2, "PLN": 2,
+	"QAR": 2, "RON": 2, "SAR": 2, "SEK": 2, "SGD": 2, "THB": 2, "TND": 3,
+	"TRY": 2, "TWD": 2, "UAH": 2, "UGX": 0, "USD": 2, "VND": 0, "ZAR": 2,
+}
+
+// CurrencyExponent returns the number of minor unit digits of an ISO 4217
+// currency and whether the currency is supported.
+func CurrencyExponent(currencyCode string) (int, bool) {
+	exponent, ok := currencyExponents[strings.ToUpper(currencyCode)]
+	return exponent, ok
+}
+
+// ParseMoney parses a decimal amount such as "149.99" in the given currency,
+// rejecting amounts with more decimals than the currency's minor unit allows.
+func ParseMoney(amount, currencyCode string) (Money, error) {
+	currencyCode = strings.ToUpper(strings.TrimSpace(currencyCode))
+	exponent, ok := CurrencyExponent(currencyCode)
+	if !ok {
+		return Money{}, fmt.Errorf("unsupported currency %q", currencyCode)
+	}
+
+	amount = strings.TrimSpace(amount)
+	negative := strings.HasPrefix(amount, "-")
+	amount = strings.TrimPrefix(amount, "-")
+
+	whole, fraction, _ := strings.Cut(amount, ".")
+	if whole == "" || strings.Trim(whole+fraction, "0123456789") != "" {
+		return Money{}, fmt.Errorf("invalid amount %q", amount)
+	}
+	if len(fraction) > exponent {
+		return Money{}, fmt.Errorf("amount %q has more than %d decimal places for %s", amount, exponent, currencyCode)
+	}
+
+	minorUnits, err := strconv.ParseInt(whole+fraction+strings.Repeat("0", exponent-len(fraction)), 10, 64)
+	if err != nil {
+		return Money{}, fmt.Errorf("invalid amount %q: %w", amount, err)
+	}
+	if negative {
+		minorUnits = -minorUnits
+	}
+
+	return Money{Amount: minorUnits, CurrencyCode: currencyCode}, nil
+}
+
+// MoneyFromFloat converts a floating point amount in major units, rounding to
+// the nearest minor unit. It only exists to read prices stored before Money.
+func MoneyFromFloat(amount float64, currencyCode string) Money {
+	exponent, _ := CurrencyExponent(currencyCode)
+	return Money{
+		Amount:       int64(math.Round(amount * math.Pow10(exponent))),
+		CurrencyCode: strings.ToUpper(currencyCode),
+	}
+}
+
+// Decimal formats the amount in major units, e.g. "149.99".
+func (m Money) Decimal() string {
+	exponent, _ := CurrencyExponent(m.CurrencyCode)
+
+	sign := ""
+	amount := m.Amount
+	if amount < 0 {
+		sign = "-"
+		amount = -amount
+	}
+
+	digits := strconv.FormatInt(amount, 10)
+	if exponent == 0 {
+		return sign + digits
+	}
+	if len(digits) <= exponent {
+		digits = strings.Repeat("0", exponent-len(digits)+1) + digits
+	}
+	return sign + digits[:len(digits)-exponent] + "." + digits[len(digits)-exponent:]
+}
+
+func (m Money) String() string {
+	return m.Decimal() + " " + m.CurrencyCode
+}
@@ -0,0 +1,137 @@
+package common
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/nats-io/nats.go"
+	"github.com/nats-io/nats.go/jetstream"
+	"go.uber.org/zap"
+)
+
+const (
+	natsTimeout          = 5 * time.Second
+	natsReconnectWait    = 2 * time.Second
+	natsSubscriberBuffer = 256
+)
+
+// NATSStream is the JetStream stream that stores the events a service
+// publishes, such as "ACCOUNT_EVENTS" for the subjects "account.>".
+type NATSStream struct {
+	Name     string
+	Subjects []string
+}
+
+// NATSEventBus publishes every event as a JSON-encoded Event on the NATS
+// subject named after its topic. Events are published to a JetStream stream,
+// so Publish only succeeds once the server has stored the event, and the
+// event ID is sent as message ID for the server to drop duplicates. The
+// connection is re-established in the background after it breaks.
+type NATSEventBus struct {
+	conn   *nats.Conn
+	js     jetstream.JetStream
+	logger *zap.Logger
+}
+
+// NewNATSEventBus connects to a server given as nats://[user:password@]host[:port]
+// and creates stream unless it exists already.
+func NewNATSEventBus(url string, stream NATSStream) (*NATSEventBus, error) {
+	logger := GetLogger()
+
+	conn, err := nats.Connect(url,
+		nats.Timeout(natsTimeout),
+		nats.MaxReconnects(-1),
+		nats.ReconnectWait(natsReconnectWait),
+		nats.DisconnectErrHandler(func(_ *nats.Conn, err error) {
+			if err != nil {
+				logger.Warn("NATS connection lost", zap.String("error", err.Error()))
+			}
+		}),
+		nats.ReconnectHandler(func(conn *nats.Conn) {
+			logger.Info("NATS connection restored", zap.String("url", conn.ConnectedUrlRedacted()))
+		}),
+		nats.ErrorHandler(func(_ *nats.Conn, subscription *nats.Subscription, err error) {
+			fields := []zap.Field{zap.String("error", err.Error())}
+			if subscription != nil {
+				fields = append(fields, zap.String("topic", subscription.Subject))
+			}
+			logger.Error("NATS error", fields...)
+		}),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to nats: %w", err)
+	}
+
+	js, err := jetstream.New(conn)
+	if err != nil {
+		conn.Close()
+		return nil, fmt.Errorf("failed to open jetstream: %w", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), natsTimeout)
+	defer cancel()
+	_, err = js.CreateStream(ctx, jetstream.StreamConfig{Name: stream.Name, Subjects: stream.Subjects})
+	if err != nil && !errors.Is(err, jetstream.ErrStreamNameAlreadyInUse) {
+		conn.Close()
+		return nil, fmt.Errorf("failed to create stream %s: %w", stream.Name, err)
+	}
+
+	return &NATSEventBus{conn: conn, js: js, logger: logger}, nil
+}
+
+// Publish waits for the stream to acknowledge the event, and fails when the
+// server rejects or does not store it.
+func (b *NATSEventBus) Publish(ctx context.Context, event Event) error {
+	data, err := json.Marshal(event)
+	if err != nil {
+		return fmt.Errorf("failed to encode event: %w", err)
+	}
+
+	if _, err := b.js.Publish(ctx, event.Topic, data, jetstream.WithMsgID(event.ID)); err != nil {
+		return fmt.Errorf("failed to publish event: %w", err)
+	}
+	return nil
+}
+
+// Subscribe delivers the events of topic to handler one at a time. Events
+// are dropped when the handler falls behind by more than 256 events, as NATS
+// core subscriptions only deliver at most once.
+func (b *NATSEventBus) Subscribe(topic string, handler EventHandler) (func(), error) {
+	messages := make(chan *nats.Msg, natsSubscriberBuffer)
+	subscription, err := b.conn.ChanSubscribe(topic, messages)
+	if err != nil {
+		return nil, fmt.Errorf("failed to subscribe to %s: %w", topic, err)
+	}
+
+	go func() {
+		for message := range messages {
+			var event Event
+			if err := json.Unmarshal(message.Data, &event); err != nil {
+				b.logger.Error("Failed to decode event", zap.String("topic", message.Subject), zap.String("error", err.Error()))
+				continue
+			}
+			if err := handler(context.Background(), event); err != nil {
+				b.logger.Error("Event handler failed", zap.String("topic", event.Topic), zap.String("event_id", event.ID), zap.String("error", err.Error()))
+			}
+		}
+	}()
+
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			if err := subscription.Unsubscribe(); err != nil && !errors.Is(err, nats.ErrConnectionClosed) {
+				b.logger.Warn("Failed to unsubscribe", zap.String("topic", topic), zap.String("error", err.Error()))
+			}
+			close(messages)
+		})
+	}, nil
+}
+
+// Close flushes pending messages and closes the connection.
+func (b *NATSEventBus) Close() error {
+	return b.conn.Drain()
+}
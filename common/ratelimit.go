@@ -0,0 +1,264 @@
+package common
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"net"
+	"net/netip"
+	"strings"
+	"sync"
+	"time"
+
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/durationpb"
+)
+
+type tokenBucket struct {
+	tokens  float64
+	updated time.Time
+}
+
+// RateLimiter keeps a token bucket per key: a key may make burst requests at
+// once and rate requests per second after that. A nil RateLimiter allows
+// every request.
+type RateLimiter struct {
+	rate  float64
+	burst float64
+
+	mu        sync.Mutex
+	buckets   map[string]*tokenBucket
+	lastSweep time.Time
+}
+
+// NewRateLimiter returns nil, which disables limiting, when rate is not
+// positive.
+func NewRateLimiter(rate float64, burst int) *RateLimiter {
+	if rate <= 0 {
+		return nil
+	}
+	return &RateLimiter{
+		rate:      rate,
+		burst:     math.Max(float64(burst), 1),
+		buckets:   map[string]*tokenBucket{},
+		lastSweep: time.Now(),
+	}
+}
+
+// Allow takes a token from the bucket of key. When the bucket is empty it
+// returns false and the time until the next token is available.
+func (l *RateLimiter) Allow(key string) (bool, time.Duration) {
+	if l == nil {
+		return true, 0
+	}
+
+	now := time.Now()
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.sweep(now)
+
+	bucket, ok := l.buckets[key]
+	if !ok {
+		bucket = &tokenBucket{tokens: l.burst, updated: now}
+		l.buckets[key] = bucket
+	}
+
+	bucket.tokens = math.Min(l.burst, bucket.tokens+now.Sub(bucket.updated).Seconds()*l.rate)
+	bucket.updated = now
+
+	if bucket.tokens >= 1 {
+		bucket.tokens--
+		return true, 0
+	}
+	return false, time.Duration((1 - bucket.tokens) / l.rate * float64(time.Second))
+}
+
+// sweep forgets the buckets that have refilled completely, as they behave
+// like new ones. It runs at most once a minute.
+func (l *RateLimiter) sweep(now time.Time) {
+	if now.Sub(l.lastSweep) < time.Minute {
+		return
+	}
+	l.lastSweep = now
+
+	refill := time.Duration(l.burst / l.rate * float64(time.Second))
+	for key, bucket := range l.buckets {
+		if now.Sub(bucket.updated) >= refill {
+			delete(l.buckets, key)
+		}
+	}
+}
+
+// RateLimitError reports a request rejected by a RateLimiter.
+type RateLimitError struct {
+	RetryAfter time.Duration
+}
+
+func (e *RateLimitError) Error() string {
+	return fmt.Sprintf("rate limit exceeded, retry after %s", e.RetryAfter.Round(time.Millisecond))
+}
+
+// GRPCStatus converts the error into a ResourceExhausted status carrying an
+// errdetails.RetryInfo with the delay before the next attempt.
+func (e *RateLimitError) GRPCStatus() *status.Status {
+	st := status.New(codes.ResourceExhausted, e.Error())
+	detailed, err := st.WithDetails(&errdetails.RetryInfo{RetryDelay: durationpb.New(e.RetryAfter)})
+	if err != nil {
+		return st
+	}
+	return detailed
+}
+
+// RetryAfter returns the delay of the RetryInfo attached to a
+// ResourceExhausted gRPC error.
+func RetryAfter(err error) (time.Duration, bool) {
+	st, ok := status.FromError(err)
+	if !ok || st.Code() != codes.ResourceExhausted {
+		return 0, false
+	}
+
+	for _, detail := range st.Details() {
+		if retryInfo, ok := detail.(*errdetails.RetryInfo); ok {
+			return retryInfo.GetRetryDelay().AsDuration(), true
+		}
+	}
+	return 0, false
+}
+
+// rateLimitKeyMetadataKey carries the client a proxy, such as the gateway,
+// makes a call on behalf of.
+const rateLimitKeyMetadataKey = "x-rate-limit-key"
+
+type rateLimitKeyContextKey struct{}
+
+// ContextWithRateLimitKey marks the calls made with ctx as made on behalf of
+// the client identified by key. The clients built with ClientConfig forward
+// it to the services, which limit that client instead of the proxy.
+func ContextWithRateLimitKey(ctx context.Context, key string) context.Context {
+	return context.WithValue(ctx, rateLimitKeyContextKey{}, key)
+}
+
+func outgoingRateLimitKey(ctx context.Context) context.Context {
+	if key, ok := ctx.Value(rateLimitKeyContextKey{}).(string); ok && key != "" {
+		return metadata.AppendToOutgoingContext(ctx, rateLimitKeyMetadataKey, key)
+	}
+	return ctx
+}
+
+// RateLimitKeyUnaryClientInterceptor forwards the key set with
+// ContextWithRateLimitKey with every call.
+func RateLimitKeyUnaryClientInterceptor() grpc.UnaryClientInterceptor {
+	return func(ctx context.Context, method string, req, reply any, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+		return invoker(outgoingRateLimitKey(ctx), method, req, reply, cc, opts...)
+	}
+}
+
+// RateLimitKeyStreamClientInterceptor forwards the key set with
+// ContextWithRateLimitKey with every stream.
+func RateLimitKeyStreamClientInterceptor() grpc.StreamClientInterceptor {
+	return func(ctx context.Context, desc *grpc.StreamDesc, cc *grpc.ClientConn, method string, streamer grpc.Streamer, opts ...grpc.CallOption) (grpc.ClientStream, error) {
+		return streamer(outgoingRateLimitKey(ctx), desc, cc, method, opts...)
+	}
+}
+
+// ParseTrustedPeers parses IP addresses and CIDR prefixes of the proxies whose
+// forwarded metadata, such as rate limit keys, a service trusts.
+func ParseTrustedPeers(peers []string) ([]netip.Prefix, error) {
+	prefixes := make([]netip.Prefix, 0, len(peers))
+	for _, p := range peers {
+		p = strings.TrimSpace(p)
+		if p == "" {
+			continue
+		}
+
+		if prefix, err := netip.ParsePrefix(p); err == nil {
+			prefixes = append(prefixes, prefix.Masked())
+			continue
+		}
+		addr, err := netip.ParseAddr(p)
+		if err != nil {
+			return nil, fmt.Errorf("invalid trusted peer %q", p)
+		}
+		prefixes = append(prefixes, netip.PrefixFrom(addr, addr.BitLen()))
+	}
+	return prefixes, nil
+}
+
+// RateLimitUnaryInterceptor limits the calls of each caller. Calls from
+// trustedPeers count against the client they forward a key for, see
+// ContextWithRateLimitKey, and every other call against the caller's IP
+// address, so a key sent by anyone else is ignored.
+func RateLimitUnaryInterceptor(limiter *RateLimiter, trustedPeers []netip.Prefix) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req any, _ *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		if ok, retryAfter := limiter.Allow(rateLimitCaller(ctx, trustedPeers)); !ok {
+			return nil, &RateLimitError{RetryAfter: retryAfter}
+		}
+		return handler(ctx, req)
+	}
+}
+
+// RateLimitStreamInterceptor limits the streams each caller opens, like
+// RateLimitUnaryInterceptor.
+func RateLimitStreamInterceptor(limiter *RateLimiter, trustedPeers []netip.Prefix) grpc.StreamServerInterceptor {
+	return func(srv any, stream grpc.ServerStream, _ *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		if ok, retryAfter := limiter.Allow(rateLimitCaller(stream.Context(), trustedPeers)); !ok {
+			return &RateLimitError{RetryAfter: retryAfter}
+		}
+		return handler(srv, stream)
+	}
+}
+
+// FromTrustedPeer reports whether the call in ctx was made by one of
+// trustedPeers, whose forwarded metadata a service may rely on.
+func FromTrustedPeer(ctx context.Context, trustedPeers []netip.Prefix) bool {
+	host := peerHost(ctx)
+	if host == "" {
+		return false
+	}
+	addr, err := netip.ParseAddr(host)
+	return err == nil && isTrustedPeer(addr.Unmap(), trustedPeers)
+}
+
+func rateLimitCaller(ctx context.Context, trustedPeers []netip.Prefix) string {
+	host := peerHost(ctx)
+	if host == "" {
+		return ""
+	}
+
+	if FromTrustedPeer(ctx, trustedPeers) {
+		if md, ok := metadata.FromIncomingContext(ctx); ok {
+			if values := md.Get(rateLimitKeyMetadataKey); len(values) > 0 && values[0] != "" {
+				return "client:" + values[0]
+			}
+		}
+	}
+	return "ip:" + host
+}
+
+func peerHost(ctx context.Context) string {
+	p, ok := peer.FromContext(ctx)
+	if !ok || p.Addr == nil {
+		return ""
+	}
+
+	host, _, err := net.SplitHostPort(p.Addr.String())
+	if err != nil {
+		host = p.Addr.String()
+	}
+	return host
+}
+
+func isTrustedPeer(addr netip.Addr, trustedPeers []netip.Prefix) bool {
+	for _, prefix := range trustedPeers {
+		if prefix.Contains(addr) {
+			return true
+		}
+	}
+	return false
+}
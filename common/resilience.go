@@ -0,0 +1,298 @@
+package common
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// ClientConfig controls how a gRPC client calls its downstream service.
+type ClientConfig struct {
+	// Timeout bounds calls whose context carries no deadline of its own.
+	Timeout time.Duration
+
+	// MaxAttempts is the number of tries of an idempotent call that fails
+	// with UNAVAILABLE, waiting between InitialBackoff and MaxBackoff with
+	// jitter in between. Values below 2 turn retries off.
+	MaxAttempts    int
+	InitialBackoff time.Duration
+	MaxBackoff     time.Duration
+
+	// BreakerThreshold consecutive failures open the circuit breaker for
+	// BreakerCooldown. A threshold of 0 turns the breaker off.
+	BreakerThreshold int
+	BreakerCooldown  time.Duration
+
+	// Discovery finds the instances of discovery:/// targets and is asked
+	// again every DiscoveryInterval.
+	Discovery         Discovery
+	DiscoveryInterval time.Duration
+}
+
+func DefaultClientConfig() ClientConfig {
+	return ClientConfig{
+		Timeout:          5 * time.Second,
+		MaxAttempts:      3,
+		InitialBackoff:   100 * time.Millisecond,
+		MaxBackoff:       time.Second,
+		BreakerThreshold: 5,
+		BreakerCooldown:  10 * time.Second,
+
+		DiscoveryInterval: 10 * time.Second,
+	}
+}
+
+// DialOptions returns the resolvers of the static:/// and discovery:///
+// targets, round-robin balancing over the instances, the retry policy for the
+// idempotent methods of service and the circuit breaker interceptors.
+func (c ClientConfig) DialOptions(service string, idempotent []string) []grpc.DialOption {
+	opts := []grpc.DialOption{
+		grpc.WithResolvers(staticBuilder{}, discoveryBuilder{discovery: c.Discovery, interval: c.DiscoveryInterval}),
+		grpc.WithDefaultServiceConfig(c.serviceConfig(service, idempotent)),
+		grpc.WithChainUnaryInterceptor(ServiceErrorUnaryInterceptor(), RateLimitKeyUnaryClientInterceptor()),
+		grpc.WithChainStreamInterceptor(RateLimitKeyStreamClientInterceptor()),
+	}
+	if breaker := NewCircuitBreaker(service, c.BreakerThreshold, c.BreakerCooldown); breaker != nil {
+		opts = append(opts,
+			grpc.WithChainUnaryInterceptor(breaker.UnaryClientInterceptor()),
+			grpc.WithChainStreamInterceptor(breaker.StreamClientInterceptor()),
+		)
+	}
+	return opts
+}
+
+type methodName struct {
+	Service string `json:"service"`
+	Method  string `json:"method"`
+}
+
+type retryPolicy struct {
+	MaxAttempts          int      `json:"maxAttempts"`
+	InitialBackoff       string   `json:"initialBackoff"`
+	MaxBackoff           string   `json:"maxBackoff"`
+	BackoffMultiplier    float64  `json:"backoffMultiplier"`
+	RetryableStatusCodes []string `json:"retryableStatusCodes"`
+}
+
+type methodConfig struct {
+	Name        []methodName `json:"name"`
+	RetryPolicy *retryPolicy `json:"retryPolicy,omitempty"`
+}
+
+type serviceConfig struct {
+	LoadBalancingConfig []map[string]struct{} `json:"loadBalancingConfig"`
+	MethodConfig        []methodConfig        `json:"methodConfig,omitempty"`
+}
+
+func (c ClientConfig) serviceConfig(service string, idempotent []string) string {
+	config := serviceConfig{
+		LoadBalancingConfig: []map[string]struct{}{{"round_robin": {}}},
+	}
+	if c.MaxAttempts >= 2 && len(idempotent) > 0 {
+		method := methodConfig{
+			RetryPolicy: &retryPolicy{
+				MaxAttempts:          c.MaxAttempts,
+				InitialBackoff:       protoDuration(c.InitialBackoff),
+				MaxBackoff:           protoDuration(max(c.MaxBackoff, c.InitialBackoff)),
+				BackoffMultiplier:    2,
+				RetryableStatusCodes: []string{"UNAVAILABLE"},
+			},
+		}
+		for _, name := range idempotent {
+			method.Name = append(method.Name, methodName{Service: service, Method: name})
+		}
+		config.MethodConfig = append(config.MethodConfig, method)
+	}
+
+	data, _ := json.Marshal(config)
+	return string(data)
+}
+
+// protoDuration formats d the way the JSON form of google.protobuf.Duration
+// expects it.
+func protoDuration(d time.Duration) string {
+	if d <= 0 {
+		d = time.Millisecond
+	}
+	return strconv.FormatFloat(d.Seconds(), 'f', -1, 64) + "s"
+}
+
+// WithDefaultTimeout keeps the deadline of ctx, such as the one of the
+// incoming request, and only falls back to timeout when there is none.
+func WithDefaultTimeout(ctx context.Context, timeout time.Duration) (context.Context, context.CancelFunc) {
+	if _, ok := ctx.Deadline(); ok || timeout <= 0 {
+		return context.WithCancel(ctx)
+	}
+	return context.WithTimeout(ctx, timeout)
+}
+
+// ServiceError tags an error of a gRPC call with the service that returned
+// it. It keeps the status of the error, details included.
+type ServiceError struct {
+	Service string
+	Err     error
+}
+
+func (e *ServiceError) Error() string {
+	return e.Err.Error()
+}
+
+func (e *ServiceError) Unwrap() error {
+	return e.Err
+}
+
+func (e *ServiceError) GRPCStatus() *status.Status {
+	return status.Convert(e.Err)
+}
+
+// ServiceErrorUnaryInterceptor wraps the errors of calls in a ServiceError
+// named after the called service.
+func ServiceErrorUnaryInterceptor() grpc.UnaryClientInterceptor {
+	return func(ctx context.Context, method string, req, reply any, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+		err := invoker(ctx, method, req, reply, cc, opts...)
+		if err == nil {
+			return nil
+		}
+		service, _, _ := strings.Cut(strings.TrimPrefix(method, "/"), "/")
+		return &ServiceError{Service: service, Err: err}
+	}
+}
+
+type breakerState int
+
+const (
+	breakerClosed breakerState = iota
+	breakerOpen
+	breakerHalfOpen
+)
+
+// CircuitBreaker fails calls to a service fast once it stopped answering.
+// After threshold consecutive failures it opens and rejects calls with
+// UNAVAILABLE for cooldown. It then lets a single call through and closes
+// again when that call succeeds.
+type CircuitBreaker struct {
+	name      string
+	threshold int
+	cooldown  time.Duration
+	logger    *zap.Logger
+
+	mu       sync.Mutex
+	state    breakerState
+	failures int
+	openedAt time.Time
+}
+
+// NewCircuitBreaker returns nil, which never rejects a call, when threshold
+// is not positive.
+func NewCircuitBreaker(name string, threshold int, cooldown time.Duration) *CircuitBreaker {
+	if threshold <= 0 {
+		return nil
+	}
+	return &CircuitBreaker{name: name, threshold: threshold, cooldown: cooldown, logger: GetLogger()}
+}
+
+// allow reports whether a call may go ahead. In the half-open state only the
+// probe is allowed, which the caller must report back through done.
+func (b *CircuitBreaker) allow(probe bool) bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	switch b.state {
+	case breakerOpen:
+		if time.Since(b.openedAt) < b.cooldown {
+			return false
+		}
+		if !probe {
+			return true
+		}
+		b.state = breakerHalfOpen
+		return true
+	case breakerHalfOpen:
+		return false
+	default:
+		return true
+	}
+}
+
+func (b *CircuitBreaker) done(ctx context.Context, err error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if ctx.Err() != nil {
+		// The caller gave up or ran out of time, which says nothing about
+		// the service.
+		if b.state == breakerHalfOpen {
+			b.state = breakerOpen
+		}
+		return
+	}
+
+	if !IsServiceFailure(ctx, err) {
+		if b.state != breakerClosed {
+			b.logger.Info("Circuit breaker closed", zap.String("service", b.name))
+		}
+		b.state = breakerClosed
+		b.failures = 0
+		return
+	}
+
+	b.failures++
+	if b.state == breakerHalfOpen || b.failures >= b.threshold {
+		if b.state != breakerOpen {
+			b.logger.Warn("Circuit breaker opened", zap.String("service", b.name), zap.Int("failures", b.failures), zap.Error(err))
+		}
+		b.state = breakerOpen
+		b.openedAt = time.Now()
+	}
+}
+
+func (b *CircuitBreaker) openError() error {
+	return status.Error(codes.Unavailable, fmt.Sprintf("%s is unavailable: circuit breaker open", b.name))
+}
+
+// IsServiceFailure tells failures of the service apart from errors that are
+// answers, such as NOT_FOUND or INVALID_ARGUMENT. A call that failed because
+// ctx was canceled or reached its own deadline is not a service failure.
+func IsServiceFailure(ctx context.Context, err error) bool {
+	if ctx.Err() != nil {
+		return false
+	}
+
+	switch status.Code(err) {
+	case codes.Unavailable, codes.DeadlineExceeded:
+		return true
+	default:
+		return false
+	}
+}
+
+func (b *CircuitBreaker) UnaryClientInterceptor() grpc.UnaryClientInterceptor {
+	return func(ctx context.Context, method string, req, reply any, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+		if !b.allow(true) {
+			return b.openError()
+		}
+		err := invoker(ctx, method, req, reply, cc, opts...)
+		b.done(ctx, err)
+		return err
+	}
+}
+
+// StreamClientInterceptor rejects new streams while the breaker is open.
+// Streams live too long to count towards its failures.
+func (b *CircuitBreaker) StreamClientInterceptor() grpc.StreamClientInterceptor {
+	return func(ctx context.Context, desc *grpc.StreamDesc, cc *grpc.ClientConn, method string, streamer grpc.Streamer, opts ...grpc.CallOption) (grpc.ClientStream, error) {
+		if !b.allow(false) {
+			return nil, b.openError()
+		}
+		return streamer(ctx, desc, cc, method, opts...)
+	}
+}
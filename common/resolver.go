@@ -0,0 +1,160 @@
+package common
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"go.uber.org/zap"
+	"google.golang.org/grpc/resolver"
+)
+
+// Besides host:port and dns:///host:port, clients dial these targets:
+//
+//	static:///host1:port,host2:port  a fixed list of instances
+//	discovery:///product             the instances ClientConfig.Discovery finds
+const (
+	StaticScheme    = "static"
+	DiscoveryScheme = "discovery"
+)
+
+type staticBuilder struct{}
+
+func (staticBuilder) Scheme() string {
+	return StaticScheme
+}
+
+func (staticBuilder) Build(target resolver.Target, cc resolver.ClientConn, _ resolver.BuildOptions) (resolver.Resolver, error) {
+	var addresses []resolver.Address
+	for _, addr := range strings.Split(target.Endpoint(), ",") {
+		if addr = strings.TrimSpace(addr); addr != "" {
+			addresses = append(addresses, resolver.Address{Addr: addr})
+		}
+	}
+	if len(addresses) == 0 {
+		return nil, fmt.Errorf("static target %q has no addresses", target.URL.String())
+	}
+
+	if err := cc.UpdateState(resolver.State{Addresses: addresses}); err != nil {
+		return nil, err
+	}
+	return staticResolver{}, nil
+}
+
+type staticResolver struct{}
+
+func (staticResolver) ResolveNow(resolver.ResolveNowOptions) {}
+
+func (staticResolver) Close() {}
+
+type discoveryBuilder struct {
+	discovery Discovery
+	interval  time.Duration
+}
+
+func (b discoveryBuilder) Scheme() string {
+	return DiscoveryScheme
+}
+
+func (b discoveryBuilder) Build(target resolver.Target, cc resolver.ClientConn, _ resolver.BuildOptions) (resolver.Resolver, error) {
+	if b.discovery == nil {
+		return nil, fmt.Errorf("no service discovery configured for %q", target.URL.String())
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	r := &discoveryResolver{
+		service:   target.Endpoint(),
+		discovery: b.discovery,
+		interval:  b.interval,
+		cc:        cc,
+		logger:    GetLogger(),
+		refresh:   make(chan struct{}, 1),
+		cancel:    cancel,
+	}
+	if r.interval <= 0 {
+		r.interval = DefaultClientConfig().DiscoveryInterval
+	}
+	go r.run(ctx)
+	return r, nil
+}
+
+// discoveryResolver looks up the instances of service every interval, and
+// whenever gRPC asks for it after a connection failed.
+type discoveryResolver struct {
+	service   string
+	discovery Discovery
+	interval  time.Duration
+	cc        resolver.ClientConn
+	logger    *zap.Logger
+
+	refresh chan struct{}
+	cancel  context.CancelFunc
+
+	addresses []string
+	failed    bool
+}
+
+func (r *discoveryResolver) run(ctx context.Context) {
+	ticker := time.NewTicker(r.interval)
+	defer ticker.Stop()
+
+	for {
+		r.lookup(ctx)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		case <-r.refresh:
+		}
+	}
+}
+
+func (r *discoveryResolver) lookup(ctx context.Context) {
+	lookupCtx, cancel := context.WithTimeout(ctx, r.interval)
+	addresses, err := r.discovery.Lookup(lookupCtx, r.service)
+	cancel()
+	if ctx.Err() != nil {
+		return
+	}
+	if err != nil {
+		r.logger.Error("Service discovery failed", zap.String("service", r.service), zap.Error(err))
+		r.cc.ReportError(err)
+		r.failed = true
+		return
+	}
+
+	addresses = slices.Clone(addresses)
+	slices.Sort(addresses)
+	addresses = slices.Compact(addresses)
+	if !r.failed && slices.Equal(addresses, r.addresses) {
+		return
+	}
+
+	state := resolver.State{}
+	for _, addr := range addresses {
+		state.Addresses = append(state.Addresses, resolver.Address{Addr: addr})
+	}
+	if err := r.cc.UpdateState(state); err != nil {
+		r.logger.Error("Failed to update service instances", zap.String("service", r.service), zap.Error(err))
+		r.failed = true
+		return
+	}
+
+	r.logger.Info("Service instances updated", zap.String("service", r.service), zap.Strings("addresses", addresses))
+	r.addresses = addresses
+	r.failed = false
+}
+
+func (r *discoveryResolver) ResolveNow(resolver.ResolveNowOptions) {
+	select {
+	case r.refresh <- struct{}{}:
+	default:
+	}
+}
+
+func (r *discoveryResolver) Close() {
+	r.cancel()
+}
@@ -0,0 +1,61 @@
+package common
+
+import (
+	"fmt"
+	"strings"
+
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+type FieldViolation struct {
+	Field       string
+	Description string
+}
+
+// ValidationError collects every field violation found in a request so callers
+// can report them all at once instead of failing on the first one.
+type ValidationError struct {
+	Violations []FieldViolation
+}
+
+func (e *ValidationError) Add(field, description string) {
+	e.Violations = append(e.Violations, FieldViolation{Field: field, Description: description})
+}
+
+// Err returns nil when no violations were recorded, so the result can be
+// returned directly from a validation function.
+func (e *ValidationError) Err() error {
+	if len(e.Violations) == 0 {
+		return nil
+	}
+	return e
+}
+
+func (e *ValidationError) Error() string {
+	messages := make([]string, 0, len(e.Violations))
+	for _, v := range e.Violations {
+		messages = append(messages, fmt.Sprintf("%s: %s", v.Field, v.Description))
+	}
+	return "invalid input: " + strings.Join(messages, "; ")
+}
+
+// GRPCStatus converts the violations into an InvalidArgument status carrying
+// an errdetails.BadRequest, which status.FromError picks up automatically.
+func (e *ValidationError) GRPCStatus() *status.Status {
+	badRequest := &errdetails.BadRequest{}
+	for _, v := range e.Violations {
+		badRequest.FieldViolations = append(badRequest.FieldViolations, &errdetails.BadRequest_FieldViolation{
+			Field:       v.Field,
+			Description: v.Description,
+		})
+	}
+
+	st := status.New(codes.InvalidArgument, e.Error())
+	detailed, err := st.WithDetails(badRequest)
+	if err != nil {
+		return st
+	}
+	return detailed
+}
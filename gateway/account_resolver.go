@@ -3,7 +3,12 @@ package main
 import (
 	"context"
 	"errors"
+	"graphql-grpc-go-microservice-project/account"
 	"graphql-grpc-go-microservice-project/gateway/models"
+	"graphql-grpc-go-microservice-project/gateway/utils"
+	"graphql-grpc-go-microservice-project/product"
+
+	"github.com/99designs/gqlgen/graphql"
 )
 
 type accountResolver struct {
@@ -16,3 +21,72 @@ func (r *accountResolver) ID(ctx context.Context, obj *models.Account) (string,
 	}
 	return obj.ID.String(), nil
 }
+
+// Wishlists are only shown to the account itself, staff and admins.
+func (r *accountResolver) Wishlists(ctx context.Context, obj *models.Account) ([]*models.Wishlist, error) {
+	id := obj.ID.String()
+	if err := ownAccountOrStaff(ctx, r.server.AccountClient, id); err != nil {
+		return nil, err
+	}
+
+	wishlists, err := r.server.AccountClient.ListWishlists(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	return r.server.resolveWishlists(ctx, wishlists...)
+}
+
+// Addresses are only shown to the account itself, staff and admins.
+func (r *accountResolver) Addresses(ctx context.Context, obj *models.Account) ([]*models.Address, error) {
+	id := obj.ID.String()
+	if err := ownAccountOrStaff(ctx, r.server.AccountClient, id); err != nil {
+		return nil, err
+	}
+
+	addresses, err := r.server.AccountClient.ListAddresses(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	result := make([]*models.Address, 0, len(addresses))
+	for _, a := range addresses {
+		result = append(result, utils.ConvertAddressToModel(&a))
+	}
+	return result, nil
+}
+
+// resolveWishlists loads the products of all given wishlists with a single
+// ListProductsWithIDs call. When the product service fails, the wishlists are
+// still returned, without products and with the error added to the response.
+func (s *GatewayServer) resolveWishlists(ctx context.Context, wishlists ...account.Wishlist) ([]*models.Wishlist, error) {
+	ids := []string{}
+	seen := map[string]bool{}
+	for _, wishlist := range wishlists {
+		for _, item := range wishlist.Items {
+			if !seen[item.ProductID] {
+				seen[item.ProductID] = true
+				ids = append(ids, item.ProductID)
+			}
+		}
+	}
+
+	products := map[string]*product.Product{}
+	if len(ids) > 0 {
+		found, err := s.ProductClient.ListProductsWithIDs(ctx, ids, uint32(len(ids)), 0)
+		if err != nil {
+			graphql.AddError(ctx, err)
+			products = nil
+		} else {
+			for _, p := range found {
+				products[p.ID] = p
+			}
+		}
+	}
+
+	resolved := make([]*models.Wishlist, 0, len(wishlists))
+	for _, wishlist := range wishlists {
+		resolved = append(resolved, utils.ConvertWishlistToModel(&wishlist, products))
+	}
+	return resolved, nil
+}
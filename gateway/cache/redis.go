@@ -0,0 +1,66 @@
+package cache
+
+import (
+	"context"
+	"errors"
+	"log"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// RedisStore keeps values in Redis, shared by all gateway instances. Keys are
+// namespaced by a generation number that Clear increments, so that a clear
+// takes a single command and the old values simply expire. Failed commands
+// are logged and count as cache misses.
+type RedisStore struct {
+	client *redis.Client
+	prefix string
+}
+
+func NewRedisStore(client *redis.Client, prefix string) *RedisStore {
+	return &RedisStore{client: client, prefix: prefix}
+}
+
+func (s *RedisStore) Get(ctx context.Context, key string) ([]byte, bool) {
+	generation, err := s.generation(ctx)
+	if err != nil {
+		log.Printf("Failed to read cache generation: %v", err)
+		return nil, false
+	}
+
+	value, err := s.client.Get(ctx, s.prefix+generation+":"+key).Bytes()
+	if err != nil {
+		if !errors.Is(err, redis.Nil) {
+			log.Printf("Failed to read cache entry %s: %v", key, err)
+		}
+		return nil, false
+	}
+	return value, true
+}
+
+func (s *RedisStore) Set(ctx context.Context, key string, value []byte, ttl time.Duration) {
+	generation, err := s.generation(ctx)
+	if err != nil {
+		log.Printf("Failed to read cache generation: %v", err)
+		return
+	}
+
+	if err := s.client.Set(ctx, s.prefix+generation+":"+key, value, ttl).Err(); err != nil {
+		log.Printf("Failed to write cache entry %s: %v", key, err)
+	}
+}
+
+func (s *RedisStore) Clear(ctx context.Context) {
+	if err := s.client.Incr(ctx, s.prefix+"generation").Err(); err != nil {
+		log.Printf("Failed to clear cache: %v", err)
+	}
+}
+
+func (s *RedisStore) generation(ctx context.Context) (string, error) {
+	generation, err := s.client.Get(ctx, s.prefix+"generation").Result()
+	if errors.Is(err, redis.Nil) {
+		return "0", nil
+	}
+	return generation, err
+}
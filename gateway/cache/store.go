@@ -0,0 +1,87 @@
+package cache
+
+import (
+	"container/list"
+	"context"
+	"sync"
+	"time"
+)
+
+// Store keeps cached values by key until their TTL runs out. Clear drops
+// every value at once, on all gateway instances when the store is shared.
+type Store interface {
+	Get(ctx context.Context, key string) ([]byte, bool)
+	Set(ctx context.Context, key string, value []byte, ttl time.Duration)
+	Clear(ctx context.Context)
+}
+
+type memoryEntry struct {
+	key       string
+	value     []byte
+	expiresAt time.Time
+}
+
+// MemoryStore keeps at most size values in the gateway process and evicts
+// the least recently used one when full.
+type MemoryStore struct {
+	size int
+
+	mu      sync.Mutex
+	entries map[string]*list.Element
+	order   *list.List
+}
+
+func NewMemoryStore(size int) *MemoryStore {
+	return &MemoryStore{
+		size:    max(size, 1),
+		entries: map[string]*list.Element{},
+		order:   list.New(),
+	}
+}
+
+func (s *MemoryStore) Get(_ context.Context, key string) ([]byte, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	element, ok := s.entries[key]
+	if !ok {
+		return nil, false
+	}
+
+	entry := element.Value.(*memoryEntry)
+	if time.Now().After(entry.expiresAt) {
+		s.order.Remove(element)
+		delete(s.entries, key)
+		return nil, false
+	}
+
+	s.order.MoveToFront(element)
+	return entry.value, true
+}
+
+func (s *MemoryStore) Set(_ context.Context, key string, value []byte, ttl time.Duration) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	entry := &memoryEntry{key: key, value: value, expiresAt: time.Now().Add(ttl)}
+	if element, ok := s.entries[key]; ok {
+		element.Value = entry
+		s.order.MoveToFront(element)
+		return
+	}
+
+	s.entries[key] = s.order.PushFront(entry)
+	for s.order.Len() > s.size {
+		oldest := s.order.Back()
+		s.order.Remove(oldest)
+		delete(s.entries, oldest.Value.(*memoryEntry).key)
+	}
+}
+
+func (s *MemoryStore) Clear(context.Context) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.entries = map[string]*list.Element{}
+	s.order.Init()
+}
@@ -0,0 +1,136 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"sync"
+
+	"graphql-grpc-go-microservice-project/gateway/models"
+
+	"github.com/99designs/gqlgen/graphql"
+	"github.com/vektah/gqlparser/v2/ast"
+)
+
+// cachePolicy collects the @cacheControl hints of the fields resolved for
+// one request.
+type cachePolicy struct {
+	header http.Header
+
+	mu         sync.Mutex
+	maxAge     int
+	private    bool
+	hinted     bool
+	rootHinted map[string]bool
+}
+
+func (p *cachePolicy) hint(rootField string, maxAge int, scope *models.CacheControlScope) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if !p.hinted || maxAge < p.maxAge {
+		p.maxAge = maxAge
+	}
+	p.hinted = true
+	if scope != nil && *scope == models.CacheControlScopePrivate {
+		p.private = true
+	}
+	if rootField != "" {
+		p.rootHinted[rootField] = true
+	}
+}
+
+// uncacheable keeps the response from getting a Cache-Control header, such as
+// when it holds stale data.
+func (p *cachePolicy) uncacheable() {
+	p.hint("", 0, nil)
+}
+
+// value returns the Cache-Control header for a response with the given root
+// fields, or "" when one of them is not cacheable.
+func (p *cachePolicy) value(rootFields []string) string {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if !p.hinted || p.maxAge <= 0 {
+		return ""
+	}
+	for _, field := range rootFields {
+		if field != "__typename" && !p.rootHinted[field] {
+			return ""
+		}
+	}
+
+	scope := "public"
+	if p.private {
+		scope = "private"
+	}
+	return fmt.Sprintf("max-age=%d, %s", p.maxAge, scope)
+}
+
+type cachePolicyKey struct{}
+
+func cachePolicyFromContext(ctx context.Context) *cachePolicy {
+	policy, _ := ctx.Value(cachePolicyKey{}).(*cachePolicy)
+	return policy
+}
+
+// withCacheControl lets the @cacheControl hints of a query set the
+// Cache-Control header of its response. Subscriptions are never cached.
+func withCacheControl(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Upgrade") == "" {
+			policy := &cachePolicy{header: w.Header(), rootHinted: map[string]bool{}}
+			r = r.WithContext(context.WithValue(r.Context(), cachePolicyKey{}, policy))
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+func cacheControlDirective(ctx context.Context, _ any, next graphql.Resolver, maxAge int, scope *models.CacheControlScope) (any, error) {
+	if policy := cachePolicyFromContext(ctx); policy != nil {
+		var rootField string
+		if field := graphql.GetFieldContext(ctx); field != nil && field.Object == "Query" {
+			rootField = field.Field.Name
+		}
+		policy.hint(rootField, maxAge, scope)
+	}
+	return next(ctx)
+}
+
+// cacheControl sets the Cache-Control header of successful queries whose
+// root fields all carry a @cacheControl hint, using the lowest maxAge of the
+// fields resolved. Responses with errors are never cached.
+type cacheControl struct{}
+
+var _ interface {
+	graphql.ResponseInterceptor
+	graphql.HandlerExtension
+} = cacheControl{}
+
+func (cacheControl) ExtensionName() string {
+	return "CacheControl"
+}
+
+func (cacheControl) Validate(graphql.ExecutableSchema) error {
+	return nil
+}
+
+func (cacheControl) InterceptResponse(ctx context.Context, next graphql.ResponseHandler) *graphql.Response {
+	response := next(ctx)
+
+	policy := cachePolicyFromContext(ctx)
+	if policy == nil || response == nil || len(response.Errors) > 0 || !graphql.HasOperationContext(ctx) {
+		return response
+	}
+
+	operation := graphql.GetOperationContext(ctx).Operation
+	if operation == nil || operation.Operation != ast.Query {
+		return response
+	}
+
+	if value := policy.value(rootFields(operation.SelectionSet)); value != "" {
+		policy.header.Set("Cache-Control", value)
+	}
+	return response
+}
@@ -0,0 +1,163 @@
+package main
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"log"
+	"slices"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"graphql-grpc-go-microservice-project/common"
+	"graphql-grpc-go-microservice-project/gateway/cache"
+	"graphql-grpc-go-microservice-project/product"
+)
+
+// catalogCache serves repeated product reads from a cache.Store instead of
+// the product service. The product mutations call Invalidate, which only
+// reaches the local store, so Watch also invalidates on the product events of
+// every other writer, such as other gateway instances. Every entry expires
+// after ttl, which bounds how stale products get while the event stream is
+// down. When the product service fails, expired entries are still served for
+// staleTTL. A nil store disables caching.
+type catalogCache struct {
+	client   *product.ProductClient
+	store    cache.Store
+	ttl      time.Duration
+	staleTTL time.Duration
+
+	// version changes on every Invalidate, so that a read that started
+	// before it does not store its outdated result afterwards.
+	version atomic.Uint64
+}
+
+func newCatalogCache(client *product.ProductClient, store cache.Store, ttl, staleTTL time.Duration) *catalogCache {
+	return &catalogCache{client: client, store: store, ttl: ttl, staleTTL: staleTTL}
+}
+
+func (c *catalogCache) GetProductByID(ctx context.Context, id string) (*product.Product, error) {
+	var result *product.Product
+	err := c.cached(ctx, "product:"+id, &result, func() (err error) {
+		result, err = c.client.GetProductByID(ctx, id)
+		return err
+	})
+	return result, err
+}
+
+func (c *catalogCache) ListProducts(ctx context.Context, limit, offset uint32) ([]*product.Product, error) {
+	var result []*product.Product
+	err := c.cached(ctx, fmt.Sprintf("products:%d:%d", limit, offset), &result, func() (err error) {
+		result, err = c.client.ListProducts(ctx, limit, offset)
+		return err
+	})
+	return result, err
+}
+
+func (c *catalogCache) SearchProducts(ctx context.Context, query string, filter product.ProductSearchFilter, orderBy product.ProductOrderField, limit, offset uint32) ([]*product.Product, error) {
+	var result []*product.Product
+	err := c.cached(ctx, searchKey(query, filter, orderBy, limit, offset), &result, func() (err error) {
+		result, err = c.client.SearchProducts(ctx, query, filter, orderBy, limit, offset)
+		return err
+	})
+	return result, err
+}
+
+// Invalidate drops every cached read after a product or category changed.
+func (c *catalogCache) Invalidate(ctx context.Context) {
+	c.version.Add(1)
+	if c.store != nil {
+		c.store.Clear(ctx)
+	}
+}
+
+// catalogWatchRetry is the pause before Watch reopens a broken event stream.
+const catalogWatchRetry = 5 * time.Second
+
+// Watch invalidates the cache on every product event until ctx is done. Events
+// missed while the stream was broken are made up for by invalidating once it
+// is open again.
+func (c *catalogCache) Watch(ctx context.Context) {
+	for {
+		events, err := c.client.WatchProducts(ctx, "")
+		if err == nil {
+			c.Invalidate(ctx)
+			for range events {
+				c.Invalidate(ctx)
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(catalogWatchRetry):
+		}
+	}
+}
+
+// catalogEntry is a cached read and the time it was loaded at.
+type catalogEntry struct {
+	Value    json.RawMessage `json:"value"`
+	LoadedAt time.Time       `json:"loadedAt"`
+}
+
+// cached decodes the entry for key into value, or calls load to fill value
+// and stores it. Errors of load are never cached, but an expired entry is
+// used instead when the product service is unavailable.
+func (c *catalogCache) cached(ctx context.Context, key string, value any, load func() error) error {
+	if c.store == nil {
+		return load()
+	}
+
+	var entry catalogEntry
+	data, found := c.store.Get(ctx, key)
+	found = found && json.Unmarshal(data, &entry) == nil
+	if found && time.Since(entry.LoadedAt) < c.ttl && json.Unmarshal(entry.Value, value) == nil {
+		return nil
+	}
+
+	version := c.version.Load()
+	if err := load(); err != nil {
+		if found && common.IsServiceFailure(ctx, err) && json.Unmarshal(entry.Value, value) == nil {
+			log.Printf("Serving stale %s loaded at %s: %v", key, entry.LoadedAt.Format(time.RFC3339), err)
+			if policy := cachePolicyFromContext(ctx); policy != nil {
+				policy.uncacheable()
+			}
+			return nil
+		}
+		return err
+	}
+
+	encoded, err := json.Marshal(value)
+	if err != nil || c.version.Load() != version {
+		return nil
+	}
+	if data, err := json.Marshal(catalogEntry{Value: encoded, LoadedAt: time.Now()}); err == nil {
+		c.store.Set(ctx, key, data, c.ttl+c.staleTTL)
+	}
+	return nil
+}
+
+// searchKey normalizes the search arguments, so that searches differing only
+// in letter case, whitespace or attribute order share an entry.
+func searchKey(query string, filter product.ProductSearchFilter, orderBy product.ProductOrderField, limit, offset uint32) string {
+	attributes := make([]string, 0, len(filter.Attributes))
+	for _, attribute := range filter.Attributes {
+		attributes = append(attributes, strings.ToLower(strings.TrimSpace(attribute.Key))+"="+attribute.Value)
+	}
+	slices.Sort(attributes)
+
+	normalized, _ := json.Marshal([]any{
+		strings.Join(strings.Fields(strings.ToLower(query)), " "),
+		attributes,
+		filter.MinRating,
+		orderBy,
+		limit,
+		offset,
+	})
+	sum := sha256.Sum256(normalized)
+	return "search:" + hex.EncodeToString(sum[:])
+}
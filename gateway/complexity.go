@@ -0,0 +1,84 @@
+package main
+
+import (
+	gatewayGraphQL "graphql-grpc-go-microservice-project/gateway/graphql"
+	"graphql-grpc-go-microservice-project/gateway/models"
+)
+
+const (
+	// callCost is charged for every field that makes a gRPC call of its own.
+	callCost = 10
+
+	// maxPageSize is the largest page a paginated field returns, and the page
+	// size assumed when a query passes no pagination.
+	maxPageSize = 20
+
+	// estimatedListSize is the size assumed for lists that cannot be
+	// paginated, such as the variants of a product or wishlists of an account.
+	estimatedListSize = 10
+)
+
+// newComplexityRoot estimates the cost of each field as the gRPC calls it
+// makes plus the cost of its selections times the number of items it returns.
+// Fields without an entry cost one plus their selections.
+func newComplexityRoot() gatewayGraphQL.ComplexityRoot {
+	var c gatewayGraphQL.ComplexityRoot
+
+	c.Query.GetAccountByID = func(childComplexity int, _ string) int { return callCost + childComplexity }
+	c.Query.GetAccountByEmail = func(childComplexity int, _ string) int { return callCost + childComplexity }
+	c.Query.ListAccounts = func(childComplexity int, pagination *models.PaginationInput, _ *models.AccountFilterInput, _ *models.AccountOrderByInput) int {
+		return callCost + pageSize(pagination)*childComplexity
+	}
+	c.Query.GetAccountHistory = func(childComplexity int, _ string, pagination *models.PaginationInput) int {
+		return callCost + pageSize(pagination)*childComplexity
+	}
+	c.Query.GetProductByID = func(childComplexity int, _ string) int { return callCost + childComplexity }
+	c.Query.GetProductBySku = func(childComplexity int, _ string) int { return callCost + childComplexity }
+	c.Query.ListProducts = func(childComplexity int, pagination *models.PaginationInput) int {
+		return callCost + pageSize(pagination)*childComplexity
+	}
+	c.Query.ListProductsWithIDs = func(childComplexity int, ids []string, pagination *models.PaginationInput) int {
+		count := len(ids)
+		if pagination != nil && pagination.Limit > 0 && pagination.Limit < count {
+			count = pagination.Limit
+		}
+		return callCost + count*childComplexity
+	}
+	c.Query.SearchProducts = func(childComplexity int, _ string, _ []*models.ProductAttributeInput, inStock *bool, _ *float64, _ *models.ProductOrderField, pagination *models.PaginationInput) int {
+		cost := callCost
+		if inStock != nil {
+			cost *= availabilityScanMaxBatches
+		}
+		return cost + pageSize(pagination)*childComplexity
+	}
+	c.Query.Categories = func(childComplexity int) int { return callCost + estimatedListSize*childComplexity }
+	c.Query.ProductsInCategory = func(childComplexity int, _ string, pagination *models.PaginationInput) int {
+		return callCost + pageSize(pagination)*childComplexity
+	}
+	c.Query.Cart = func(childComplexity int, _ *models.CartOwnerInput) int { return callCost + childComplexity }
+	c.Query.Order = func(childComplexity int, _ string) int { return callCost + childComplexity }
+
+	c.Account.Wishlists = func(childComplexity int) int { return 2*callCost + estimatedListSize*childComplexity }
+	c.Account.Addresses = func(childComplexity int) int { return callCost + estimatedListSize*childComplexity }
+	c.Wishlist.Items = func(childComplexity int) int { return estimatedListSize * childComplexity }
+
+	c.Product.Stock = func(childComplexity int) int { return callCost + childComplexity }
+	c.Product.Reviews = func(childComplexity int, pagination *models.PaginationInput) int {
+		return callCost + pageSize(pagination)*childComplexity
+	}
+	c.Product.Categories = func(childComplexity int) int { return estimatedListSize * childComplexity }
+	c.Product.Variants = func(childComplexity int) int { return estimatedListSize * childComplexity }
+	c.ProductVariant.Attributes = func(childComplexity int) int { return estimatedListSize * childComplexity }
+
+	c.Cart.Items = func(childComplexity int) int { return estimatedListSize * childComplexity }
+	c.Order.Items = func(childComplexity int) int { return estimatedListSize * childComplexity }
+
+	return c
+}
+
+func pageSize(pagination *models.PaginationInput) int {
+	if pagination == nil || pagination.Limit <= 0 || pagination.Limit > maxPageSize {
+		return maxPageSize
+	}
+	return pagination.Limit
+}
@@ -0,0 +1,65 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"unicode"
+
+	"graphql-grpc-go-microservice-project/common"
+
+	"github.com/99designs/gqlgen/graphql"
+	"github.com/99designs/gqlgen/graphql/errcode"
+	"github.com/vektah/gqlparser/v2/gqlerror"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// presentError adds the service that failed and its gRPC code to the
+// extensions of errors from the downstream services:
+//
+//	{"code": "UNAVAILABLE", "service": "ProductService", "grpcCode": "UNAVAILABLE"}
+//
+// The code is only set when the gateway did not pick a more specific one,
+// such as RATE_LIMITED, or UNAUTHENTICATED and FORBIDDEN for requests that
+// are not signed in as the account they act on.
+func presentError(ctx context.Context, err error) *gqlerror.Error {
+	presented := graphql.DefaultErrorPresenter(ctx, err)
+	presentRateLimit(ctx, err, presented)
+
+	switch {
+	case errors.Is(err, errSignInRequired):
+		errcode.Set(presented, "UNAUTHENTICATED")
+	case errors.Is(err, errAccountMismatch), errors.Is(err, errStaffRequired), errors.Is(err, errAccountDenied):
+		errcode.Set(presented, "FORBIDDEN")
+	}
+
+	var serviceErr *common.ServiceError
+	if errors.As(err, &serviceErr) {
+		code := grpcCodeName(status.Code(serviceErr.Err))
+		if presented.Extensions == nil {
+			presented.Extensions = map[string]interface{}{}
+		}
+		presented.Extensions["service"] = serviceErr.Service
+		presented.Extensions["grpcCode"] = code
+		if _, ok := presented.Extensions["code"]; !ok {
+			errcode.Set(presented, code)
+		}
+	}
+	return presented
+}
+
+// grpcCodeName spells code the way the gRPC specification does, such as
+// DEADLINE_EXCEEDED for codes.DeadlineExceeded.
+func grpcCodeName(code codes.Code) string {
+	var name strings.Builder
+	var previous rune
+	for _, r := range code.String() {
+		if unicode.IsUpper(r) && unicode.IsLower(previous) {
+			name.WriteByte('_')
+		}
+		name.WriteRune(unicode.ToUpper(r))
+		previous = r
+	}
+	return name.String()
+}
@@ -0,0 +1,112 @@
+package exchange
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"math/big"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"graphql-grpc-go-microservice-project/common"
+)
+
+// RateCache keeps the latest rates from a RateProvider in memory so price
+// conversion never waits on the provider during a request.
+type RateCache struct {
+	provider RateProvider
+
+	mu    sync.RWMutex
+	rates *Rates
+}
+
+func NewRateCache(ctx context.Context, provider RateProvider) (*RateCache, error) {
+	cache := &RateCache{provider: provider}
+	if err := cache.Refresh(ctx); err != nil {
+		return nil, err
+	}
+	return cache, nil
+}
+
+// Refresh replaces the cached rates with the provider's current ones. The
+// previous rates stay in use if the provider fails.
+func (c *RateCache) Refresh(ctx context.Context) error {
+	rates, err := c.provider.Rates(ctx)
+	if err != nil {
+		return err
+	}
+
+	c.mu.Lock()
+	c.rates = rates
+	c.mu.Unlock()
+	return nil
+}
+
+// Run refreshes the rates every interval until ctx is cancelled. A
+// non-positive interval keeps the rates loaded at start.
+func (c *RateCache) Run(ctx context.Context, interval time.Duration) {
+	if interval <= 0 {
+		return
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := c.Refresh(ctx); err != nil {
+				log.Printf("Failed to refresh exchange rates: %v", err)
+			}
+		}
+	}
+}
+
+// Convert expresses money in another currency, rounding to the nearest minor
+// unit of the target currency with halves rounded away from zero.
+func (c *RateCache) Convert(money common.Money, currencyCode string) (common.Money, error) {
+	currencyCode = strings.ToUpper(strings.TrimSpace(currencyCode))
+	if money.CurrencyCode == currencyCode {
+		return money, nil
+	}
+
+	fromExponent, ok := common.CurrencyExponent(money.CurrencyCode)
+	if !ok {
+		return common.Money{}, fmt.Errorf("unsupported currency %q", money.CurrencyCode)
+	}
+	toExponent, ok := common.CurrencyExponent(currencyCode)
+	if !ok {
+		return common.Money{}, fmt.Errorf("unsupported currency %q", currencyCode)
+	}
+
+	c.mu.RLock()
+	fromRate, fromOK := c.rates.Rates[money.CurrencyCode]
+	toRate, toOK := c.rates.Rates[currencyCode]
+	c.mu.RUnlock()
+	if !fromOK {
+		return common.Money{}, fmt.Errorf("no exchange rate for %s", money.CurrencyCode)
+	}
+	if !toOK {
+		return common.Money{}, fmt.Errorf("no exchange rate for %s", currencyCode)
+	}
+
+	amount := new(big.Rat).SetInt64(money.Amount)
+	amount.Mul(amount, toRate)
+	amount.Quo(amount, fromRate)
+	amount.Mul(amount, new(big.Rat).SetInt(pow10(toExponent)))
+	amount.Quo(amount, new(big.Rat).SetInt(pow10(fromExponent)))
+
+	converted, err := strconv.ParseInt(amount.FloatString(0), 10, 64)
+	if err != nil {
+		return common.Money{}, fmt.Errorf("converted amount out of range: %w", err)
+	}
+	return common.Money{Amount: converted, CurrencyCode: currencyCode}, nil
+}
+
+func pow10(exponent int) *big.Int {
+	return new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(exponent)), nil)
+}
@@ -0,0 +1,90 @@
+package exchange
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"os"
+	"strings"
+	"time"
+)
+
+// RateProvider supplies exchange rates relative to a base currency.
+type RateProvider interface {
+	Rates(ctx context.Context) (*Rates, error)
+}
+
+// Rates holds how many units of each currency one unit of Base buys.
+type Rates struct {
+	Base      string
+	Rates     map[string]*big.Rat
+	FetchedAt time.Time
+}
+
+func parseRates(base string, rates map[string]string) (*Rates, error) {
+	base = strings.ToUpper(strings.TrimSpace(base))
+	if base == "" {
+		return nil, fmt.Errorf("exchange rate base currency is required")
+	}
+
+	parsed := &Rates{
+		Base:      base,
+		Rates:     map[string]*big.Rat{base: big.NewRat(1, 1)},
+		FetchedAt: time.Now(),
+	}
+	for code, value := range rates {
+		rate, ok := new(big.Rat).SetString(strings.TrimSpace(value))
+		if !ok || rate.Sign() <= 0 {
+			return nil, fmt.Errorf("invalid exchange rate %q for %s", value, code)
+		}
+		parsed.Rates[strings.ToUpper(strings.TrimSpace(code))] = rate
+	}
+	return parsed, nil
+}
+
+// StaticProvider serves a fixed rate table, e.g. from configuration, which
+// keeps price conversion working without any network access.
+type StaticProvider struct {
+	rates *Rates
+}
+
+func NewStaticProvider(base string, rates map[string]string) (*StaticProvider, error) {
+	parsed, err := parseRates(base, rates)
+	if err != nil {
+		return nil, err
+	}
+	return &StaticProvider{rates: parsed}, nil
+}
+
+func (p *StaticProvider) Rates(ctx context.Context) (*Rates, error) {
+	return p.rates, nil
+}
+
+// FileProvider reads rates from a JSON file on every call, so edits to the
+// file are picked up on the next refresh. The file looks like
+// {"base": "USD", "rates": {"EUR": "0.92", "INR": "83.12"}}.
+type FileProvider struct {
+	path string
+}
+
+func NewFileProvider(path string) *FileProvider {
+	return &FileProvider{path: path}
+}
+
+func (p *FileProvider) Rates(ctx context.Context) (*Rates, error) {
+	data, err := os.ReadFile(p.path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read exchange rates file: %w", err)
+	}
+
+	var file struct {
+		Base  string            `json:"base"`
+		Rates map[string]string `json:"rates"`
+	}
+	if err := json.Unmarshal(data, &file); err != nil {
+		return nil, fmt.Errorf("failed to parse exchange rates file: %w", err)
+	}
+
+	return parseRates(file.Base, file.Rates)
+}
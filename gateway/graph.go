@@ -1,37 +1,91 @@
 package main
 
 import (
+	"errors"
+	"time"
+
 	"graphql-grpc-go-microservice-project/account"
+	"graphql-grpc-go-microservice-project/cart"
+	"graphql-grpc-go-microservice-project/common"
+	"graphql-grpc-go-microservice-project/gateway/cache"
+	"graphql-grpc-go-microservice-project/gateway/exchange"
 	gatewayGraphQL "graphql-grpc-go-microservice-project/gateway/graphql"
+	"graphql-grpc-go-microservice-project/inventory"
+	"graphql-grpc-go-microservice-project/order"
 	"graphql-grpc-go-microservice-project/product"
 
 	"github.com/99designs/gqlgen/graphql"
 )
 
 type GatewayServer struct {
-	AccountClient *account.AccountClient
-	ProductClient *product.ProductClient
+	AccountClient   *account.AccountClient
+	ProductClient   *product.ProductClient
+	InventoryClient *inventory.InventoryClient
+	CartClient      *cart.CartClient
+	OrderClient     *order.OrderClient
+	ExchangeRates   *exchange.RateCache
+	Catalog         *catalogCache
 }
 
-func NewGraphQLServer(accountServiceURL string, productServiceURL string, secure bool) (*GatewayServer, error) {
-	accountClient, err := account.NewAccountClient(accountServiceURL, secure)
+func NewGraphQLServer(accountServiceURL string, productServiceURL string, inventoryServiceURL string, cartServiceURL string, orderServiceURL string, clientConfig common.ClientConfig, exchangeRates *exchange.RateCache, catalogStore cache.Store, catalogTTL, catalogStaleTTL time.Duration, secure bool) (*GatewayServer, error) {
+	accountClient, err := account.NewAccountClient(accountServiceURL, secure, clientConfig)
+	if err != nil {
+		accountClient.Close()
+		return nil, err
+	}
+
+	productClient, err := product.NewProductClient(productServiceURL, secure, clientConfig)
+	if err != nil {
+		accountClient.Close()
+		return nil, err
+	}
+
+	inventoryClient, err := inventory.NewInventoryClient(inventoryServiceURL, secure)
+	if err != nil {
+		accountClient.Close()
+		productClient.Close()
+		return nil, err
+	}
+
+	cartClient, err := cart.NewCartClient(cartServiceURL, secure)
 	if err != nil {
 		accountClient.Close()
+		productClient.Close()
+		inventoryClient.Close()
 		return nil, err
 	}
 
-	productClient, err := product.NewProductClient(productServiceURL, secure)
+	orderClient, err := order.NewOrderClient(orderServiceURL, secure)
 	if err != nil {
 		accountClient.Close()
+		productClient.Close()
+		inventoryClient.Close()
+		cartClient.Close()
 		return nil, err
 	}
 
 	return &GatewayServer{
-		AccountClient: accountClient,
-		ProductClient: productClient,
+		AccountClient:   accountClient,
+		ProductClient:   productClient,
+		InventoryClient: inventoryClient,
+		CartClient:      cartClient,
+		OrderClient:     orderClient,
+		ExchangeRates:   exchangeRates,
+		Catalog:         newCatalogCache(productClient, catalogStore, catalogTTL, catalogStaleTTL),
 	}, nil
 }
 
+// Close closes the connections to every service.
+func (s *GatewayServer) Close() error {
+	return errors.Join(
+		s.AccountClient.Close(),
+		s.ProductClient.Close(),
+		s.InventoryClient.Close(),
+		s.CartClient.Close(),
+		s.OrderClient.Close(),
+	)
+}
+
 func (s *GatewayServer) Mutation() gatewayGraphQL.MutationResolver {
 	return &mutationResolver{
 		server: s,
@@ -44,14 +98,34 @@ func (s *GatewayServer) Query() gatewayGraphQL.QueryResolver {
 	}
 }
 
+func (s *GatewayServer) Subscription() gatewayGraphQL.SubscriptionResolver {
+	return &subscriptionResolver{
+		server: s,
+	}
+}
+
 func (s *GatewayServer) Account() gatewayGraphQL.AccountResolver {
 	return &accountResolver{
 		server: s,
 	}
 }
 
+func (s *GatewayServer) Product() gatewayGraphQL.ProductResolver {
+	return &productResolver{
+		server: s,
+	}
+}
+
+func (s *GatewayServer) ProductVariant() gatewayGraphQL.ProductVariantResolver {
+	return &productVariantResolver{
+		server: s,
+	}
+}
+
 func (s *GatewayServer) ToExecutableSchema() graphql.ExecutableSchema {
 	return gatewayGraphQL.NewExecutableSchema(gatewayGraphQL.Config{
-		Resolvers: s,
+		Resolvers:  s,
+		Directives: gatewayGraphQL.DirectiveRoot{CacheControl: cacheControlDirective},
+		Complexity: newComplexityRoot(),
 	})
 }
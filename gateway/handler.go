@@ -0,0 +1,53 @@
+package main
+
+import (
+	"time"
+
+	"github.com/99designs/gqlgen/graphql"
+	"github.com/99designs/gqlgen/graphql/handler"
+	"github.com/99designs/gqlgen/graphql/handler/extension"
+	"github.com/99designs/gqlgen/graphql/handler/lru"
+	"github.com/99designs/gqlgen/graphql/handler/transport"
+	"github.com/vektah/gqlparser/v2/ast"
+)
+
+// queryLimits bounds the cost and shape of the operations the gateway
+// executes. A zero limit is not enforced.
+type queryLimits struct {
+	Complexity int
+	Depth      int
+	Aliases    int
+}
+
+// newGraphQLHandler serves queries and mutations over HTTP and subscriptions
+// over WebSocket. The keep-alive pings stop proxies from closing
+// subscriptions that go quiet between events. Operations over the limits are
+// rejected before any resolver runs. persistedQueries is either the APQ
+// extension or an allow-list of persisted queries.
+func newGraphQLHandler(schema graphql.ExecutableSchema, limits queryLimits, persistedQueries graphql.HandlerExtension, rateLimits operationRateLimit) *handler.Server {
+	srv := handler.New(schema)
+
+	srv.AddTransport(transport.Websocket{
+		KeepAlivePingInterval: 10 * time.Second,
+		InitTimeout:           15 * time.Second,
+	})
+	srv.AddTransport(transport.Options{})
+	srv.AddTransport(transport.GET{})
+	srv.AddTransport(transport.POST{})
+	srv.AddTransport(transport.MultipartForm{})
+
+	srv.SetQueryCache(lru.New[*ast.QueryDocument](1000))
+
+	srv.Use(extension.Introspection{})
+	srv.Use(queryShapeLimit{MaxDepth: limits.Depth, MaxAliases: limits.Aliases})
+	if limits.Complexity > 0 {
+		srv.Use(extension.FixedComplexityLimit(limits.Complexity))
+	}
+	srv.Use(persistedQueries)
+	srv.Use(rateLimits)
+	srv.Use(cacheControl{})
+
+	srv.SetErrorPresenter(presentError)
+
+	return srv
+}
@@ -0,0 +1,140 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"net/http"
+
+	"graphql-grpc-go-microservice-project/account"
+	"graphql-grpc-go-microservice-project/cart"
+
+	"github.com/google/uuid"
+)
+
+var (
+	errSignInRequired  = errors.New("sign in required")
+	errAccountMismatch = errors.New("accountId must be the signed-in account")
+	errStaffRequired   = errors.New("only staff and admins can do this")
+	errAccountDenied   = errors.New("not allowed for this account")
+)
+
+type signedInAccountKey struct{}
+
+// withAccountIdentity forwards the ID of the signed-in account, set in header
+// by the authenticating proxy in front of the gateway, to the account service
+// as the actor of the request. The proxy must strip the header from client
+// requests, since the gateway trusts it as is.
+func withAccountIdentity(header string, next http.Handler) http.Handler {
+	if header == "" {
+		return next
+	}
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if id, ok := accountIDFromRequest(r, header); ok {
+			ctx := context.WithValue(r.Context(), signedInAccountKey{}, id)
+			r = r.WithContext(account.ContextWithActor(ctx, id))
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+func accountIDFromRequest(r *http.Request, header string) (string, bool) {
+	if header == "" {
+		return "", false
+	}
+
+	id, err := uuid.Parse(r.Header.Get(header))
+	if err != nil {
+		return "", false
+	}
+	return id.String(), true
+}
+
+// signedInAccount returns the ID of the account the request was authenticated
+// as by withAccountIdentity.
+func signedInAccount(ctx context.Context) (string, bool) {
+	id, ok := ctx.Value(signedInAccountKey{}).(string)
+	return id, ok && id != ""
+}
+
+// ownAccount returns the signed-in account for operations on an account's own
+// data. accountID may be omitted, and must name that account otherwise.
+func ownAccount(ctx context.Context, accountID *string) (string, error) {
+	id, ok := signedInAccount(ctx)
+	if !ok {
+		return "", errSignInRequired
+	}
+
+	if accountID != nil {
+		requested, err := uuid.Parse(*accountID)
+		if err != nil || requested.String() != id {
+			return "", errAccountMismatch
+		}
+	}
+	return id, nil
+}
+
+// ownAccountOr allows operations on accountID to the account itself and to
+// signed-in accounts with one of roles.
+func ownAccountOr(ctx context.Context, accounts *account.AccountClient, accountID string, roles ...account.Role) error {
+	if _, err := ownAccount(ctx, &accountID); !errors.Is(err, errAccountMismatch) {
+		return err
+	}
+	return requireRole(ctx, accounts, errAccountDenied, roles...)
+}
+
+// ownAccountOrStaff allows operations on accountID to the account itself, staff
+// and admins.
+func ownAccountOrStaff(ctx context.Context, accounts *account.AccountClient, accountID string) error {
+	return ownAccountOr(ctx, accounts, accountID, account.RoleStaff, account.RoleAdmin)
+}
+
+// requireStaff checks that the signed-in account has the staff or admin role.
+func requireStaff(ctx context.Context, accounts *account.AccountClient) error {
+	return requireRole(ctx, accounts, errStaffRequired, account.RoleStaff, account.RoleAdmin)
+}
+
+// requireRole returns denied unless the signed-in account has one of roles.
+func requireRole(ctx context.Context, accounts *account.AccountClient, denied error, roles ...account.Role) error {
+	id, ok := signedInAccount(ctx)
+	if !ok {
+		return errSignInRequired
+	}
+
+	a, err := accounts.GetAccountByID(ctx, id)
+	if err != nil {
+		return err
+	}
+	for _, role := range roles {
+		if a.Role == role {
+			return nil
+		}
+	}
+	return denied
+}
+
+// authorizeCartOwner checks the owner of a cart operation against the
+// signed-in account. A signed-in request always works on the account's cart
+// and moves its anonymous cart over with mergeCarts rather than addressing it
+// by ID. An anonymous request can only address anonymous carts.
+func authorizeCartOwner(ctx context.Context, owner cart.CartOwner) (cart.CartOwner, error) {
+	if _, ok := signedInAccount(ctx); !ok {
+		if owner.AccountID != "" {
+			return cart.CartOwner{}, errSignInRequired
+		}
+		return owner, nil
+	}
+
+	var accountID *string
+	if owner.AccountID != "" {
+		accountID = &owner.AccountID
+	}
+	id, err := ownAccount(ctx, accountID)
+	if err != nil {
+		return cart.CartOwner{}, err
+	}
+	if owner.CartID != "" {
+		return cart.CartOwner{}, errors.New("cartId only addresses anonymous carts, use mergeCarts to move one into the account's cart")
+	}
+	return cart.CartOwner{AccountID: id}, nil
+}
@@ -0,0 +1,101 @@
+package main
+
+import (
+	"context"
+	"math"
+	"strings"
+
+	"github.com/99designs/gqlgen/graphql"
+	"github.com/99designs/gqlgen/graphql/errcode"
+	"github.com/vektah/gqlparser/v2/ast"
+	"github.com/vektah/gqlparser/v2/gqlerror"
+)
+
+// queryShapeLimit rejects operations that nest fields deeper than MaxDepth or
+// use more than MaxAliases aliases before they are executed. Introspection
+// fields are not counted. A zero limit is not enforced.
+type queryShapeLimit struct {
+	MaxDepth   int
+	MaxAliases int
+}
+
+var _ interface {
+	graphql.OperationContextMutator
+	graphql.HandlerExtension
+} = queryShapeLimit{}
+
+func (l queryShapeLimit) ExtensionName() string {
+	return "QueryShapeLimit"
+}
+
+func (l queryShapeLimit) Validate(graphql.ExecutableSchema) error {
+	return nil
+}
+
+func (l queryShapeLimit) MutateOperationContext(_ context.Context, rc *graphql.OperationContext) *gqlerror.Error {
+	if rc.Operation == nil {
+		return nil
+	}
+
+	shape := (&shapeWalker{fragments: map[string]selectionShape{}}).walk(rc.Operation.SelectionSet)
+
+	if l.MaxDepth > 0 && shape.depth > l.MaxDepth {
+		err := gqlerror.Errorf("operation has depth %d, which exceeds the limit of %d", shape.depth, l.MaxDepth)
+		errcode.Set(err, "DEPTH_LIMIT_EXCEEDED")
+		return err
+	}
+	if l.MaxAliases > 0 && shape.aliases > l.MaxAliases {
+		err := gqlerror.Errorf("operation has %d aliases, which exceeds the limit of %d", shape.aliases, l.MaxAliases)
+		errcode.Set(err, "ALIAS_LIMIT_EXCEEDED")
+		return err
+	}
+	return nil
+}
+
+type selectionShape struct {
+	depth   int
+	aliases int
+}
+
+// shapeWalker measures selection sets, remembering each fragment so that
+// fragments spread many times are only walked once.
+type shapeWalker struct {
+	fragments map[string]selectionShape
+}
+
+func (w *shapeWalker) walk(set ast.SelectionSet) selectionShape {
+	var shape selectionShape
+	for _, selection := range set {
+		var child selectionShape
+		switch selection := selection.(type) {
+		case *ast.Field:
+			if strings.HasPrefix(selection.Name, "__") {
+				continue
+			}
+			child = w.walk(selection.SelectionSet)
+			child.depth++
+			if selection.Alias != selection.Name {
+				child.aliases = saturatingAdd(child.aliases, 1)
+			}
+		case *ast.InlineFragment:
+			child = w.walk(selection.SelectionSet)
+		case *ast.FragmentSpread:
+			var ok bool
+			if child, ok = w.fragments[selection.Name]; !ok && selection.Definition != nil {
+				child = w.walk(selection.Definition.SelectionSet)
+				w.fragments[selection.Name] = child
+			}
+		}
+
+		shape.depth = max(shape.depth, child.depth)
+		shape.aliases = saturatingAdd(shape.aliases, child.aliases)
+	}
+	return shape
+}
+
+func saturatingAdd(a, b int) int {
+	if a > math.MaxInt-b {
+		return math.MaxInt
+	}
+	return a + b
+}
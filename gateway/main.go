@@ -1,19 +1,95 @@
 package main
 
 import (
+	"context"
+	"fmt"
 	"log"
 	"net/http"
 	"time"
 
-	"github.com/99designs/gqlgen/graphql/handler"
+	"graphql-grpc-go-microservice-project/common"
+	"graphql-grpc-go-microservice-project/gateway/cache"
+	"graphql-grpc-go-microservice-project/gateway/exchange"
+	"graphql-grpc-go-microservice-project/gateway/persisted"
+
+	"github.com/99designs/gqlgen/graphql"
+	"github.com/99designs/gqlgen/graphql/handler/extension"
+	"github.com/99designs/gqlgen/graphql/handler/lru"
 	"github.com/99designs/gqlgen/graphql/playground"
 	"github.com/kelseyhightower/envconfig"
+	"github.com/redis/go-redis/v9"
 )
 
 type AppConfig struct {
-	ACCOUNT_SERVICE_URL string `envconfig:"ACCOUNT_SERVICE_URL" required:"true"`
-	PRODUCT_SERVICE_URL string `envconfig:"PRODUCT_SERVICE_URL" required:"true"`
-	PORT                string `envconfig:"PORT" default:"8080"`
+	ACCOUNT_SERVICE_URL   string `envconfig:"ACCOUNT_SERVICE_URL" required:"true"`
+	PRODUCT_SERVICE_URL   string `envconfig:"PRODUCT_SERVICE_URL" required:"true"`
+	INVENTORY_SERVICE_URL string `envconfig:"INVENTORY_SERVICE_URL" required:"true"`
+	CART_SERVICE_URL      string `envconfig:"CART_SERVICE_URL" required:"true"`
+	ORDER_SERVICE_URL     string `envconfig:"ORDER_SERVICE_URL" required:"true"`
+	PORT                  string `envconfig:"PORT" default:"8080"`
+
+	// The account and product service URLs may also be dns:///host:port,
+	// static:///host1:port,host2:port or discovery:///name, and calls are
+	// spread over every instance they name. SERVICE_DISCOVERY is "none",
+	// "file" or "consul" and finds the instances of discovery:/// URLs.
+	SERVICE_DISCOVERY            string        `envconfig:"SERVICE_DISCOVERY" default:"none"`
+	SERVICE_DISCOVERY_FILE       string        `envconfig:"SERVICE_DISCOVERY_FILE"`
+	SERVICE_DISCOVERY_CONSUL_URL string        `envconfig:"SERVICE_DISCOVERY_CONSUL_URL" default:"http://localhost:8500"`
+	SERVICE_DISCOVERY_INTERVAL   time.Duration `envconfig:"SERVICE_DISCOVERY_INTERVAL" default:"10s"`
+
+	// TRUSTED_ACCOUNT_HEADER names the header in which an authenticating
+	// proxy passes the signed-in account ID. Requests are anonymous when unset.
+	TRUSTED_ACCOUNT_HEADER string `envconfig:"TRUSTED_ACCOUNT_HEADER"`
+
+	// RATE_LIMIT and RATE_LIMIT_BURST bound the requests per second of each
+	// client. GRAPHQL_OPERATION_RATE_LIMITS adds "rate/burst" limits for
+	// single root fields.
+	RATE_LIMIT                    float64           `envconfig:"RATE_LIMIT" default:"20"`
+	RATE_LIMIT_BURST              int               `envconfig:"RATE_LIMIT_BURST" default:"40"`
+	RATE_LIMIT_CLIENT_IP_HEADER   string            `envconfig:"RATE_LIMIT_CLIENT_IP_HEADER"`
+	RATE_LIMIT_PROXY_HOPS         int               `envconfig:"RATE_LIMIT_PROXY_HOPS" default:"1"`
+	GRAPHQL_OPERATION_RATE_LIMITS map[string]string `envconfig:"GRAPHQL_OPERATION_RATE_LIMITS" default:"searchProducts:2/10,createAccount:0.1/3,sendVerificationEmail:0.05/3,requestPasswordReset:0.05/3"`
+
+	GRAPHQL_COMPLEXITY_LIMIT int `envconfig:"GRAPHQL_COMPLEXITY_LIMIT" default:"5000"`
+	GRAPHQL_DEPTH_LIMIT      int `envconfig:"GRAPHQL_DEPTH_LIMIT" default:"10"`
+	GRAPHQL_ALIAS_LIMIT      int `envconfig:"GRAPHQL_ALIAS_LIMIT" default:"20"`
+
+	// PERSISTED_QUERIES_MODE is "apq" to let clients register queries by
+	// hash, or "allowlist" to only run the queries in PERSISTED_QUERIES_FILE.
+	PERSISTED_QUERIES_MODE       string        `envconfig:"PERSISTED_QUERIES_MODE" default:"apq"`
+	PERSISTED_QUERIES_FILE       string        `envconfig:"PERSISTED_QUERIES_FILE"`
+	PERSISTED_QUERIES_CACHE      string        `envconfig:"PERSISTED_QUERIES_CACHE" default:"memory"`
+	PERSISTED_QUERIES_CACHE_SIZE int           `envconfig:"PERSISTED_QUERIES_CACHE_SIZE" default:"1000"`
+	PERSISTED_QUERIES_REDIS_URL  string        `envconfig:"PERSISTED_QUERIES_REDIS_URL" default:"redis://localhost:6379/0"`
+	PERSISTED_QUERIES_REDIS_TTL  time.Duration `envconfig:"PERSISTED_QUERIES_REDIS_TTL" default:"168h"`
+
+	// CATALOG_CACHE is "memory", "redis" or "none" and caches product reads
+	// for CATALOG_CACHE_TTL. Expired reads are kept for another
+	// CATALOG_CACHE_STALE_TTL and served while the product service is down.
+	CATALOG_CACHE           string        `envconfig:"CATALOG_CACHE" default:"memory"`
+	CATALOG_CACHE_SIZE      int           `envconfig:"CATALOG_CACHE_SIZE" default:"10000"`
+	CATALOG_CACHE_TTL       time.Duration `envconfig:"CATALOG_CACHE_TTL" default:"30s"`
+	CATALOG_CACHE_STALE_TTL time.Duration `envconfig:"CATALOG_CACHE_STALE_TTL" default:"10m"`
+	CATALOG_CACHE_REDIS_URL string        `envconfig:"CATALOG_CACHE_REDIS_URL" default:"redis://localhost:6379/0"`
+
+	// REQUEST_TIMEOUT is the deadline of a GraphQL request, which calls to the
+	// services inherit. GRPC_CLIENT_TIMEOUT only applies to calls without one.
+	REQUEST_TIMEOUT             time.Duration `envconfig:"REQUEST_TIMEOUT" default:"8s"`
+	GRPC_CLIENT_TIMEOUT         time.Duration `envconfig:"GRPC_CLIENT_TIMEOUT" default:"5s"`
+	GRPC_CLIENT_MAX_ATTEMPTS    int           `envconfig:"GRPC_CLIENT_MAX_ATTEMPTS" default:"3"`
+	GRPC_CLIENT_INITIAL_BACKOFF time.Duration `envconfig:"GRPC_CLIENT_INITIAL_BACKOFF" default:"100ms"`
+	GRPC_CLIENT_MAX_BACKOFF     time.Duration `envconfig:"GRPC_CLIENT_MAX_BACKOFF" default:"1s"`
+	CIRCUIT_BREAKER_THRESHOLD   int           `envconfig:"CIRCUIT_BREAKER_THRESHOLD" default:"5"`
+	CIRCUIT_BREAKER_COOLDOWN    time.Duration `envconfig:"CIRCUIT_BREAKER_COOLDOWN" default:"10s"`
+
+	// SHUTDOWN_TIMEOUT bounds how long requests in flight may take to finish
+	// once the gateway is asked to stop.
+	SHUTDOWN_TIMEOUT time.Duration `envconfig:"SHUTDOWN_TIMEOUT" default:"20s"`
+
+	EXCHANGE_RATES_BASE             string            `envconfig:"EXCHANGE_RATES_BASE" default:"USD"`
+	EXCHANGE_RATES                  map[string]string `envconfig:"EXCHANGE_RATES"`
+	EXCHANGE_RATES_FILE             string            `envconfig:"EXCHANGE_RATES_FILE"`
+	EXCHANGE_RATES_REFRESH_INTERVAL time.Duration     `envconfig:"EXCHANGE_RATES_REFRESH_INTERVAL" default:"1h"`
 }
 
 func main() {
@@ -23,13 +99,112 @@ func main() {
 		log.Fatalf("Failed to load environment variables: %v", err)
 	}
 
-	server, err := NewGraphQLServer(cfg.ACCOUNT_SERVICE_URL, cfg.PRODUCT_SERVICE_URL, false)
+	lifecycle := common.NewLifecycle(cfg.SHUTDOWN_TIMEOUT)
+
+	var rateProvider exchange.RateProvider
+	if cfg.EXCHANGE_RATES_FILE != "" {
+		rateProvider = exchange.NewFileProvider(cfg.EXCHANGE_RATES_FILE)
+	} else {
+		rateProvider, err = exchange.NewStaticProvider(cfg.EXCHANGE_RATES_BASE, cfg.EXCHANGE_RATES)
+		if err != nil {
+			log.Fatalf("Failed to load exchange rates: %v", err)
+		}
+	}
+
+	exchangeRates, err := exchange.NewRateCache(context.Background(), rateProvider)
+	if err != nil {
+		log.Fatalf("Failed to load exchange rates: %v", err)
+	}
+	lifecycle.Go("exchange rates", func(ctx context.Context) {
+		exchangeRates.Run(ctx, cfg.EXCHANGE_RATES_REFRESH_INTERVAL)
+	})
+
+	var catalogStore cache.Store
+	switch cfg.CATALOG_CACHE {
+	case "none":
+	case "memory":
+		catalogStore = cache.NewMemoryStore(cfg.CATALOG_CACHE_SIZE)
+	case "redis":
+		client, err := newRedisClient(cfg.CATALOG_CACHE_REDIS_URL)
+		if err != nil {
+			log.Fatalf("Failed to connect to catalog cache: %v", err)
+		}
+		lifecycle.OnClose("catalog cache", client)
+		catalogStore = cache.NewRedisStore(client, "catalog:")
+	default:
+		log.Fatalf("Unknown catalog cache %q", cfg.CATALOG_CACHE)
+	}
+
+	clientConfig := common.ClientConfig{
+		Timeout:          cfg.GRPC_CLIENT_TIMEOUT,
+		MaxAttempts:      cfg.GRPC_CLIENT_MAX_ATTEMPTS,
+		InitialBackoff:   cfg.GRPC_CLIENT_INITIAL_BACKOFF,
+		MaxBackoff:       cfg.GRPC_CLIENT_MAX_BACKOFF,
+		BreakerThreshold: cfg.CIRCUIT_BREAKER_THRESHOLD,
+		BreakerCooldown:  cfg.CIRCUIT_BREAKER_COOLDOWN,
+
+		DiscoveryInterval: cfg.SERVICE_DISCOVERY_INTERVAL,
+	}
+	if cfg.SERVICE_DISCOVERY != "none" {
+		clientConfig.Discovery, err = common.NewDiscovery(cfg.SERVICE_DISCOVERY, cfg.SERVICE_DISCOVERY_FILE, cfg.SERVICE_DISCOVERY_CONSUL_URL)
+		if err != nil {
+			log.Fatalf("Failed to set up service discovery: %v", err)
+		}
+	}
+
+	server, err := NewGraphQLServer(cfg.ACCOUNT_SERVICE_URL, cfg.PRODUCT_SERVICE_URL, cfg.INVENTORY_SERVICE_URL, cfg.CART_SERVICE_URL, cfg.ORDER_SERVICE_URL, clientConfig, exchangeRates, catalogStore, cfg.CATALOG_CACHE_TTL, cfg.CATALOG_CACHE_STALE_TTL, false)
 	if err != nil {
 		log.Fatalf("Failed to create GraphQL server: %v", err)
 	}
+	lifecycle.OnClose("service clients", server)
+	if catalogStore != nil {
+		lifecycle.Go("catalog invalidation", server.Catalog.Watch)
+	}
+
+	var persistedQueries graphql.HandlerExtension
+	switch cfg.PERSISTED_QUERIES_MODE {
+	case "apq":
+		var queryCache graphql.Cache[string]
+		switch cfg.PERSISTED_QUERIES_CACHE {
+		case "memory":
+			queryCache = lru.New[string](cfg.PERSISTED_QUERIES_CACHE_SIZE)
+		case "redis":
+			client, err := newRedisClient(cfg.PERSISTED_QUERIES_REDIS_URL)
+			if err != nil {
+				log.Fatalf("Failed to connect to persisted query cache: %v", err)
+			}
+			lifecycle.OnClose("persisted query cache", client)
+			queryCache = persisted.NewRedisCache(client, cfg.PERSISTED_QUERIES_REDIS_TTL)
+		default:
+			log.Fatalf("Unknown persisted query cache %q", cfg.PERSISTED_QUERIES_CACHE)
+		}
+		persistedQueries = extension.AutomaticPersistedQuery{Cache: queryCache}
+	case "allowlist":
+		allowList, err := persisted.LoadAllowList(cfg.PERSISTED_QUERIES_FILE)
+		if err != nil {
+			log.Fatalf("Failed to load persisted queries: %v", err)
+		}
+		log.Printf("Only executing the %d persisted queries in %s", allowList.Len(), cfg.PERSISTED_QUERIES_FILE)
+		persistedQueries = allowList
+	default:
+		log.Fatalf("Unknown persisted query mode %q", cfg.PERSISTED_QUERIES_MODE)
+	}
+
+	operationRateLimits, err := newOperationRateLimit(cfg.GRAPHQL_OPERATION_RATE_LIMITS)
+	if err != nil {
+		log.Fatalf("Failed to load rate limits: %v", err)
+	}
+
+	graphQLHandler := newGraphQLHandler(server.ToExecutableSchema(), queryLimits{
+		Complexity: cfg.GRAPHQL_COMPLEXITY_LIMIT,
+		Depth:      cfg.GRAPHQL_DEPTH_LIMIT,
+		Aliases:    cfg.GRAPHQL_ALIAS_LIMIT,
+	}, persistedQueries, operationRateLimits)
+	rateLimiter := common.NewRateLimiter(cfg.RATE_LIMIT, cfg.RATE_LIMIT_BURST)
 
 	mux := http.NewServeMux()
-	mux.Handle("/graphql", handler.NewDefaultServer(server.ToExecutableSchema()))
+	mux.Handle("/graphql", withAccountIdentity(cfg.TRUSTED_ACCOUNT_HEADER,
+		withRateLimit(rateLimiter, cfg.TRUSTED_ACCOUNT_HEADER, cfg.RATE_LIMIT_CLIENT_IP_HEADER, cfg.RATE_LIMIT_PROXY_HOPS, withRequestTimeout(cfg.REQUEST_TIMEOUT, withCacheControl(graphQLHandler)))))
 	mux.Handle("/playground", playground.Handler("GraphQL Playground", "/graphql"))
 
 	srv := &http.Server{
@@ -41,7 +216,33 @@ func main() {
 	}
 
 	log.Printf("Starting server on port %s", cfg.PORT)
-	if err := srv.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+	lifecycle.ServeHTTP("HTTP server", srv)
+	if err := lifecycle.Wait(); err != nil {
 		log.Fatalf("Server failed: %v", err)
 	}
 }
+
+// redisTimeout bounds cache commands, which should fail fast and count as
+// misses rather than hold up requests.
+const redisTimeout = time.Second
+
+// newRedisClient connects to a server given as
+// redis://[[user]:password@]host[:port][/db].
+func newRedisClient(url string) (*redis.Client, error) {
+	options, err := redis.ParseURL(url)
+	if err != nil {
+		return nil, fmt.Errorf("invalid redis url %q: %w", url, err)
+	}
+	options.DialTimeout = redisTimeout
+	options.ReadTimeout = redisTimeout
+	options.WriteTimeout = redisTimeout
+
+	client := redis.NewClient(options)
+	ctx, cancel := context.WithTimeout(context.Background(), redisTimeout)
+	defer cancel()
+	if err := client.Ping(ctx).Err(); err != nil {
+		client.Close()
+		return nil, err
+	}
+	return client, nil
+}
@@ -5,16 +5,44 @@ import (
 )
 
 type Account struct {
-	ID        uuid.UUID `json:"id"`
-	Email     string    `json:"email"`
-	Name      string    `json:"name"`
-	CreatedAt string    `json:"created_at"`
-	UpdatedAt string    `json:"updated_at"`
+	ID              uuid.UUID   `json:"id"`
+	Email           string      `json:"email"`
+	Name            string      `json:"name"`
+	Role            AccountRole `json:"role"`
+	EmailVerified   bool        `json:"email_verified"`
+	EmailVerifiedAt *string     `json:"email_verified_at"`
+	CreatedAt       string      `json:"created_at"`
+	UpdatedAt       string      `json:"updated_at"`
+	DeletedAt       *string     `json:"deleted_at"`
+}
+
+type Money struct {
+	Amount       string `json:"amount"`
+	MinorUnits   int64  `json:"minor_units"`
+	CurrencyCode string `json:"currency_code"`
+}
+
+type Category struct {
+	ID       string  `json:"id"`
+	Name     string  `json:"name"`
+	ParentID *string `json:"parent_id"`
+	Path     string  `json:"path"`
 }
 
 type Product struct {
-	ID          string  `json:"id"`
-	Name        string  `json:"name"`
-	Description string  `json:"description"`
-	Price       float64 `json:"price"`
+	ID          string            `json:"id"`
+	Name        string            `json:"name"`
+	Description string            `json:"description"`
+	Price       *Money            `json:"price"`
+	Categories  []*Category       `json:"categories"`
+	Variants    []*ProductVariant `json:"variants"`
+	// AverageRating is nil when the product has no reviews.
+	AverageRating *float64 `json:"average_rating"`
+	ReviewCount   int      `json:"review_count"`
+}
+
+type ProductVariant struct {
+	SKU        string              `json:"sku"`
+	Price      *Money              `json:"price"`
+	Attributes []*ProductAttribute `json:"attributes"`
 }
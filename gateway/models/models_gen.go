@@ -2,24 +2,513 @@
 
 package models
 
+import (
+	"fmt"
+	"io"
+	"strconv"
+)
+
+type AccountAuditEntry struct {
+	ID        string  `json:"id"`
+	AccountID string  `json:"accountId"`
+	Action    string  `json:"action"`
+	Actor     string  `json:"actor"`
+	Before    *string `json:"before,omitempty"`
+	After     *string `json:"after,omitempty"`
+	CreatedAt string  `json:"createdAt"`
+}
+
+type AccountFilterInput struct {
+	Name          *string `json:"name,omitempty"`
+	Email         *string `json:"email,omitempty"`
+	CreatedAfter  *string `json:"createdAfter,omitempty"`
+	CreatedBefore *string `json:"createdBefore,omitempty"`
+}
+
 type AccountInput struct {
 	Email string `json:"email"`
 	Name  string `json:"name"`
 }
 
+type AccountList struct {
+	Accounts   []*Account `json:"accounts"`
+	TotalCount int        `json:"totalCount"`
+}
+
+type AccountOrderByInput struct {
+	Field     AccountOrderField `json:"field"`
+	Direction *SortDirection    `json:"direction,omitempty"`
+}
+
+type Address struct {
+	ID         string      `json:"id"`
+	Type       AddressType `json:"type"`
+	IsDefault  bool        `json:"isDefault"`
+	Name       string      `json:"name"`
+	Line1      string      `json:"line1"`
+	Line2      string      `json:"line2"`
+	City       string      `json:"city"`
+	Region     string      `json:"region"`
+	PostalCode string      `json:"postalCode"`
+	Country    string      `json:"country"`
+	CreatedAt  string      `json:"createdAt"`
+	UpdatedAt  string      `json:"updatedAt"`
+}
+
+type AddressInput struct {
+	Type       AddressType `json:"type"`
+	IsDefault  bool        `json:"isDefault"`
+	Name       string      `json:"name"`
+	Line1      string      `json:"line1"`
+	Line2      *string     `json:"line2,omitempty"`
+	City       string      `json:"city"`
+	Region     *string     `json:"region,omitempty"`
+	PostalCode *string     `json:"postalCode,omitempty"`
+	Country    string      `json:"country"`
+}
+
+type Cart struct {
+	ID        *string     `json:"id,omitempty"`
+	AccountID *string     `json:"accountId,omitempty"`
+	Items     []*CartItem `json:"items"`
+	Totals    []*Money    `json:"totals"`
+	UpdatedAt *string     `json:"updatedAt,omitempty"`
+}
+
+type CartItem struct {
+	ProductID string `json:"productId"`
+	Name      string `json:"name"`
+	Quantity  int    `json:"quantity"`
+	UnitPrice *Money `json:"unitPrice,omitempty"`
+	Subtotal  *Money `json:"subtotal,omitempty"`
+	Available bool   `json:"available"`
+	AddedAt   string `json:"addedAt"`
+}
+
+// Addresses a cart. Signed-in requests always use the account's cart, and
+// accountId may only name the signed-in account. Anonymous requests use the
+// anonymous cart with the given cartId.
+type CartOwnerInput struct {
+	AccountID *string `json:"accountId,omitempty"`
+	CartID    *string `json:"cartId,omitempty"`
+}
+
+type CategoryInput struct {
+	Name     string  `json:"name"`
+	ParentID *string `json:"parentId,omitempty"`
+}
+
+// Checkout always places the order for the signed-in account; accountId may be
+// omitted and must name that account otherwise.
+type CheckoutInput struct {
+	AccountID    *string              `json:"accountId,omitempty"`
+	PaymentToken string               `json:"paymentToken"`
+	Items        []*CheckoutItemInput `json:"items,omitempty"`
+}
+
+// sku names the variant to buy. It is required for products with variants and
+// must be omitted otherwise.
+type CheckoutItemInput struct {
+	ProductID string  `json:"productId"`
+	Sku       *string `json:"sku,omitempty"`
+	Quantity  int     `json:"quantity"`
+}
+
+type MoneyInput struct {
+	Amount       string `json:"amount"`
+	CurrencyCode string `json:"currencyCode"`
+}
+
 type Mutation struct {
 }
 
+type Order struct {
+	ID            string       `json:"id"`
+	AccountID     string       `json:"accountId"`
+	Status        OrderStatus  `json:"status"`
+	Items         []*OrderItem `json:"items"`
+	Total         *Money       `json:"total"`
+	FailureReason *string      `json:"failureReason,omitempty"`
+	CreatedAt     string       `json:"createdAt"`
+	UpdatedAt     string       `json:"updatedAt"`
+}
+
+type OrderItem struct {
+	ProductID string  `json:"productId"`
+	Sku       *string `json:"sku,omitempty"`
+	Name      string  `json:"name"`
+	Quantity  int     `json:"quantity"`
+	UnitPrice *Money  `json:"unitPrice"`
+}
+
 type PaginationInput struct {
 	Limit  int `json:"limit"`
 	Offset int `json:"offset"`
 }
 
+type ProductAttribute struct {
+	Key   string `json:"key"`
+	Value string `json:"value"`
+}
+
+type ProductAttributeInput struct {
+	Key   string `json:"key"`
+	Value string `json:"value"`
+}
+
 type ProductInput struct {
-	Name        string  `json:"name"`
-	Description string  `json:"description"`
-	Price       float64 `json:"price"`
+	Name        string                 `json:"name"`
+	Description string                 `json:"description"`
+	Price       *MoneyInput            `json:"price"`
+	Variants    []*ProductVariantInput `json:"variants,omitempty"`
+}
+
+type ProductVariantInput struct {
+	Sku        string                   `json:"sku"`
+	Price      *MoneyInput              `json:"price"`
+	Attributes []*ProductAttributeInput `json:"attributes,omitempty"`
 }
 
 type Query struct {
 }
+
+type Review struct {
+	ProductID string `json:"productId"`
+	AccountID string `json:"accountId"`
+	Rating    int    `json:"rating"`
+	Text      string `json:"text"`
+	CreatedAt string `json:"createdAt"`
+	UpdatedAt string `json:"updatedAt"`
+}
+
+// A review is always submitted as the signed-in account; accountId may be
+// omitted and must name that account otherwise.
+type ReviewInput struct {
+	ProductID string  `json:"productId"`
+	AccountID *string `json:"accountId,omitempty"`
+	Rating    int     `json:"rating"`
+	Text      *string `json:"text,omitempty"`
+}
+
+type Stock struct {
+	OnHand    int `json:"onHand"`
+	Reserved  int `json:"reserved"`
+	Available int `json:"available"`
+}
+
+type Subscription struct {
+}
+
+type Wishlist struct {
+	ID        string          `json:"id"`
+	Name      string          `json:"name"`
+	Items     []*WishlistItem `json:"items"`
+	CreatedAt string          `json:"createdAt"`
+	UpdatedAt string          `json:"updatedAt"`
+}
+
+type WishlistItem struct {
+	ProductID string   `json:"productId"`
+	AddedAt   string   `json:"addedAt"`
+	Product   *Product `json:"product,omitempty"`
+	Available *bool    `json:"available,omitempty"`
+}
+
+type AccountOrderField string
+
+const (
+	AccountOrderFieldCreatedAt AccountOrderField = "CREATED_AT"
+	AccountOrderFieldName      AccountOrderField = "NAME"
+	AccountOrderFieldEmail     AccountOrderField = "EMAIL"
+)
+
+var AllAccountOrderField = []AccountOrderField{
+	AccountOrderFieldCreatedAt,
+	AccountOrderFieldName,
+	AccountOrderFieldEmail,
+}
+
+func (e AccountOrderField) IsValid() bool {
+	switch e {
+	case AccountOrderFieldCreatedAt, AccountOrderFieldName, AccountOrderFieldEmail:
+		return true
+	}
+	return false
+}
+
+func (e AccountOrderField) String() string {
+	return string(e)
+}
+
+func (e *AccountOrderField) UnmarshalGQL(v interface{}) error {
+	str, ok := v.(string)
+	if !ok {
+		return fmt.Errorf("enums must be strings")
+	}
+
+	*e = AccountOrderField(str)
+	if !e.IsValid() {
+		return fmt.Errorf("%s is not a valid AccountOrderField", str)
+	}
+	return nil
+}
+
+func (e AccountOrderField) MarshalGQL(w io.Writer) {
+	fmt.Fprint(w, strconv.Quote(e.String()))
+}
+
+type AccountRole string
+
+const (
+	AccountRoleCustomer AccountRole = "CUSTOMER"
+	AccountRoleStaff    AccountRole = "STAFF"
+	AccountRoleAdmin    AccountRole = "ADMIN"
+)
+
+var AllAccountRole = []AccountRole{
+	AccountRoleCustomer,
+	AccountRoleStaff,
+	AccountRoleAdmin,
+}
+
+func (e AccountRole) IsValid() bool {
+	switch e {
+	case AccountRoleCustomer, AccountRoleStaff, AccountRoleAdmin:
+		return true
+	}
+	return false
+}
+
+func (e AccountRole) String() string {
+	return string(e)
+}
+
+func (e *AccountRole) UnmarshalGQL(v interface{}) error {
+	str, ok := v.(string)
+	if !ok {
+		return fmt.Errorf("enums must be strings")
+	}
+
+	*e = AccountRole(str)
+	if !e.IsValid() {
+		return fmt.Errorf("%s is not a valid AccountRole", str)
+	}
+	return nil
+}
+
+func (e AccountRole) MarshalGQL(w io.Writer) {
+	fmt.Fprint(w, strconv.Quote(e.String()))
+}
+
+type AddressType string
+
+const (
+	AddressTypeShipping AddressType = "SHIPPING"
+	AddressTypeBilling  AddressType = "BILLING"
+)
+
+var AllAddressType = []AddressType{
+	AddressTypeShipping,
+	AddressTypeBilling,
+}
+
+func (e AddressType) IsValid() bool {
+	switch e {
+	case AddressTypeShipping, AddressTypeBilling:
+		return true
+	}
+	return false
+}
+
+func (e AddressType) String() string {
+	return string(e)
+}
+
+func (e *AddressType) UnmarshalGQL(v interface{}) error {
+	str, ok := v.(string)
+	if !ok {
+		return fmt.Errorf("enums must be strings")
+	}
+
+	*e = AddressType(str)
+	if !e.IsValid() {
+		return fmt.Errorf("%s is not a valid AddressType", str)
+	}
+	return nil
+}
+
+func (e AddressType) MarshalGQL(w io.Writer) {
+	fmt.Fprint(w, strconv.Quote(e.String()))
+}
+
+type CacheControlScope string
+
+const (
+	CacheControlScopePublic  CacheControlScope = "PUBLIC"
+	CacheControlScopePrivate CacheControlScope = "PRIVATE"
+)
+
+var AllCacheControlScope = []CacheControlScope{
+	CacheControlScopePublic,
+	CacheControlScopePrivate,
+}
+
+func (e CacheControlScope) IsValid() bool {
+	switch e {
+	case CacheControlScopePublic, CacheControlScopePrivate:
+		return true
+	}
+	return false
+}
+
+func (e CacheControlScope) String() string {
+	return string(e)
+}
+
+func (e *CacheControlScope) UnmarshalGQL(v interface{}) error {
+	str, ok := v.(string)
+	if !ok {
+		return fmt.Errorf("enums must be strings")
+	}
+
+	*e = CacheControlScope(str)
+	if !e.IsValid() {
+		return fmt.Errorf("%s is not a valid CacheControlScope", str)
+	}
+	return nil
+}
+
+func (e CacheControlScope) MarshalGQL(w io.Writer) {
+	fmt.Fprint(w, strconv.Quote(e.String()))
+}
+
+type OrderStatus string
+
+const (
+	OrderStatusPending         OrderStatus = "PENDING"
+	OrderStatusStockReserved   OrderStatus = "STOCK_RESERVED"
+	OrderStatusPaymentCaptured OrderStatus = "PAYMENT_CAPTURED"
+	OrderStatusCompleted       OrderStatus = "COMPLETED"
+	OrderStatusCompensating    OrderStatus = "COMPENSATING"
+	OrderStatusFailed          OrderStatus = "FAILED"
+)
+
+var AllOrderStatus = []OrderStatus{
+	OrderStatusPending,
+	OrderStatusStockReserved,
+	OrderStatusPaymentCaptured,
+	OrderStatusCompleted,
+	OrderStatusCompensating,
+	OrderStatusFailed,
+}
+
+func (e OrderStatus) IsValid() bool {
+	switch e {
+	case OrderStatusPending, OrderStatusStockReserved, OrderStatusPaymentCaptured, OrderStatusCompleted, OrderStatusCompensating, OrderStatusFailed:
+		return true
+	}
+	return false
+}
+
+func (e OrderStatus) String() string {
+	return string(e)
+}
+
+func (e *OrderStatus) UnmarshalGQL(v interface{}) error {
+	str, ok := v.(string)
+	if !ok {
+		return fmt.Errorf("enums must be strings")
+	}
+
+	*e = OrderStatus(str)
+	if !e.IsValid() {
+		return fmt.Errorf("%s is not a valid OrderStatus", str)
+	}
+	return nil
+}
+
+func (e OrderStatus) MarshalGQL(w io.Writer) {
+	fmt.Fprint(w, strconv.Quote(e.String()))
+}
+
+type ProductOrderField string
+
+const (
+	ProductOrderFieldRelevance ProductOrderField = "RELEVANCE"
+	ProductOrderFieldRating    ProductOrderField = "RATING"
+)
+
+var AllProductOrderField = []ProductOrderField{
+	ProductOrderFieldRelevance,
+	ProductOrderFieldRating,
+}
+
+func (e ProductOrderField) IsValid() bool {
+	switch e {
+	case ProductOrderFieldRelevance, ProductOrderFieldRating:
+		return true
+	}
+	return false
+}
+
+func (e ProductOrderField) String() string {
+	return string(e)
+}
+
+func (e *ProductOrderField) UnmarshalGQL(v interface{}) error {
+	str, ok := v.(string)
+	if !ok {
+		return fmt.Errorf("enums must be strings")
+	}
+
+	*e = ProductOrderField(str)
+	if !e.IsValid() {
+		return fmt.Errorf("%s is not a valid ProductOrderField", str)
+	}
+	return nil
+}
+
+func (e ProductOrderField) MarshalGQL(w io.Writer) {
+	fmt.Fprint(w, strconv.Quote(e.String()))
+}
+
+type SortDirection string
+
+const (
+	SortDirectionAsc  SortDirection = "ASC"
+	SortDirectionDesc SortDirection = "DESC"
+)
+
+var AllSortDirection = []SortDirection{
+	SortDirectionAsc,
+	SortDirectionDesc,
+}
+
+func (e SortDirection) IsValid() bool {
+	switch e {
+	case SortDirectionAsc, SortDirectionDesc:
+		return true
+	}
+	return false
+}
+
+func (e SortDirection) String() string {
+	return string(e)
+}
+
+func (e *SortDirection) UnmarshalGQL(v interface{}) error {
+	str, ok := v.(string)
+	if !ok {
+		return fmt.Errorf("enums must be strings")
+	}
+
+	*e = SortDirection(str)
+	if !e.IsValid() {
+		return fmt.Errorf("%s is not a valid SortDirection", str)
+	}
+	return nil
+}
+
+func (e SortDirection) MarshalGQL(w io.Writer) {
+	fmt.Fprint(w, strconv.Quote(e.String()))
+}
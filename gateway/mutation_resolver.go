@@ -2,9 +2,16 @@ package main
 
 import (
 	"context"
+	"errors"
+	"fmt"
+	"graphql-grpc-go-microservice-project/account"
+	"graphql-grpc-go-microservice-project/cart"
 	"graphql-grpc-go-microservice-project/gateway/models"
 	"graphql-grpc-go-microservice-project/gateway/utils"
-	"time"
+	"graphql-grpc-go-microservice-project/order"
+
+	"github.com/99designs/gqlgen/graphql"
+	"github.com/google/uuid"
 )
 
 type mutationResolver struct {
@@ -12,25 +19,414 @@ type mutationResolver struct {
 }
 
 func (r *mutationResolver) CreateAccount(ctx context.Context, in models.AccountInput) (*models.Account, error) {
-	ctx, cancel := context.WithTimeout(ctx, 3*time.Second)
-	defer cancel()
-
 	account, err := r.server.AccountClient.CreateAccount(ctx, in.Email, in.Name)
 	if err != nil {
-		return nil, err
+		return nil, utils.ReportFieldViolations(ctx, err)
 	}
 
 	return utils.ConvertAccountToModel(account), nil
 }
 
 func (r *mutationResolver) CreateProduct(ctx context.Context, in models.ProductInput) (*models.Product, error) {
-	ctx, cancel := context.WithTimeout(ctx, 3*time.Second)
-	defer cancel()
+	price, err := utils.ConvertMoneyFromModel("price", in.Price)
+	if err != nil {
+		return nil, utils.ReportFieldViolations(ctx, err)
+	}
+
+	variants, err := utils.ConvertVariantsFromModel(in.Variants)
+	if err != nil {
+		return nil, utils.ReportFieldViolations(ctx, err)
+	}
+
+	product, err := r.server.ProductClient.CreateProduct(ctx, in.Name, in.Description, price, variants)
+	if err != nil {
+		return nil, utils.ReportFieldViolations(ctx, err)
+	}
+
+	r.server.Catalog.Invalidate(ctx)
+	return utils.ConvertProductToModel(product), nil
+}
+
+func (r *mutationResolver) UpdateAccount(ctx context.Context, id string, in models.AccountInput) (*models.Account, error) {
+	uuidID, err := uuid.Parse(id)
+	if err != nil {
+		return nil, errors.New("invalid ID format")
+	}
+	if err := ownAccountOr(ctx, r.server.AccountClient, uuidID.String(), account.RoleAdmin); err != nil {
+		return nil, err
+	}
 
-	product, err := r.server.ProductClient.CreateProduct(ctx, in.Name, in.Description, in.Price)
+	account, err := r.server.AccountClient.UpdateAccount(ctx, uuidID.String(), in.Email, in.Name)
+	if err != nil {
+		return nil, utils.ReportFieldViolations(ctx, err)
+	}
+
+	return utils.ConvertAccountToModel(account), nil
+}
+
+func (r *mutationResolver) DeleteAccount(ctx context.Context, id string) (*models.Account, error) {
+	uuidID, err := uuid.Parse(id)
+	if err != nil {
+		return nil, errors.New("invalid ID format")
+	}
+
+	account, err := r.server.AccountClient.DeleteAccount(ctx, uuidID.String())
 	if err != nil {
 		return nil, err
 	}
 
+	return utils.ConvertAccountToModel(account), nil
+}
+
+func (r *mutationResolver) RestoreAccount(ctx context.Context, id string) (*models.Account, error) {
+	uuidID, err := uuid.Parse(id)
+	if err != nil {
+		return nil, errors.New("invalid ID format")
+	}
+
+	account, err := r.server.AccountClient.RestoreAccount(ctx, uuidID.String())
+	if err != nil {
+		return nil, err
+	}
+
+	return utils.ConvertAccountToModel(account), nil
+}
+
+func (r *mutationResolver) SetAccountRole(ctx context.Context, id string, role models.AccountRole) (*models.Account, error) {
+	account, err := r.server.AccountClient.SetAccountRole(ctx, id, utils.ConvertAccountRoleFromModel(role))
+	if err != nil {
+		return nil, utils.ReportFieldViolations(ctx, err)
+	}
+
+	return utils.ConvertAccountToModel(account), nil
+}
+
+func (r *mutationResolver) SendVerificationEmail(ctx context.Context, accountID string) (bool, error) {
+	id, err := ownAccount(ctx, &accountID)
+	if err != nil {
+		return false, err
+	}
+	if err := r.server.AccountClient.SendVerification(ctx, id); err != nil {
+		return false, utils.ReportFieldViolations(ctx, err)
+	}
+	return true, nil
+}
+
+func (r *mutationResolver) VerifyEmail(ctx context.Context, token string) (*models.Account, error) {
+	account, err := r.server.AccountClient.VerifyEmail(ctx, token)
+	if err != nil {
+		return nil, utils.ReportFieldViolations(ctx, err)
+	}
+
+	return utils.ConvertAccountToModel(account), nil
+}
+
+func (r *mutationResolver) RequestPasswordReset(ctx context.Context, email string) (bool, error) {
+	if err := r.server.AccountClient.RequestPasswordReset(ctx, email); err != nil {
+		return false, utils.ReportFieldViolations(ctx, err)
+	}
+	return true, nil
+}
+
+func (r *mutationResolver) ResetPassword(ctx context.Context, token string, password string) (bool, error) {
+	if err := r.server.AccountClient.ResetPassword(ctx, token, password); err != nil {
+		return false, utils.ReportFieldViolations(ctx, err)
+	}
+	return true, nil
+}
+
+func (r *mutationResolver) SetProductCategories(ctx context.Context, productID string, categoryIDs []string) (*models.Product, error) {
+	product, err := r.server.ProductClient.SetProductCategories(ctx, productID, categoryIDs)
+	if err != nil {
+		return nil, utils.ReportFieldViolations(ctx, err)
+	}
+
+	r.server.Catalog.Invalidate(ctx)
 	return utils.ConvertProductToModel(product), nil
 }
+
+func (r *mutationResolver) CreateCategory(ctx context.Context, in models.CategoryInput) (*models.Category, error) {
+	var parentID string
+	if in.ParentID != nil {
+		parentID = *in.ParentID
+	}
+
+	category, err := r.server.ProductClient.CreateCategory(ctx, in.Name, parentID)
+	if err != nil {
+		return nil, utils.ReportFieldViolations(ctx, err)
+	}
+
+	return utils.ConvertCategoryToModel(category), nil
+}
+
+func (r *mutationResolver) RenameCategory(ctx context.Context, id string, name string) (*models.Category, error) {
+	category, err := r.server.ProductClient.RenameCategory(ctx, id, name)
+	if err != nil {
+		return nil, utils.ReportFieldViolations(ctx, err)
+	}
+
+	r.server.Catalog.Invalidate(ctx)
+	return utils.ConvertCategoryToModel(category), nil
+}
+
+func (r *mutationResolver) MoveCategory(ctx context.Context, id string, parentID *string) (*models.Category, error) {
+	var newParentID string
+	if parentID != nil {
+		newParentID = *parentID
+	}
+
+	category, err := r.server.ProductClient.MoveCategory(ctx, id, newParentID)
+	if err != nil {
+		return nil, utils.ReportFieldViolations(ctx, err)
+	}
+
+	r.server.Catalog.Invalidate(ctx)
+	return utils.ConvertCategoryToModel(category), nil
+}
+
+func (r *mutationResolver) DeleteCategory(ctx context.Context, id string) (*models.Category, error) {
+	category, err := r.server.ProductClient.DeleteCategory(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	r.server.Catalog.Invalidate(ctx)
+	return utils.ConvertCategoryToModel(category), nil
+}
+
+func (r *mutationResolver) AdjustStock(ctx context.Context, productID string, delta int) (*models.Stock, error) {
+	if err := requireStaff(ctx, r.server.AccountClient); err != nil {
+		return nil, err
+	}
+	if _, err := r.server.ProductClient.GetProductByID(ctx, productID); err != nil {
+		return nil, err
+	}
+
+	stock, err := r.server.InventoryClient.AdjustStock(ctx, productID, int32(delta))
+	if err != nil {
+		return nil, utils.ReportFieldViolations(ctx, err)
+	}
+
+	return utils.ConvertStockToModel(stock), nil
+}
+
+func (r *mutationResolver) SubmitReview(ctx context.Context, input models.ReviewInput) (*models.Review, error) {
+	accountID, err := ownAccount(ctx, input.AccountID)
+	if err != nil {
+		return nil, err
+	}
+
+	text := ""
+	if input.Text != nil {
+		text = *input.Text
+	}
+
+	review, err := r.server.ProductClient.SubmitReview(ctx, input.ProductID, accountID, int32(input.Rating), text)
+	if err != nil {
+		return nil, utils.ReportFieldViolations(ctx, err)
+	}
+
+	r.server.Catalog.Invalidate(ctx)
+	return utils.ConvertReviewToModel(review), nil
+}
+
+func (r *mutationResolver) AddToCart(ctx context.Context, owner *models.CartOwnerInput, productID string, quantity int) (*models.Cart, error) {
+	cartOwner, err := authorizeCartOwner(ctx, utils.ConvertCartOwnerFromModel(owner))
+	if err != nil {
+		return nil, err
+	}
+
+	cart, err := r.server.CartClient.AddItem(ctx, cartOwner, productID, int32(quantity))
+	if err != nil {
+		return nil, utils.ReportFieldViolations(ctx, err)
+	}
+
+	return utils.ConvertCartToModel(cart), nil
+}
+
+func (r *mutationResolver) UpdateCartItem(ctx context.Context, owner *models.CartOwnerInput, productID string, quantity int) (*models.Cart, error) {
+	cartOwner, err := authorizeCartOwner(ctx, utils.ConvertCartOwnerFromModel(owner))
+	if err != nil {
+		return nil, err
+	}
+
+	cart, err := r.server.CartClient.UpdateItem(ctx, cartOwner, productID, int32(quantity))
+	if err != nil {
+		return nil, utils.ReportFieldViolations(ctx, err)
+	}
+
+	return utils.ConvertCartToModel(cart), nil
+}
+
+func (r *mutationResolver) RemoveFromCart(ctx context.Context, owner *models.CartOwnerInput, productID string) (*models.Cart, error) {
+	cartOwner, err := authorizeCartOwner(ctx, utils.ConvertCartOwnerFromModel(owner))
+	if err != nil {
+		return nil, err
+	}
+
+	cart, err := r.server.CartClient.RemoveItem(ctx, cartOwner, productID)
+	if err != nil {
+		return nil, utils.ReportFieldViolations(ctx, err)
+	}
+
+	return utils.ConvertCartToModel(cart), nil
+}
+
+func (r *mutationResolver) ClearCart(ctx context.Context, owner *models.CartOwnerInput) (*models.Cart, error) {
+	cartOwner, err := authorizeCartOwner(ctx, utils.ConvertCartOwnerFromModel(owner))
+	if err != nil {
+		return nil, err
+	}
+
+	cart, err := r.server.CartClient.ClearCart(ctx, cartOwner)
+	if err != nil {
+		return nil, utils.ReportFieldViolations(ctx, err)
+	}
+
+	return utils.ConvertCartToModel(cart), nil
+}
+
+// MergeCarts moves an anonymous cart into the cart of the signed-in account.
+func (r *mutationResolver) MergeCarts(ctx context.Context, cartID string, accountID *string) (*models.Cart, error) {
+	ownID, err := ownAccount(ctx, accountID)
+	if err != nil {
+		return nil, err
+	}
+
+	cart, err := r.server.CartClient.MergeCarts(ctx, cartID, ownID)
+	if err != nil {
+		return nil, utils.ReportFieldViolations(ctx, err)
+	}
+
+	return utils.ConvertCartToModel(cart), nil
+}
+
+// Checkout places an order for the given items, or for the account's cart
+// when no items are given; the cart is emptied once the order completes. The
+// order is returned in the status its saga reached, which is FAILED with a
+// failureReason when stock or payment was refused.
+func (r *mutationResolver) Checkout(ctx context.Context, input models.CheckoutInput) (*models.Order, error) {
+	accountID, err := ownAccount(ctx, input.AccountID)
+	if err != nil {
+		return nil, err
+	}
+
+	fromCart := input.Items == nil
+	items := utils.ConvertCheckoutItemsFromModel(input.Items)
+	if fromCart {
+		c, err := r.server.CartClient.GetCart(ctx, cart.CartOwner{AccountID: accountID})
+		if err != nil {
+			return nil, utils.ReportFieldViolations(ctx, err)
+		}
+		items = utils.ConvertCartToCheckoutItems(c)
+	}
+
+	o, err := r.server.OrderClient.Checkout(ctx, accountID, items, input.PaymentToken)
+	if err != nil {
+		return nil, utils.ReportFieldViolations(ctx, err)
+	}
+
+	if fromCart && o.Status == order.OrderStatusCompleted {
+		if _, err := r.server.CartClient.ClearCart(ctx, cart.CartOwner{AccountID: accountID}); err != nil {
+			graphql.AddError(ctx, fmt.Errorf("order %s was placed but the cart could not be cleared: %w", o.ID, err))
+		}
+	}
+
+	return utils.ConvertOrderToModel(o), nil
+}
+
+func (r *mutationResolver) CreateWishlist(ctx context.Context, accountID *string, name string) (*models.Wishlist, error) {
+	owner, err := ownAccount(ctx, accountID)
+	if err != nil {
+		return nil, err
+	}
+
+	wishlist, err := r.server.AccountClient.CreateWishlist(ctx, owner, name)
+	if err != nil {
+		return nil, utils.ReportFieldViolations(ctx, err)
+	}
+
+	return utils.ConvertWishlistToModel(wishlist, nil), nil
+}
+
+func (r *mutationResolver) AddToWishlist(ctx context.Context, accountID *string, wishlistID string, productID string) (*models.Wishlist, error) {
+	owner, err := ownAccount(ctx, accountID)
+	if err != nil {
+		return nil, err
+	}
+
+	if _, err := r.server.ProductClient.GetProductByID(ctx, productID); err != nil {
+		return nil, err
+	}
+
+	wishlist, err := r.server.AccountClient.AddWishlistItem(ctx, owner, wishlistID, productID)
+	if err != nil {
+		return nil, utils.ReportFieldViolations(ctx, err)
+	}
+
+	return r.resolveWishlist(ctx, wishlist)
+}
+
+func (r *mutationResolver) RemoveFromWishlist(ctx context.Context, accountID *string, wishlistID string, productID string) (*models.Wishlist, error) {
+	owner, err := ownAccount(ctx, accountID)
+	if err != nil {
+		return nil, err
+	}
+
+	wishlist, err := r.server.AccountClient.RemoveWishlistItem(ctx, owner, wishlistID, productID)
+	if err != nil {
+		return nil, utils.ReportFieldViolations(ctx, err)
+	}
+
+	return r.resolveWishlist(ctx, wishlist)
+}
+
+func (r *mutationResolver) CreateAddress(ctx context.Context, accountID *string, input models.AddressInput) (*models.Address, error) {
+	owner, err := ownAccount(ctx, accountID)
+	if err != nil {
+		return nil, err
+	}
+
+	address, err := r.server.AccountClient.CreateAddress(ctx, owner, utils.ConvertAddressInputFromModel(input))
+	if err != nil {
+		return nil, utils.ReportFieldViolations(ctx, err)
+	}
+
+	return utils.ConvertAddressToModel(address), nil
+}
+
+func (r *mutationResolver) UpdateAddress(ctx context.Context, accountID *string, addressID string, input models.AddressInput) (*models.Address, error) {
+	owner, err := ownAccount(ctx, accountID)
+	if err != nil {
+		return nil, err
+	}
+
+	address, err := r.server.AccountClient.UpdateAddress(ctx, owner, addressID, utils.ConvertAddressInputFromModel(input))
+	if err != nil {
+		return nil, utils.ReportFieldViolations(ctx, err)
+	}
+
+	return utils.ConvertAddressToModel(address), nil
+}
+
+func (r *mutationResolver) DeleteAddress(ctx context.Context, accountID *string, addressID string) (*models.Address, error) {
+	owner, err := ownAccount(ctx, accountID)
+	if err != nil {
+		return nil, err
+	}
+
+	address, err := r.server.AccountClient.DeleteAddress(ctx, owner, addressID)
+	if err != nil {
+		return nil, utils.ReportFieldViolations(ctx, err)
+	}
+
+	return utils.ConvertAddressToModel(address), nil
+}
+
+func (r *mutationResolver) resolveWishlist(ctx context.Context, wishlist *account.Wishlist) (*models.Wishlist, error) {
+	resolved, err := r.server.resolveWishlists(ctx, *wishlist)
+	if err != nil {
+		return nil, err
+	}
+	return resolved[0], nil
+}
@@ -0,0 +1,102 @@
+package persisted
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"github.com/99designs/gqlgen/graphql"
+	"github.com/99designs/gqlgen/graphql/errcode"
+	"github.com/vektah/gqlparser/v2/gqlerror"
+)
+
+const errQueryNotAllowedCode = "PERSISTED_QUERY_NOT_ALLOWED"
+
+// AllowList only executes the queries registered in a manifest file. Clients
+// send the SHA-256 hash of a query in the APQ persistedQuery extension, or
+// the full text of a registered query; everything else is rejected before
+// it is parsed.
+type AllowList struct {
+	queries map[string]string
+}
+
+var _ interface {
+	graphql.OperationParameterMutator
+	graphql.HandlerExtension
+} = &AllowList{}
+
+// LoadAllowList reads either an Apollo persisted query manifest or a JSON
+// object mapping each query hash to its query. Every hash must be the
+// SHA-256 of its query in lowercase hex.
+func LoadAllowList(path string) (*AllowList, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read persisted queries: %w", err)
+	}
+
+	var manifest struct {
+		Operations []struct {
+			ID   string `json:"id"`
+			Body string `json:"body"`
+		} `json:"operations"`
+	}
+	if err := json.Unmarshal(data, &manifest); err != nil {
+		return nil, fmt.Errorf("failed to parse persisted queries: %w", err)
+	}
+
+	queries := map[string]string{}
+	if manifest.Operations != nil {
+		for _, operation := range manifest.Operations {
+			queries[operation.ID] = operation.Body
+		}
+	} else if err := json.Unmarshal(data, &queries); err != nil {
+		return nil, fmt.Errorf("failed to parse persisted queries: %w", err)
+	}
+
+	for hash, query := range queries {
+		if queryHash(query) != hash {
+			return nil, fmt.Errorf("persisted query %s does not match its hash", hash)
+		}
+	}
+	return &AllowList{queries: queries}, nil
+}
+
+func (l *AllowList) Len() int {
+	return len(l.queries)
+}
+
+func (l *AllowList) ExtensionName() string {
+	return "PersistedQueryAllowList"
+}
+
+func (l *AllowList) Validate(graphql.ExecutableSchema) error {
+	return nil
+}
+
+func (l *AllowList) MutateOperationParameters(_ context.Context, rawParams *graphql.RawParams) *gqlerror.Error {
+	var hash string
+	if extension, ok := rawParams.Extensions["persistedQuery"].(map[string]any); ok {
+		hash, _ = extension["sha256Hash"].(string)
+	}
+	if hash == "" {
+		hash = queryHash(rawParams.Query)
+	}
+
+	query, ok := l.queries[hash]
+	if !ok || (rawParams.Query != "" && rawParams.Query != query) {
+		err := gqlerror.Errorf("query is not a registered persisted query")
+		errcode.Set(err, errQueryNotAllowedCode)
+		return err
+	}
+
+	rawParams.Query = query
+	return nil
+}
+
+func queryHash(query string) string {
+	sum := sha256.Sum256([]byte(query))
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,44 @@
+package persisted
+
+import (
+	"context"
+	"errors"
+	"log"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+const redisKeyPrefix = "apq:"
+
+// RedisCache stores persisted queries in Redis or any server speaking the
+// Redis protocol, so that a query registered through one gateway instance is
+// known to all of them. Lookups that fail count as cache misses, which makes
+// clients resend the full query.
+type RedisCache struct {
+	client *redis.Client
+	ttl    time.Duration
+}
+
+// NewRedisCache stores queries on client. Queries expire ttl after they were
+// last registered; a zero ttl keeps them forever.
+func NewRedisCache(client *redis.Client, ttl time.Duration) *RedisCache {
+	return &RedisCache{client: client, ttl: ttl}
+}
+
+func (c *RedisCache) Get(ctx context.Context, hash string) (string, bool) {
+	query, err := c.client.Get(ctx, redisKeyPrefix+hash).Result()
+	if err != nil {
+		if !errors.Is(err, redis.Nil) {
+			log.Printf("Failed to get persisted query %s: %v", hash, err)
+		}
+		return "", false
+	}
+	return query, true
+}
+
+func (c *RedisCache) Add(ctx context.Context, hash, query string) {
+	if err := c.client.Set(ctx, redisKeyPrefix+hash, query, c.ttl).Err(); err != nil {
+		log.Printf("Failed to store persisted query %s: %v", hash, err)
+	}
+}
@@ -0,0 +1,87 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"graphql-grpc-go-microservice-project/common"
+	"graphql-grpc-go-microservice-project/gateway/models"
+	"graphql-grpc-go-microservice-project/gateway/utils"
+)
+
+type productResolver struct {
+	server *GatewayServer
+}
+
+func (r *productResolver) Price(ctx context.Context, obj *models.Product, currency *string) (*models.Money, error) {
+	if obj == nil || obj.Price == nil {
+		return nil, errors.New("product price is nil")
+	}
+
+	return r.server.convertPrice(obj.Price, currency)
+}
+
+func (r *productResolver) Stock(ctx context.Context, obj *models.Product) (*models.Stock, error) {
+	stock, err := r.server.InventoryClient.GetStock(ctx, []string{obj.ID})
+	if err != nil {
+		return nil, err
+	}
+	if len(stock) == 0 {
+		return nil, errors.New("stock not returned for product")
+	}
+
+	return utils.ConvertStockToModel(&stock[0]), nil
+}
+
+func (r *productResolver) Reviews(ctx context.Context, obj *models.Product, pagination *models.PaginationInput) ([]*models.Review, error) {
+	var limit, offset uint32 = 20, 0
+	if pagination != nil {
+		limit = uint32(pagination.Limit)
+		offset = uint32(pagination.Offset)
+
+		if limit > 20 {
+			return nil, fmt.Errorf("failed to fetch reviews: limit %d is greater than 20", limit)
+		}
+	}
+
+	reviews, err := r.server.ProductClient.ListReviews(ctx, obj.ID, limit, offset)
+	if err != nil {
+		return nil, err
+	}
+
+	reviewList := []*models.Review{}
+	for _, review := range reviews {
+		reviewList = append(reviewList, utils.ConvertReviewToModel(review))
+	}
+	return reviewList, nil
+}
+
+type productVariantResolver struct {
+	server *GatewayServer
+}
+
+func (r *productVariantResolver) Price(ctx context.Context, obj *models.ProductVariant, currency *string) (*models.Money, error) {
+	if obj == nil || obj.Price == nil {
+		return nil, errors.New("variant price is nil")
+	}
+
+	return r.server.convertPrice(obj.Price, currency)
+}
+
+// convertPrice returns price unchanged unless a currency is requested, in
+// which case it is converted with the cached exchange rates.
+func (s *GatewayServer) convertPrice(price *models.Money, currency *string) (*models.Money, error) {
+	if currency == nil || *currency == "" {
+		return price, nil
+	}
+
+	converted, err := s.ExchangeRates.Convert(common.Money{
+		Amount:       price.MinorUnits,
+		CurrencyCode: price.CurrencyCode,
+	}, *currency)
+	if err != nil {
+		return nil, err
+	}
+
+	return utils.ConvertMoneyToModel(converted), nil
+}
@@ -6,8 +6,11 @@ import (
 	"fmt"
 	"graphql-grpc-go-microservice-project/gateway/models"
 	"graphql-grpc-go-microservice-project/gateway/utils"
+	"graphql-grpc-go-microservice-project/product"
 
+	"github.com/99designs/gqlgen/graphql"
 	"github.com/google/uuid"
+	"github.com/vektah/gqlparser/v2/gqlerror"
 )
 
 type queryResolver struct {
@@ -37,7 +40,7 @@ func (r *queryResolver) GetAccountByEmail(ctx context.Context, email string) (*m
 	return utils.ConvertAccountToModel(account), nil
 }
 
-func (r *queryResolver) ListAccounts(ctx context.Context, pagination *models.PaginationInput) ([]*models.Account, error) {
+func (r *queryResolver) ListAccounts(ctx context.Context, pagination *models.PaginationInput, filter *models.AccountFilterInput, orderBy *models.AccountOrderByInput) (*models.AccountList, error) {
 	var limit, offset uint32
 	limit, offset = 0, 0
 
@@ -50,20 +53,67 @@ func (r *queryResolver) ListAccounts(ctx context.Context, pagination *models.Pag
 		}
 	}
 
-	accounts, err := r.server.AccountClient.ListAccounts(ctx, limit, offset)
+	accountFilter, err := utils.ConvertAccountFilterFromModel(filter)
 	if err != nil {
 		return nil, err
 	}
 
-	var accountList []*models.Account
+	accounts, total, err := r.server.AccountClient.ListAccounts(ctx, accountFilter, utils.ConvertAccountOrderByFromModel(orderBy), limit, offset)
+	if err != nil {
+		return nil, err
+	}
+
+	accountList := []*models.Account{}
 	for _, acc := range accounts {
 		accountList = append(accountList, utils.ConvertAccountToModel(&acc))
 	}
-	return accountList, nil
+	return &models.AccountList{Accounts: accountList, TotalCount: int(total)}, nil
+}
+
+func (r *queryResolver) GetAccountHistory(ctx context.Context, id string, pagination *models.PaginationInput) ([]*models.AccountAuditEntry, error) {
+	uuidID, err := uuid.Parse(id)
+	if err != nil {
+		return nil, errors.New("invalid ID format")
+	}
+	if err := ownAccountOrStaff(ctx, r.server.AccountClient, uuidID.String()); err != nil {
+		return nil, err
+	}
+
+	var limit, offset uint32
+	limit, offset = 0, 0
+
+	if pagination != nil {
+		limit = uint32(pagination.Limit)
+		offset = uint32(pagination.Offset)
+
+		if limit > 20 {
+			return nil, fmt.Errorf("failed to fetch account history: limit %d is greater than 20", limit)
+		}
+	}
+
+	entries, err := r.server.AccountClient.GetAccountHistory(ctx, uuidID.String(), limit, offset)
+	if err != nil {
+		return nil, err
+	}
+
+	var entryList []*models.AccountAuditEntry
+	for _, entry := range entries {
+		entryList = append(entryList, utils.ConvertAuditEntryToModel(&entry))
+	}
+	return entryList, nil
 }
 
 func (r *queryResolver) GetProductByID(ctx context.Context, id string) (*models.Product, error) {
-	product, err := r.server.ProductClient.GetProductByID(ctx, id)
+	product, err := r.server.Catalog.GetProductByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	return utils.ConvertProductToModel(product), nil
+}
+
+func (r *queryResolver) GetProductBySku(ctx context.Context, sku string) (*models.Product, error) {
+	product, err := r.server.ProductClient.GetProductBySKU(ctx, sku)
 	if err != nil {
 		return nil, err
 	}
@@ -84,7 +134,7 @@ func (r *queryResolver) ListProducts(ctx context.Context, pagination *models.Pag
 		}
 	}
 
-	products, err := r.server.ProductClient.ListProducts(ctx, uint32(limit), uint32(offset))
+	products, err := r.server.Catalog.ListProducts(ctx, uint32(limit), uint32(offset))
 	if err != nil {
 		return nil, err
 	}
@@ -121,7 +171,7 @@ func (r *queryResolver) ListProductsWithIDs(ctx context.Context, ids []string, p
 	return productList, nil
 }
 
-func (r *queryResolver) SearchProducts(ctx context.Context, search string, pagination *models.PaginationInput) ([]*models.Product, error) {
+func (r *queryResolver) SearchProducts(ctx context.Context, search string, attributes []*models.ProductAttributeInput, inStock *bool, minRating *float64, orderBy *models.ProductOrderField, pagination *models.PaginationInput) ([]*models.Product, error) {
 	var limit, offset uint32
 	limit, offset = 0, 0
 
@@ -134,9 +184,31 @@ func (r *queryResolver) SearchProducts(ctx context.Context, search string, pagin
 		}
 	}
 
-	products, err := r.server.ProductClient.SearchProducts(ctx, search, uint32(limit), uint32(offset))
+	filter := product.ProductSearchFilter{Attributes: utils.ConvertAttributesFromModel(attributes)}
+	if minRating != nil {
+		filter.MinRating = *minRating
+	}
+	productOrderBy := utils.ConvertProductOrderFieldFromModel(orderBy)
+
+	var products []*product.Product
+	var err error
+	if inStock == nil {
+		products, err = r.server.Catalog.SearchProducts(ctx, search, filter, productOrderBy, uint32(limit), uint32(offset))
+	} else {
+		var truncated bool
+		products, truncated, err = r.searchProductsByAvailability(ctx, search, filter, productOrderBy, *inStock, limit, offset)
+		if truncated {
+			graphql.AddError(ctx, &gqlerror.Error{
+				Message: fmt.Sprintf("only the first %d search results were checked for stock, narrow the search to see more", availabilityScanBatchSize*availabilityScanMaxBatches),
+				Path:    graphql.GetPath(ctx),
+				Extensions: map[string]interface{}{
+					"code": "RESULTS_TRUNCATED",
+				},
+			})
+		}
+	}
 	if err != nil {
-		return nil, err
+		return nil, utils.ReportFieldViolations(ctx, err)
 	}
 
 	var productList []*models.Product
@@ -145,3 +217,129 @@ func (r *queryResolver) SearchProducts(ctx context.Context, search string, pagin
 	}
 	return productList, nil
 }
+
+const (
+	availabilityScanBatchSize  = 50
+	availabilityScanMaxBatches = 10
+)
+
+// searchProductsByAvailability pages through the search results and keeps the
+// products whose stock matches inStock. Stock lives in the inventory service,
+// so offset and limit apply to the filtered results and at most
+// availabilityScanMaxBatches pages of search results are examined. truncated
+// reports that the page came up short because the scan stopped there, while
+// further search results might have matched.
+func (r *queryResolver) searchProductsByAvailability(ctx context.Context, search string, filter product.ProductSearchFilter, orderBy product.ProductOrderField, inStock bool, limit, offset uint32) (matched []*product.Product, truncated bool, err error) {
+	matched = []*product.Product{}
+	skipped := uint32(0)
+
+	for batch := uint32(0); uint32(len(matched)) < limit; batch++ {
+		if batch == availabilityScanMaxBatches {
+			return matched, true, nil
+		}
+
+		products, err := r.server.Catalog.SearchProducts(ctx, search, filter, orderBy, availabilityScanBatchSize, batch*availabilityScanBatchSize)
+		if err != nil {
+			return nil, false, err
+		}
+		if len(products) == 0 {
+			break
+		}
+
+		ids := make([]string, 0, len(products))
+		for _, prod := range products {
+			ids = append(ids, prod.ID)
+		}
+		stock, err := r.server.InventoryClient.GetStock(ctx, ids)
+		if err != nil {
+			return nil, false, err
+		}
+
+		for i, prod := range products {
+			if i >= len(stock) || (stock[i].Available() > 0) != inStock {
+				continue
+			}
+			if skipped < offset {
+				skipped++
+				continue
+			}
+			matched = append(matched, prod)
+			if uint32(len(matched)) == limit {
+				break
+			}
+		}
+
+		if len(products) < availabilityScanBatchSize {
+			break
+		}
+	}
+
+	return matched, false, nil
+}
+
+func (r *queryResolver) Categories(ctx context.Context) ([]*models.Category, error) {
+	categories, err := r.server.ProductClient.ListCategories(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	categoryList := []*models.Category{}
+	for _, category := range categories {
+		categoryList = append(categoryList, utils.ConvertCategoryToModel(category))
+	}
+	return categoryList, nil
+}
+
+func (r *queryResolver) ProductsInCategory(ctx context.Context, categoryID string, pagination *models.PaginationInput) ([]*models.Product, error) {
+	var limit, offset uint32
+	limit, offset = 0, 0
+
+	if pagination != nil {
+		limit = uint32(pagination.Limit)
+		offset = uint32(pagination.Offset)
+
+		if limit > 20 {
+			return nil, fmt.Errorf("failed to fetch products: limit %d is greater than 20", limit)
+		}
+	}
+
+	products, err := r.server.ProductClient.ListProductsInCategory(ctx, categoryID, limit, offset)
+	if err != nil {
+		return nil, utils.ReportFieldViolations(ctx, err)
+	}
+
+	productList := []*models.Product{}
+	for _, prod := range products {
+		productList = append(productList, utils.ConvertProductToModel(prod))
+	}
+	return productList, nil
+}
+
+func (r *queryResolver) Cart(ctx context.Context, owner *models.CartOwnerInput) (*models.Cart, error) {
+	cartOwner, err := authorizeCartOwner(ctx, utils.ConvertCartOwnerFromModel(owner))
+	if err != nil {
+		return nil, err
+	}
+
+	cart, err := r.server.CartClient.GetCart(ctx, cartOwner)
+	if err != nil {
+		return nil, utils.ReportFieldViolations(ctx, err)
+	}
+
+	return utils.ConvertCartToModel(cart), nil
+}
+
+// Order returns an order placed by the signed-in account.
+func (r *queryResolver) Order(ctx context.Context, id string) (*models.Order, error) {
+	accountID, err := ownAccount(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	o, err := r.server.OrderClient.GetOrder(ctx, id, accountID)
+	if err != nil {
+		return nil, utils.ReportFieldViolations(ctx, err)
+	}
+
+	return utils.ConvertOrderToModel(o), nil
+}
@@ -0,0 +1,251 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"graphql-grpc-go-microservice-project/common"
+
+	"github.com/99designs/gqlgen/graphql"
+	"github.com/99designs/gqlgen/graphql/errcode"
+	"github.com/vektah/gqlparser/v2/ast"
+	"github.com/vektah/gqlparser/v2/gqlerror"
+)
+
+const errRateLimitedCode = "RATE_LIMITED"
+
+// rateLimitState identifies the client of a request and collects the longest
+// delay any rate limit asked it to wait, which is sent as Retry-After.
+type rateLimitState struct {
+	client string
+
+	mu         sync.Mutex
+	retryAfter time.Duration
+}
+
+func (s *rateLimitState) limited(retryAfter time.Duration) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.retryAfter = max(s.retryAfter, retryAfter)
+}
+
+func (s *rateLimitState) retryAfterSeconds() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return retryAfterSeconds(s.retryAfter)
+}
+
+type rateLimitStateKey struct{}
+
+func rateLimitStateFromContext(ctx context.Context) *rateLimitState {
+	state, _ := ctx.Value(rateLimitStateKey{}).(*rateLimitState)
+	return state
+}
+
+// withRateLimit limits the requests of each client, identified by the
+// signed-in account or else by its IP address. The IP address is taken from
+// ipHeader when the gateway runs behind proxyHops proxies that append to it. Rejected
+// requests get a 429 response with a Retry-After header. The client is
+// forwarded with the calls to the services, which limit it in turn.
+func withRateLimit(limiter *common.RateLimiter, accountHeader, ipHeader string, proxyHops int, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		state := &rateLimitState{client: rateLimitClient(r, accountHeader, ipHeader, proxyHops)}
+
+		if ok, retryAfter := limiter.Allow(state.client); !ok {
+			writeRateLimited(w, retryAfter)
+			return
+		}
+
+		ctx := common.ContextWithRateLimitKey(r.Context(), state.client)
+		r = r.WithContext(context.WithValue(ctx, rateLimitStateKey{}, state))
+		if r.Header.Get("Upgrade") == "" {
+			w = &retryAfterWriter{ResponseWriter: w, state: state}
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+func rateLimitClient(r *http.Request, accountHeader, ipHeader string, proxyHops int) string {
+	if id, ok := accountIDFromRequest(r, accountHeader); ok {
+		return "account:" + id
+	}
+
+	if ip := forwardedClientIP(r, ipHeader, proxyHops); ip != "" {
+		return "ip:" + ip
+	}
+
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		host = r.RemoteAddr
+	}
+	return "ip:" + host
+}
+
+// forwardedClientIP returns the address the outermost of proxyHops proxies
+// appended to ipHeader. Each proxy appends the address it received the
+// request from, so the entries left of it come from the client and are not
+// trusted.
+func forwardedClientIP(r *http.Request, ipHeader string, proxyHops int) string {
+	if ipHeader == "" || proxyHops < 1 {
+		return ""
+	}
+
+	var entries []string
+	for _, value := range r.Header.Values(ipHeader) {
+		for _, entry := range strings.Split(value, ",") {
+			entries = append(entries, strings.TrimSpace(entry))
+		}
+	}
+	if len(entries) < proxyHops {
+		return ""
+	}
+	return entries[len(entries)-proxyHops]
+}
+
+func writeRateLimited(w http.ResponseWriter, retryAfter time.Duration) {
+	err := rateLimitedError(fmt.Sprintf("rate limit exceeded, retry after %ds", retryAfterSeconds(retryAfter)), retryAfter)
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Retry-After", strconv.Itoa(retryAfterSeconds(retryAfter)))
+	w.WriteHeader(http.StatusTooManyRequests)
+	json.NewEncoder(w).Encode(graphql.Response{Errors: gqlerror.List{err}})
+}
+
+// retryAfterWriter adds the Retry-After header to GraphQL responses in which
+// an operation or a service was rate limited.
+type retryAfterWriter struct {
+	http.ResponseWriter
+	state       *rateLimitState
+	wroteHeader bool
+}
+
+func (w *retryAfterWriter) WriteHeader(code int) {
+	if !w.wroteHeader {
+		w.wroteHeader = true
+		if seconds := w.state.retryAfterSeconds(); seconds > 0 {
+			w.Header().Set("Retry-After", strconv.Itoa(seconds))
+		}
+	}
+	w.ResponseWriter.WriteHeader(code)
+}
+
+func (w *retryAfterWriter) Write(data []byte) (int, error) {
+	if !w.wroteHeader {
+		w.WriteHeader(http.StatusOK)
+	}
+	return w.ResponseWriter.Write(data)
+}
+
+func (w *retryAfterWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
+
+// operationRateLimit limits how often each client may call the root fields
+// that have a limiter, such as searchProducts or createAccount.
+type operationRateLimit struct {
+	limiters map[string]*common.RateLimiter
+}
+
+var _ interface {
+	graphql.OperationContextMutator
+	graphql.HandlerExtension
+} = operationRateLimit{}
+
+// newOperationRateLimit parses limits of the form "rate/burst", in requests
+// per second and requests at once, keyed by root field name.
+func newOperationRateLimit(limits map[string]string) (operationRateLimit, error) {
+	limiters := map[string]*common.RateLimiter{}
+	for field, limit := range limits {
+		rateText, burstText, _ := strings.Cut(limit, "/")
+
+		rate, err := strconv.ParseFloat(rateText, 64)
+		if err != nil {
+			return operationRateLimit{}, fmt.Errorf("invalid rate limit %q for %s", limit, field)
+		}
+		burst := int(math.Ceil(rate))
+		if burstText != "" {
+			if burst, err = strconv.Atoi(burstText); err != nil {
+				return operationRateLimit{}, fmt.Errorf("invalid rate limit %q for %s", limit, field)
+			}
+		}
+
+		limiters[field] = common.NewRateLimiter(rate, burst)
+	}
+	return operationRateLimit{limiters: limiters}, nil
+}
+
+func (l operationRateLimit) ExtensionName() string {
+	return "OperationRateLimit"
+}
+
+func (l operationRateLimit) Validate(graphql.ExecutableSchema) error {
+	return nil
+}
+
+func (l operationRateLimit) MutateOperationContext(ctx context.Context, rc *graphql.OperationContext) *gqlerror.Error {
+	state := rateLimitStateFromContext(ctx)
+	if state == nil || rc.Operation == nil {
+		return nil
+	}
+
+	for _, field := range rootFields(rc.Operation.SelectionSet) {
+		if ok, retryAfter := l.limiters[field].Allow(state.client); !ok {
+			state.limited(retryAfter)
+			return rateLimitedError(fmt.Sprintf("rate limit exceeded for %s, retry after %ds", field, retryAfterSeconds(retryAfter)), retryAfter)
+		}
+	}
+	return nil
+}
+
+// rootFields lists the names of the root fields of an operation, once per
+// occurrence so that aliases of the same field count separately.
+func rootFields(set ast.SelectionSet) []string {
+	var fields []string
+	for _, selection := range set {
+		switch selection := selection.(type) {
+		case *ast.Field:
+			fields = append(fields, selection.Name)
+		case *ast.InlineFragment:
+			fields = append(fields, rootFields(selection.SelectionSet)...)
+		case *ast.FragmentSpread:
+			if selection.Definition != nil {
+				fields = append(fields, rootFields(selection.Definition.SelectionSet)...)
+			}
+		}
+	}
+	return fields
+}
+
+// presentRateLimit reports gRPC ResourceExhausted errors from the services
+// as rate limited, like the limits of the gateway itself.
+func presentRateLimit(ctx context.Context, err error, presented *gqlerror.Error) {
+	if retryAfter, ok := common.RetryAfter(err); ok {
+		errcode.Set(presented, errRateLimitedCode)
+		presented.Extensions["retryAfter"] = retryAfterSeconds(retryAfter)
+		if state := rateLimitStateFromContext(ctx); state != nil {
+			state.limited(retryAfter)
+		}
+	}
+}
+
+func rateLimitedError(message string, retryAfter time.Duration) *gqlerror.Error {
+	err := gqlerror.Errorf("%s", message)
+	errcode.Set(err, errRateLimitedCode)
+	err.Extensions["retryAfter"] = retryAfterSeconds(retryAfter)
+	return err
+}
+
+func retryAfterSeconds(retryAfter time.Duration) int {
+	if retryAfter <= 0 {
+		return 0
+	}
+	return max(1, int(math.Ceil(retryAfter.Seconds())))
+}
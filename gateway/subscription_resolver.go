@@ -0,0 +1,75 @@
+package main
+
+import (
+	"context"
+	"graphql-grpc-go-microservice-project/account"
+	"graphql-grpc-go-microservice-project/gateway/models"
+	"graphql-grpc-go-microservice-project/gateway/utils"
+	"graphql-grpc-go-microservice-project/product"
+)
+
+type subscriptionResolver struct {
+	server *GatewayServer
+}
+
+func (r *subscriptionResolver) ProductCreated(ctx context.Context) (<-chan *models.Product, error) {
+	return r.watchProducts(ctx, "", product.ProductEventCreated)
+}
+
+func (r *subscriptionResolver) ProductUpdated(ctx context.Context, id string) (<-chan *models.Product, error) {
+	return r.watchProducts(ctx, id, product.ProductEventUpdated)
+}
+
+// AccountCreated exposes the details of every new account, so only staff and
+// admins may subscribe.
+func (r *subscriptionResolver) AccountCreated(ctx context.Context) (<-chan *models.Account, error) {
+	if err := requireStaff(ctx, r.server.AccountClient); err != nil {
+		return nil, err
+	}
+
+	events, err := r.server.AccountClient.WatchAccounts(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	accounts := make(chan *models.Account)
+	go func() {
+		defer close(accounts)
+		for event := range events {
+			if event.Type != account.AccountEventCreated {
+				continue
+			}
+			select {
+			case accounts <- utils.ConvertAccountToModel(&event.Account):
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+	return accounts, nil
+}
+
+// watchProducts ends the subscription when the product service closes the
+// stream, so that clients resubscribe instead of silently missing events.
+func (r *subscriptionResolver) watchProducts(ctx context.Context, id string, eventType product.ProductEventType) (<-chan *models.Product, error) {
+	events, err := r.server.ProductClient.WatchProducts(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	products := make(chan *models.Product)
+	go func() {
+		defer close(products)
+		for event := range events {
+			if event.Type != eventType {
+				continue
+			}
+			select {
+			case products <- utils.ConvertProductToModel(&event.Product):
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+	return products, nil
+}
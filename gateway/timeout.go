@@ -0,0 +1,28 @@
+package main
+
+import (
+	"context"
+	"net/http"
+	"time"
+)
+
+// withRequestTimeout gives each GraphQL request a deadline. The clients of the
+// downstream services pass it on with every call, so a slow service cannot
+// hold a request past it. Subscriptions run for as long as the socket is open
+// and have no deadline.
+func withRequestTimeout(timeout time.Duration, next http.Handler) http.Handler {
+	if timeout <= 0 {
+		return next
+	}
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Upgrade") != "" {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		ctx, cancel := context.WithTimeout(r.Context(), timeout)
+		defer cancel()
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
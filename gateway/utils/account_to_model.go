@@ -1,17 +1,108 @@
 package utils
 
 import (
+	"fmt"
 	"graphql-grpc-go-microservice-project/account"
 	"graphql-grpc-go-microservice-project/gateway/models"
+	"strings"
 	"time"
 )
 
 func ConvertAccountToModel(account *account.Account) *models.Account {
+	var deletedAt *string
+	if account.DeletedAt != nil {
+		formatted := account.DeletedAt.Format(time.RFC3339)
+		deletedAt = &formatted
+	}
+
+	var emailVerifiedAt *string
+	if account.EmailVerifiedAt != nil {
+		formatted := account.EmailVerifiedAt.Format(time.RFC3339)
+		emailVerifiedAt = &formatted
+	}
+
 	return &models.Account{
-		ID:        account.ID,
-		Email:     account.Email,
-		Name:      account.Name,
-		CreatedAt: account.CreatedAt.Format(time.RFC3339),
-		UpdatedAt: account.UpdatedAt.Format(time.RFC3339),
+		ID:              account.ID,
+		Email:           account.Email,
+		Name:            account.Name,
+		Role:            models.AccountRole(strings.ToUpper(string(account.Role))),
+		EmailVerified:   account.EmailVerifiedAt != nil,
+		EmailVerifiedAt: emailVerifiedAt,
+		CreatedAt:       account.CreatedAt.Format(time.RFC3339),
+		UpdatedAt:       account.UpdatedAt.Format(time.RFC3339),
+		DeletedAt:       deletedAt,
+	}
+}
+
+func ConvertAccountRoleFromModel(role models.AccountRole) account.Role {
+	return account.Role(strings.ToLower(string(role)))
+}
+
+func ConvertAuditEntryToModel(entry *account.AuditEntry) *models.AccountAuditEntry {
+	var before, after *string
+	if len(entry.Before) > 0 {
+		snapshot := string(entry.Before)
+		before = &snapshot
 	}
+	if len(entry.After) > 0 {
+		snapshot := string(entry.After)
+		after = &snapshot
+	}
+
+	return &models.AccountAuditEntry{
+		ID:        entry.ID.String(),
+		AccountID: entry.AccountID.String(),
+		Action:    entry.Action,
+		Actor:     entry.Actor,
+		Before:    before,
+		After:     after,
+		CreatedAt: entry.CreatedAt.Format(time.RFC3339),
+	}
+}
+
+func ConvertAccountFilterFromModel(filter *models.AccountFilterInput) (account.AccountFilter, error) {
+	var accountFilter account.AccountFilter
+	if filter == nil {
+		return accountFilter, nil
+	}
+
+	if filter.Name != nil {
+		accountFilter.Name = *filter.Name
+	}
+	if filter.Email != nil {
+		accountFilter.Email = *filter.Email
+	}
+	if filter.CreatedAfter != nil {
+		createdAfter, err := time.Parse(time.RFC3339, *filter.CreatedAfter)
+		if err != nil {
+			return account.AccountFilter{}, fmt.Errorf("invalid createdAfter format: %w", err)
+		}
+		accountFilter.CreatedAfter = &createdAfter
+	}
+	if filter.CreatedBefore != nil {
+		createdBefore, err := time.Parse(time.RFC3339, *filter.CreatedBefore)
+		if err != nil {
+			return account.AccountFilter{}, fmt.Errorf("invalid createdBefore format: %w", err)
+		}
+		accountFilter.CreatedBefore = &createdBefore
+	}
+
+	return accountFilter, nil
+}
+
+func ConvertAccountOrderByFromModel(orderBy *models.AccountOrderByInput) account.AccountOrderBy {
+	accountOrderBy := account.AccountOrderBy{Field: account.AccountOrderByCreatedAt}
+	if orderBy == nil {
+		return accountOrderBy
+	}
+
+	switch orderBy.Field {
+	case models.AccountOrderFieldName:
+		accountOrderBy.Field = account.AccountOrderByName
+	case models.AccountOrderFieldEmail:
+		accountOrderBy.Field = account.AccountOrderByEmail
+	}
+	accountOrderBy.Descending = orderBy.Direction != nil && *orderBy.Direction == models.SortDirectionDesc
+
+	return accountOrderBy
 }
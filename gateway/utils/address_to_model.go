@@ -0,0 +1,46 @@
+package utils
+
+import (
+	"graphql-grpc-go-microservice-project/account"
+	"graphql-grpc-go-microservice-project/gateway/models"
+	"strings"
+	"time"
+)
+
+func ConvertAddressToModel(a *account.Address) *models.Address {
+	return &models.Address{
+		ID:         a.ID.String(),
+		Type:       models.AddressType(strings.ToUpper(string(a.Type))),
+		IsDefault:  a.IsDefault,
+		Name:       a.Name,
+		Line1:      a.Line1,
+		Line2:      a.Line2,
+		City:       a.City,
+		Region:     a.Region,
+		PostalCode: a.PostalCode,
+		Country:    a.Country,
+		CreatedAt:  a.CreatedAt.Format(time.RFC3339),
+		UpdatedAt:  a.UpdatedAt.Format(time.RFC3339),
+	}
+}
+
+func ConvertAddressInputFromModel(input models.AddressInput) account.AddressInput {
+	address := account.AddressInput{
+		Type:      account.AddressType(strings.ToLower(string(input.Type))),
+		IsDefault: input.IsDefault,
+		Name:      input.Name,
+		Line1:     input.Line1,
+		City:      input.City,
+		Country:   input.Country,
+	}
+	if input.Line2 != nil {
+		address.Line2 = *input.Line2
+	}
+	if input.Region != nil {
+		address.Region = *input.Region
+	}
+	if input.PostalCode != nil {
+		address.PostalCode = *input.PostalCode
+	}
+	return address
+}
@@ -0,0 +1,58 @@
+package utils
+
+import (
+	"graphql-grpc-go-microservice-project/cart"
+	"graphql-grpc-go-microservice-project/gateway/models"
+	"time"
+)
+
+func ConvertCartToModel(c *cart.Cart) *models.Cart {
+	items := []*models.CartItem{}
+	for _, item := range c.Items {
+		converted := &models.CartItem{
+			ProductID: item.ProductID,
+			Name:      item.Name,
+			Quantity:  int(item.Quantity),
+			Available: item.Available,
+			AddedAt:   item.AddedAt.Format(time.RFC3339),
+		}
+		if item.Available {
+			converted.UnitPrice = ConvertMoneyToModel(item.UnitPrice)
+			converted.Subtotal = ConvertMoneyToModel(item.Subtotal)
+		}
+		items = append(items, converted)
+	}
+
+	totals := []*models.Money{}
+	for _, total := range c.Totals {
+		totals = append(totals, ConvertMoneyToModel(total))
+	}
+
+	model := &models.Cart{
+		Items:  items,
+		Totals: totals,
+	}
+	if c.ID != "" {
+		updatedAt := c.UpdatedAt.Format(time.RFC3339)
+		model.ID = &c.ID
+		model.UpdatedAt = &updatedAt
+	}
+	if c.AccountID != "" {
+		model.AccountID = &c.AccountID
+	}
+	return model
+}
+
+func ConvertCartOwnerFromModel(owner *models.CartOwnerInput) cart.CartOwner {
+	var converted cart.CartOwner
+	if owner == nil {
+		return converted
+	}
+	if owner.AccountID != nil {
+		converted.AccountID = *owner.AccountID
+	}
+	if owner.CartID != nil {
+		converted.CartID = *owner.CartID
+	}
+	return converted
+}
@@ -0,0 +1,46 @@
+package utils
+
+import (
+	"context"
+
+	"github.com/99designs/gqlgen/graphql"
+	"github.com/vektah/gqlparser/v2/gqlerror"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// ReportFieldViolations turns the errdetails.BadRequest attached to an
+// InvalidArgument gRPC error, or a local common.ValidationError, into one
+// GraphQL error per invalid field. It returns nil once the violations have
+// been added to the response, and err unchanged when it carries no field
+// violations.
+func ReportFieldViolations(ctx context.Context, err error) error {
+	st, ok := status.FromError(err)
+	if !ok || st.Code() != codes.InvalidArgument {
+		return err
+	}
+
+	var violations []*errdetails.BadRequest_FieldViolation
+	for _, detail := range st.Details() {
+		if badRequest, ok := detail.(*errdetails.BadRequest); ok {
+			violations = append(violations, badRequest.GetFieldViolations()...)
+		}
+	}
+	if len(violations) == 0 {
+		return err
+	}
+
+	for _, violation := range violations {
+		graphql.AddError(ctx, &gqlerror.Error{
+			Message: violation.GetField() + " " + violation.GetDescription(),
+			Path:    graphql.GetPath(ctx),
+			Extensions: map[string]interface{}{
+				"code":  "BAD_USER_INPUT",
+				"field": violation.GetField(),
+			},
+		})
+	}
+
+	return nil
+}
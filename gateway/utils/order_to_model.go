@@ -0,0 +1,69 @@
+package utils
+
+import (
+	"graphql-grpc-go-microservice-project/cart"
+	"graphql-grpc-go-microservice-project/gateway/models"
+	"graphql-grpc-go-microservice-project/order"
+	"strings"
+	"time"
+)
+
+func ConvertOrderToModel(o *order.Order) *models.Order {
+	items := []*models.OrderItem{}
+	for _, item := range o.Items {
+		model := &models.OrderItem{
+			ProductID: item.ProductID,
+			Name:      item.Name,
+			Quantity:  int(item.Quantity),
+			UnitPrice: ConvertMoneyToModel(item.UnitPrice),
+		}
+		if item.SKU != "" {
+			model.Sku = &item.SKU
+		}
+		items = append(items, model)
+	}
+
+	model := &models.Order{
+		ID:        o.ID,
+		AccountID: o.AccountID,
+		Status:    models.OrderStatus(strings.ToUpper(o.Status)),
+		Items:     items,
+		Total:     ConvertMoneyToModel(o.Total),
+		CreatedAt: o.CreatedAt.Format(time.RFC3339),
+		UpdatedAt: o.UpdatedAt.Format(time.RFC3339),
+	}
+	if o.FailureReason != "" {
+		model.FailureReason = &o.FailureReason
+	}
+	return model
+}
+
+func ConvertCheckoutItemsFromModel(items []*models.CheckoutItemInput) []order.CheckoutItem {
+	converted := make([]order.CheckoutItem, 0, len(items))
+	for _, item := range items {
+		checkoutItem := order.CheckoutItem{
+			ProductID: item.ProductID,
+			Quantity:  int32(item.Quantity),
+		}
+		if item.Sku != nil {
+			checkoutItem.SKU = *item.Sku
+		}
+		converted = append(converted, checkoutItem)
+	}
+	return converted
+}
+
+// ConvertCartToCheckoutItems checks out every available item of a cart.
+func ConvertCartToCheckoutItems(c *cart.Cart) []order.CheckoutItem {
+	converted := make([]order.CheckoutItem, 0, len(c.Items))
+	for _, item := range c.Items {
+		if !item.Available {
+			continue
+		}
+		converted = append(converted, order.CheckoutItem{
+			ProductID: item.ProductID,
+			Quantity:  item.Quantity,
+		})
+	}
+	return converted
+}
@@ -1,15 +1,136 @@
 package utils
 
 import (
+	"errors"
+	"fmt"
+	"graphql-grpc-go-microservice-project/common"
 	"graphql-grpc-go-microservice-project/gateway/models"
 	"graphql-grpc-go-microservice-project/product"
+	"time"
 )
 
 func ConvertProductToModel(product *product.Product) *models.Product {
-	return &models.Product{
+	categories := []*models.Category{}
+	for _, category := range product.Categories {
+		categories = append(categories, ConvertCategoryToModel(&category))
+	}
+
+	variants := []*models.ProductVariant{}
+	for _, variant := range product.Variants {
+		variants = append(variants, ConvertVariantToModel(variant))
+	}
+
+	model := &models.Product{
 		ID:          product.ID,
 		Name:        product.Name,
 		Description: product.Description,
-		Price:       product.Price,
+		Price:       ConvertMoneyToModel(product.Price),
+		Categories:  categories,
+		Variants:    variants,
+		ReviewCount: int(product.ReviewCount),
+	}
+	if product.ReviewCount > 0 {
+		model.AverageRating = &product.AverageRating
+	}
+	return model
+}
+
+func ConvertReviewToModel(review *product.Review) *models.Review {
+	return &models.Review{
+		ProductID: review.ProductID,
+		AccountID: review.AccountID,
+		Rating:    int(review.Rating),
+		Text:      review.Text,
+		CreatedAt: review.CreatedAt.Format(time.RFC3339),
+		UpdatedAt: review.UpdatedAt.Format(time.RFC3339),
+	}
+}
+
+func ConvertVariantToModel(variant product.Variant) *models.ProductVariant {
+	attributes := []*models.ProductAttribute{}
+	for _, attribute := range variant.Attributes {
+		attributes = append(attributes, &models.ProductAttribute{Key: attribute.Key, Value: attribute.Value})
+	}
+
+	return &models.ProductVariant{
+		SKU:        variant.SKU,
+		Price:      ConvertMoneyToModel(variant.Price),
+		Attributes: attributes,
+	}
+}
+
+// ConvertVariantsFromModel parses the decimal prices of every variant,
+// collecting all price violations before returning.
+func ConvertVariantsFromModel(in []*models.ProductVariantInput) ([]product.Variant, error) {
+	violations := &common.ValidationError{}
+	variants := []product.Variant{}
+	for i, variant := range in {
+		price, err := ConvertMoneyFromModel(fmt.Sprintf("variants[%d].price", i), variant.Price)
+		var priceViolations *common.ValidationError
+		if errors.As(err, &priceViolations) {
+			violations.Violations = append(violations.Violations, priceViolations.Violations...)
+		}
+
+		variants = append(variants, product.Variant{
+			SKU:        variant.Sku,
+			Price:      price,
+			Attributes: ConvertAttributesFromModel(variant.Attributes),
+		})
+	}
+	return variants, violations.Err()
+}
+
+func ConvertAttributesFromModel(in []*models.ProductAttributeInput) []product.Attribute {
+	attributes := []product.Attribute{}
+	for _, attribute := range in {
+		attributes = append(attributes, product.Attribute{Key: attribute.Key, Value: attribute.Value})
+	}
+	return attributes
+}
+
+func ConvertCategoryToModel(category *product.Category) *models.Category {
+	var parentID *string
+	if category.ParentID != "" {
+		parentID = &category.ParentID
+	}
+
+	return &models.Category{
+		ID:       category.ID,
+		Name:     category.Name,
+		ParentID: parentID,
+		Path:     category.Path,
+	}
+}
+
+func ConvertMoneyToModel(money common.Money) *models.Money {
+	return &models.Money{
+		Amount:       money.Decimal(),
+		MinorUnits:   money.Amount,
+		CurrencyCode: money.CurrencyCode,
+	}
+}
+
+// ConvertMoneyFromModel parses a decimal MoneyInput, reporting problems as
+// violations of the named input field so they surface like service-side
+// validation errors.
+func ConvertMoneyFromModel(field string, in *models.MoneyInput) (common.Money, error) {
+	violations := &common.ValidationError{}
+	if _, ok := common.CurrencyExponent(in.CurrencyCode); !ok {
+		violations.Add(field+".currencyCode", "must be a supported ISO 4217 currency code")
+		return common.Money{}, violations.Err()
+	}
+
+	money, err := common.ParseMoney(in.Amount, in.CurrencyCode)
+	if err != nil {
+		violations.Add(field+".amount", err.Error())
+		return common.Money{}, violations.Err()
+	}
+	return money, nil
+}
+
+func ConvertProductOrderFieldFromModel(orderBy *models.ProductOrderField) product.ProductOrderField {
+	if orderBy != nil && *orderBy == models.ProductOrderFieldRating {
+		return product.ProductOrderByRating
 	}
+	return product.ProductOrderByRelevance
 }
@@ -0,0 +1,14 @@
+package utils
+
+import (
+	"graphql-grpc-go-microservice-project/gateway/models"
+	"graphql-grpc-go-microservice-project/inventory"
+)
+
+func ConvertStockToModel(stock *inventory.Stock) *models.Stock {
+	return &models.Stock{
+		OnHand:    int(stock.OnHand),
+		Reserved:  int(stock.Reserved),
+		Available: int(stock.Available()),
+	}
+}
@@ -0,0 +1,38 @@
+package utils
+
+import (
+	"graphql-grpc-go-microservice-project/account"
+	"graphql-grpc-go-microservice-project/gateway/models"
+	"graphql-grpc-go-microservice-project/product"
+	"time"
+)
+
+// ConvertWishlistToModel attaches the products found in products to the
+// wishlist items. Items whose product is missing were deleted from the
+// catalog and are flagged as unavailable. A nil products map means the
+// products could not be loaded, and leaves availability unknown.
+func ConvertWishlistToModel(w *account.Wishlist, products map[string]*product.Product) *models.Wishlist {
+	items := []*models.WishlistItem{}
+	for _, item := range w.Items {
+		converted := &models.WishlistItem{
+			ProductID: item.ProductID,
+			AddedAt:   item.AddedAt.Format(time.RFC3339),
+		}
+		if products != nil {
+			p, ok := products[item.ProductID]
+			if ok {
+				converted.Product = ConvertProductToModel(p)
+			}
+			converted.Available = &ok
+		}
+		items = append(items, converted)
+	}
+
+	return &models.Wishlist{
+		ID:        w.ID.String(),
+		Name:      w.Name,
+		Items:     items,
+		CreatedAt: w.CreatedAt.Format(time.RFC3339),
+		UpdatedAt: w.UpdatedAt.Format(time.RFC3339),
+	}
+}
@@ -0,0 +1,191 @@
+package inventory
+
+import (
+	"context"
+	"crypto/tls"
+	"time"
+
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+
+	"graphql-grpc-go-microservice-project/inventory/protobuf"
+
+	"graphql-grpc-go-microservice-project/common"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/credentials/insecure"
+)
+
+type InventoryClient struct {
+	conn    *grpc.ClientConn
+	service protobuf.InventoryServiceClient
+	logger  *zap.Logger
+}
+
+func NewInventoryClient(url string, secure bool) (*InventoryClient, error) {
+	logger := common.GetLogger()
+
+	var opts []grpc.DialOption
+	if secure {
+		creds := credentials.NewTLS(&tls.Config{
+			InsecureSkipVerify: false,
+		})
+		opts = append(opts, grpc.WithTransportCredentials(creds))
+	} else {
+		opts = append(opts, grpc.WithTransportCredentials(insecure.NewCredentials()))
+	}
+
+	opts = append(opts, grpc.WithChainUnaryInterceptor(common.ServiceErrorUnaryInterceptor()))
+
+	conn, err := grpc.NewClient(url, opts...)
+	if err != nil {
+		logger.Error("Failed to connect to gRPC server", zap.String("url", url), zap.String("error", err.Error()))
+		return nil, err
+	}
+
+	logger.Info("Connected to gRPC server", zap.String("url", url))
+
+	client := protobuf.NewInventoryServiceClient(conn)
+
+	return &InventoryClient{conn: conn, service: client, logger: logger}, nil
+}
+
+func (c *InventoryClient) Close() error {
+	c.logger.Info("Closing gRPC connection")
+	return c.conn.Close()
+}
+
+func (c *InventoryClient) GetStock(ctx context.Context, productIDs []string) ([]Stock, error) {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	c.logger.Info("GetStock request received", zap.Strings("product_ids", productIDs))
+
+	r, err := c.service.GetStock(ctx, &protobuf.GetStockRequest{
+		ProductIds: productIDs,
+	})
+	if err != nil {
+		c.logger.Error("Failed to fetch stock", zap.Strings("product_ids", productIDs), zap.String("error", err.Error()))
+		return nil, err
+	}
+
+	stock := make([]Stock, 0, len(r.GetStock()))
+	for _, s := range r.GetStock() {
+		stock = append(stock, stockFromProto(s))
+	}
+
+	c.logger.Info("Stock fetched successfully", zap.Int("count", len(stock)))
+
+	return stock, nil
+}
+
+func (c *InventoryClient) AdjustStock(ctx context.Context, productID string, delta int32) (*Stock, error) {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	c.logger.Info("AdjustStock request received", zap.String("product_id", productID), zap.Int32("delta", delta))
+
+	r, err := c.service.AdjustStock(ctx, &protobuf.AdjustStockRequest{
+		ProductId: productID,
+		Delta:     delta,
+	})
+	if err != nil {
+		c.logger.Error("Failed to adjust stock", zap.String("product_id", productID), zap.String("error", err.Error()))
+		return nil, err
+	}
+
+	c.logger.Info("Stock adjusted successfully", zap.String("product_id", productID), zap.Int32("on_hand", r.GetStock().GetOnHand()))
+
+	stock := stockFromProto(r.GetStock())
+	return &stock, nil
+}
+
+// Reserve holds stock for the items. Calls with the same non-empty
+// idempotencyKey return the reservation made by the first one.
+func (c *InventoryClient) Reserve(ctx context.Context, idempotencyKey string, items []ReservationItem, ttl time.Duration) (*Reservation, error) {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	c.logger.Info("Reserve request received", zap.String("idempotency_key", idempotencyKey), zap.Int("item_count", len(items)), zap.Duration("ttl", ttl))
+
+	protoItems := make([]*protobuf.ReservationItem, 0, len(items))
+	for _, item := range items {
+		protoItems = append(protoItems, &protobuf.ReservationItem{ProductId: item.ProductID, Sku: item.SKU, Quantity: item.Quantity})
+	}
+
+	r, err := c.service.Reserve(ctx, &protobuf.ReserveRequest{
+		Items:          protoItems,
+		TtlSeconds:     uint32(ttl / time.Second),
+		IdempotencyKey: idempotencyKey,
+	})
+	if err != nil {
+		c.logger.Error("Failed to reserve stock", zap.String("error", err.Error()))
+		return nil, err
+	}
+
+	c.logger.Info("Stock reserved successfully", zap.String("reservation_id", r.GetReservation().GetId()))
+
+	return reservationFromProto(r.GetReservation()), nil
+}
+
+func (c *InventoryClient) Commit(ctx context.Context, reservationID string) (*Reservation, error) {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	c.logger.Info("Commit request received", zap.String("reservation_id", reservationID))
+
+	r, err := c.service.Commit(ctx, &protobuf.CommitRequest{
+		ReservationId: reservationID,
+	})
+	if err != nil {
+		c.logger.Error("Failed to commit reservation", zap.String("reservation_id", reservationID), zap.String("error", err.Error()))
+		return nil, err
+	}
+
+	c.logger.Info("Reservation committed successfully", zap.String("reservation_id", reservationID))
+
+	return reservationFromProto(r.GetReservation()), nil
+}
+
+func (c *InventoryClient) Release(ctx context.Context, reservationID string) (*Reservation, error) {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	c.logger.Info("Release request received", zap.String("reservation_id", reservationID))
+
+	r, err := c.service.Release(ctx, &protobuf.ReleaseRequest{
+		ReservationId: reservationID,
+	})
+	if err != nil {
+		c.logger.Error("Failed to release reservation", zap.String("reservation_id", reservationID), zap.String("error", err.Error()))
+		return nil, err
+	}
+
+	c.logger.Info("Reservation released successfully", zap.String("reservation_id", reservationID))
+
+	return reservationFromProto(r.GetReservation()), nil
+}
+
+func stockFromProto(s *protobuf.Stock) Stock {
+	return Stock{
+		ProductID: s.GetProductId(),
+		OnHand:    s.GetOnHand(),
+		Reserved:  s.GetReserved(),
+	}
+}
+
+func reservationFromProto(r *protobuf.Reservation) *Reservation {
+	items := make([]ReservationItem, 0, len(r.GetItems()))
+	for _, item := range r.GetItems() {
+		items = append(items, ReservationItem{ProductID: item.GetProductId(), SKU: item.GetSku(), Quantity: item.GetQuantity()})
+	}
+
+	return &Reservation{
+		ID:        uuid.MustParse(r.GetId()),
+		Status:    r.GetStatus(),
+		Items:     items,
+		ExpiresAt: r.GetExpiresAt().AsTime(),
+		CreatedAt: r.GetCreatedAt().AsTime(),
+	}
+}
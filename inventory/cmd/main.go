@@ -0,0 +1,57 @@
+package main
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"graphql-grpc-go-microservice-project/inventory"
+
+	"github.com/kelseyhightower/envconfig"
+	"github.com/tinrab/retry"
+)
+
+type Config struct {
+	INVENTORY_GRPC_SERVER_PORT           int           `envconfig:"INVENTORY_GRPC_SERVER_PORT" default:"8080"`
+	INVENTORY_DATABASE_URL               string        `envconfig:"INVENTORY_DATABASE_URL"`
+	INVENTORY_RESERVATION_TTL            time.Duration `envconfig:"INVENTORY_RESERVATION_TTL" default:"15m"`
+	INVENTORY_RESERVATION_SWEEP_INTERVAL time.Duration `envconfig:"INVENTORY_RESERVATION_SWEEP_INTERVAL" default:"1m"`
+}
+
+func main() {
+	var cfg Config
+	if err := envconfig.Process("", &cfg); err != nil {
+		log.Fatalf("Error processing environment variables: %v", err)
+	}
+
+	var repo inventory.InventoryRepository
+	retry.ForeverSleep(2*time.Second, func(_ int) error {
+		var err error
+		repo, err = inventory.NewInventoryRepository(cfg.INVENTORY_DATABASE_URL)
+		if err != nil {
+			log.Printf("Database connection failed: %v", err)
+		}
+		return err
+	})
+
+	defer func() {
+		if err := repo.Close(); err != nil {
+			log.Printf("Error closing repository: %v", err)
+		}
+	}()
+
+	log.Println("Initializing inventory service...")
+	service, err := inventory.NewInventoryService(repo, cfg.INVENTORY_RESERVATION_TTL)
+	if err != nil {
+		log.Fatalf("Failed to create inventory service: %v", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go inventory.RunReservationSweeper(ctx, service, cfg.INVENTORY_RESERVATION_SWEEP_INTERVAL)
+
+	log.Printf("Starting gRPC server on port %d...", cfg.INVENTORY_GRPC_SERVER_PORT)
+	if err := inventory.ListenGRPC(service, cfg.INVENTORY_GRPC_SERVER_PORT, false); err != nil {
+		log.Fatalf("Failed to start gRPC server: %v", err)
+	}
+}
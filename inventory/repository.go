@@ -0,0 +1,394 @@
+package inventory
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+type InventoryRepository interface {
+	Close() error
+	GetStock(ctx context.Context, productIDs []string) ([]Stock, error)
+	AdjustStock(ctx context.Context, productID string, delta int32) (Stock, error)
+	Reserve(ctx context.Context, idempotencyKey string, items []ReservationItem, ttl time.Duration) (Reservation, error)
+	Commit(ctx context.Context, id string) (Reservation, error)
+	Release(ctx context.Context, id string) (Reservation, error)
+	ListExpiredReservations(ctx context.Context, limit int) ([]string, error)
+	ExpireReservation(ctx context.Context, id string) (Reservation, error)
+}
+
+type inventoryRepository struct {
+	db *pgxpool.Pool
+}
+
+func NewInventoryRepository(connString string) (InventoryRepository, error) {
+	config, err := pgxpool.ParseConfig(connString)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse database connection string: %w", err)
+	}
+
+	config.MaxConns = 25
+	config.MaxConnIdleTime = 5 * time.Minute
+
+	db, err := pgxpool.NewWithConfig(context.Background(), config)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create connection pool: %w", err)
+	}
+
+	if err := db.Ping(context.Background()); err != nil {
+		return nil, fmt.Errorf("failed to ping database: %w", err)
+	}
+
+	return &inventoryRepository{db}, nil
+}
+
+func (repository *inventoryRepository) Close() error {
+	repository.db.Close()
+	return nil
+}
+
+func (repository *inventoryRepository) GetStock(ctx context.Context, productIDs []string) ([]Stock, error) {
+	query := `
+        SELECT product_id, on_hand, reserved, updated_at
+        FROM stock
+        WHERE product_id = ANY($1)`
+	rows, err := repository.db.Query(ctx, query, productIDs)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get stock: %w", err)
+	}
+	defer rows.Close()
+
+	stock := []Stock{}
+	for rows.Next() {
+		var s Stock
+		if err := rows.Scan(&s.ProductID, &s.OnHand, &s.Reserved, &s.UpdatedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan stock: %w", err)
+		}
+		stock = append(stock, s)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating over stock rows: %w", err)
+	}
+
+	return stock, nil
+}
+
+func (repository *inventoryRepository) AdjustStock(ctx context.Context, productID string, delta int32) (Stock, error) {
+	tx, err := repository.db.Begin(ctx)
+	if err != nil {
+		return Stock{}, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	if _, err := tx.Exec(ctx, `INSERT INTO stock (product_id) VALUES ($1) ON CONFLICT (product_id) DO NOTHING`, productID); err != nil {
+		return Stock{}, fmt.Errorf("failed to create stock: %w", err)
+	}
+
+	locked, err := lockStock(ctx, tx, []string{productID})
+	if err != nil {
+		return Stock{}, err
+	}
+
+	current := locked[productID]
+	if current.OnHand+delta < current.Reserved {
+		return Stock{}, fmt.Errorf("%w: product %s has %d on hand and %d reserved", ErrInsufficientStock, productID, current.OnHand, current.Reserved)
+	}
+
+	var stock Stock
+	query := `
+        UPDATE stock
+        SET on_hand = on_hand + $2
+        WHERE product_id = $1
+        RETURNING product_id, on_hand, reserved, updated_at`
+	err = tx.QueryRow(ctx, query, productID, delta).Scan(&stock.ProductID, &stock.OnHand, &stock.Reserved, &stock.UpdatedAt)
+	if err != nil {
+		return Stock{}, fmt.Errorf("failed to adjust stock: %w", err)
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return Stock{}, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return stock, nil
+}
+
+// Reserve holds the requested quantities until the reservation is committed,
+// released or expires. Either every item is reserved or none is. When a
+// reservation with the same idempotencyKey exists it is returned as is,
+// whatever its status, and no stock is reserved.
+func (repository *inventoryRepository) Reserve(ctx context.Context, idempotencyKey string, items []ReservationItem, ttl time.Duration) (Reservation, error) {
+	tx, err := repository.db.Begin(ctx)
+	if err != nil {
+		return Reservation{}, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	if idempotencyKey != "" {
+		// Serializes concurrent calls with the same key until commit.
+		if _, err := tx.Exec(ctx, `SELECT pg_advisory_xact_lock(hashtext($1))`, idempotencyKey); err != nil {
+			return Reservation{}, fmt.Errorf("failed to lock idempotency key: %w", err)
+		}
+
+		existing, found, err := reservationByIdempotencyKey(ctx, tx, idempotencyKey)
+		if err != nil || found {
+			return existing, err
+		}
+	}
+
+	// Items are sorted by product, and variants of a product draw from the
+	// same stock.
+	productIDs := []string{}
+	requested := map[string]int32{}
+	for _, item := range items {
+		if _, ok := requested[item.ProductID]; !ok {
+			productIDs = append(productIDs, item.ProductID)
+		}
+		requested[item.ProductID] += item.Quantity
+	}
+
+	locked, err := lockStock(ctx, tx, productIDs)
+	if err != nil {
+		return Reservation{}, err
+	}
+
+	var shortages []string
+	for _, productID := range productIDs {
+		available := locked[productID].Available()
+		if available < requested[productID] {
+			shortages = append(shortages, fmt.Sprintf("product %s has %d available, %d requested", productID, available, requested[productID]))
+		}
+	}
+	if len(shortages) > 0 {
+		return Reservation{}, fmt.Errorf("%w: %s", ErrInsufficientStock, strings.Join(shortages, "; "))
+	}
+
+	for _, productID := range productIDs {
+		if _, err := tx.Exec(ctx, `UPDATE stock SET reserved = reserved + $2 WHERE product_id = $1`, productID, requested[productID]); err != nil {
+			return Reservation{}, fmt.Errorf("failed to reserve stock: %w", err)
+		}
+	}
+
+	reservation := Reservation{Status: ReservationStatusPending, Items: items}
+	query := `
+        INSERT INTO reservations (status, expires_at, idempotency_key)
+        VALUES ($1, CURRENT_TIMESTAMP + make_interval(secs => $2), NULLIF($3, ''))
+        RETURNING id, expires_at, created_at`
+	err = tx.QueryRow(ctx, query, ReservationStatusPending, ttl.Seconds(), idempotencyKey).Scan(&reservation.ID, &reservation.ExpiresAt, &reservation.CreatedAt)
+	if err != nil {
+		return Reservation{}, fmt.Errorf("failed to create reservation: %w", err)
+	}
+
+	for _, item := range items {
+		_, err := tx.Exec(ctx, `INSERT INTO reservation_items (reservation_id, product_id, sku, quantity) VALUES ($1, $2, $3, $4)`, reservation.ID, item.ProductID, item.SKU, item.Quantity)
+		if err != nil {
+			return Reservation{}, fmt.Errorf("failed to create reservation item: %w", err)
+		}
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return Reservation{}, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return reservation, nil
+}
+
+// Commit turns the reserved quantities into a permanent deduction of stock.
+// A reservation that has already expired is released instead and reported as
+// no longer pending.
+func (repository *inventoryRepository) Commit(ctx context.Context, id string) (Reservation, error) {
+	return repository.finishReservation(ctx, id, ReservationStatusCommitted)
+}
+
+func (repository *inventoryRepository) Release(ctx context.Context, id string) (Reservation, error) {
+	return repository.finishReservation(ctx, id, ReservationStatusReleased)
+}
+
+func (repository *inventoryRepository) ExpireReservation(ctx context.Context, id string) (Reservation, error) {
+	return repository.finishReservation(ctx, id, ReservationStatusExpired)
+}
+
+func (repository *inventoryRepository) ListExpiredReservations(ctx context.Context, limit int) ([]string, error) {
+	query := `
+        SELECT id
+        FROM reservations
+        WHERE status = $1 AND expires_at < CURRENT_TIMESTAMP
+        ORDER BY expires_at
+        LIMIT $2`
+	rows, err := repository.db.Query(ctx, query, ReservationStatusPending, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list expired reservations: %w", err)
+	}
+	defer rows.Close()
+
+	ids := []string{}
+	for rows.Next() {
+		var id string
+		if err := rows.Scan(&id); err != nil {
+			return nil, fmt.Errorf("failed to scan reservation: %w", err)
+		}
+		ids = append(ids, id)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating over reservation rows: %w", err)
+	}
+
+	return ids, nil
+}
+
+func (repository *inventoryRepository) finishReservation(ctx context.Context, id, status string) (Reservation, error) {
+	tx, err := repository.db.Begin(ctx)
+	if err != nil {
+		return Reservation{}, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	var reservation Reservation
+	var expired bool
+	query := `
+        SELECT id, status, expires_at, created_at, expires_at < CURRENT_TIMESTAMP
+        FROM reservations
+        WHERE id = $1
+        FOR UPDATE`
+	err = tx.QueryRow(ctx, query, id).Scan(&reservation.ID, &reservation.Status, &reservation.ExpiresAt, &reservation.CreatedAt, &expired)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return Reservation{}, ErrReservationNotFound
+	}
+	if err != nil {
+		return Reservation{}, fmt.Errorf("failed to get reservation: %w", err)
+	}
+
+	if reservation.Status != ReservationStatusPending {
+		return Reservation{}, fmt.Errorf("%w: reservation is %s", ErrReservationNotPending, reservation.Status)
+	}
+
+	target := status
+	if status == ReservationStatusCommitted && expired {
+		target = ReservationStatusExpired
+	}
+
+	reservation.Items, err = reservationItems(ctx, tx, id)
+	if err != nil {
+		return Reservation{}, err
+	}
+
+	productIDs := make([]string, 0, len(reservation.Items))
+	for _, item := range reservation.Items {
+		productIDs = append(productIDs, item.ProductID)
+	}
+	if _, err := lockStock(ctx, tx, productIDs); err != nil {
+		return Reservation{}, err
+	}
+
+	update := `UPDATE stock SET reserved = reserved - $2 WHERE product_id = $1`
+	if target == ReservationStatusCommitted {
+		update = `UPDATE stock SET reserved = reserved - $2, on_hand = on_hand - $2 WHERE product_id = $1`
+	}
+	for _, item := range reservation.Items {
+		if _, err := tx.Exec(ctx, update, item.ProductID, item.Quantity); err != nil {
+			return Reservation{}, fmt.Errorf("failed to update stock: %w", err)
+		}
+	}
+
+	if _, err := tx.Exec(ctx, `UPDATE reservations SET status = $2 WHERE id = $1`, id, target); err != nil {
+		return Reservation{}, fmt.Errorf("failed to update reservation: %w", err)
+	}
+	reservation.Status = target
+
+	if err := tx.Commit(ctx); err != nil {
+		return Reservation{}, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	if target != status {
+		return Reservation{}, fmt.Errorf("%w: reservation is %s", ErrReservationNotPending, target)
+	}
+
+	return reservation, nil
+}
+
+func reservationByIdempotencyKey(ctx context.Context, tx pgx.Tx, idempotencyKey string) (Reservation, bool, error) {
+	var reservation Reservation
+	query := `
+        SELECT id, status, expires_at, created_at
+        FROM reservations
+        WHERE idempotency_key = $1`
+	err := tx.QueryRow(ctx, query, idempotencyKey).Scan(&reservation.ID, &reservation.Status, &reservation.ExpiresAt, &reservation.CreatedAt)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return Reservation{}, false, nil
+	}
+	if err != nil {
+		return Reservation{}, false, fmt.Errorf("failed to get reservation: %w", err)
+	}
+
+	reservation.Items, err = reservationItems(ctx, tx, reservation.ID.String())
+	if err != nil {
+		return Reservation{}, false, err
+	}
+
+	return reservation, true, nil
+}
+
+// lockStock locks the stock rows of the given products in a fixed order, so
+// that concurrent reservations over the same products cannot deadlock.
+func lockStock(ctx context.Context, tx pgx.Tx, productIDs []string) (map[string]Stock, error) {
+	query := `
+        SELECT product_id, on_hand, reserved, updated_at
+        FROM stock
+        WHERE product_id = ANY($1)
+        ORDER BY product_id
+        FOR UPDATE`
+	rows, err := tx.Query(ctx, query, productIDs)
+	if err != nil {
+		return nil, fmt.Errorf("failed to lock stock: %w", err)
+	}
+	defer rows.Close()
+
+	locked := map[string]Stock{}
+	for rows.Next() {
+		var s Stock
+		if err := rows.Scan(&s.ProductID, &s.OnHand, &s.Reserved, &s.UpdatedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan stock: %w", err)
+		}
+		locked[s.ProductID] = s
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating over stock rows: %w", err)
+	}
+
+	return locked, nil
+}
+
+func reservationItems(ctx context.Context, tx pgx.Tx, reservationID string) ([]ReservationItem, error) {
+	query := `
+        SELECT product_id, sku, quantity
+        FROM reservation_items
+        WHERE reservation_id = $1
+        ORDER BY product_id, sku`
+	rows, err := tx.Query(ctx, query, reservationID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get reservation items: %w", err)
+	}
+	defer rows.Close()
+
+	items := []ReservationItem{}
+	for rows.Next() {
+		var item ReservationItem
+		if err := rows.Scan(&item.ProductID, &item.SKU, &item.Quantity); err != nil {
+			return nil, fmt.Errorf("failed to scan reservation item: %w", err)
+		}
+		items = append(items, item)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating over reservation item rows: %w", err)
+	}
+
+	return items, nil
+}
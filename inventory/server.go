@@ -0,0 +1,225 @@
+package inventory
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"graphql-grpc-go-microservice-project/common"
+	"graphql-grpc-go-microservice-project/inventory/protobuf"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/keepalive"
+	"google.golang.org/grpc/reflection"
+	"google.golang.org/protobuf/types/known/timestamppb"
+
+	"go.uber.org/zap"
+	grpcResponseCodes "google.golang.org/grpc/codes"
+	grpcResponseStatus "google.golang.org/grpc/status"
+)
+
+type inventoryGrpcServer struct {
+	protobuf.UnimplementedInventoryServiceServer
+	service InventoryService
+	logger  *zap.Logger
+}
+
+func ListenGRPC(s InventoryService, port int, secure bool) error {
+	logger := common.GetLogger()
+
+	lis, err := net.Listen("tcp", fmt.Sprintf(":%d", port))
+	if err != nil {
+		return fmt.Errorf("failed to listen on port %d: %v", port, err)
+	}
+
+	var opts []grpc.ServerOption
+	keepAliveParams := keepalive.ServerParameters{
+		Time:    5 * time.Minute,
+		Timeout: 20 * time.Second,
+	}
+
+	opts = append(opts, grpc.KeepaliveParams(keepAliveParams))
+
+	if secure {
+		creds := credentials.NewTLS(&tls.Config{
+			InsecureSkipVerify: false,
+		})
+		opts = append(opts, grpc.Creds(creds))
+	} else {
+		opts = append(opts, grpc.Creds(insecure.NewCredentials()))
+	}
+
+	serv := grpc.NewServer(opts...)
+	inventoryServer := &inventoryGrpcServer{
+		UnimplementedInventoryServiceServer: protobuf.UnimplementedInventoryServiceServer{},
+		service:                             s,
+		logger:                              logger,
+	}
+	protobuf.RegisterInventoryServiceServer(serv, inventoryServer)
+	reflection.Register(serv)
+
+	errChan := make(chan error)
+	go func() {
+		if err := serv.Serve(lis); err != nil {
+			errChan <- fmt.Errorf("failed to serve gRPC server: %v", err)
+		}
+	}()
+
+	signalChan := make(chan os.Signal, 1)
+	signal.Notify(signalChan, os.Interrupt, syscall.SIGTERM)
+
+	select {
+	case sig := <-signalChan:
+		logger.Info("Received signal, shutting down gRPC server", zap.String("signal", sig.String()))
+		serv.GracefulStop()
+	case err := <-errChan:
+		return err
+	}
+
+	return nil
+}
+
+func (s *inventoryGrpcServer) GetStock(ctx context.Context, r *protobuf.GetStockRequest) (*protobuf.GetStockResponse, error) {
+	s.logger.Info("GetStock request received", zap.Strings("product_ids", r.ProductIds))
+
+	stock, err := s.service.GetStock(ctx, r.ProductIds)
+	if err != nil {
+		s.logger.Error("Failed to get stock", zap.Strings("product_ids", r.ProductIds), zap.String("error", err.Error()))
+		return &protobuf.GetStockResponse{
+			Error: err.Error(),
+		}, inventoryError(err)
+	}
+
+	var protoStock []*protobuf.Stock
+	for _, st := range stock {
+		protoStock = append(protoStock, stockToProto(st))
+	}
+
+	s.logger.Info("Stock fetched successfully", zap.Int("count", len(stock)))
+	return &protobuf.GetStockResponse{Stock: protoStock}, nil
+}
+
+func (s *inventoryGrpcServer) AdjustStock(ctx context.Context, r *protobuf.AdjustStockRequest) (*protobuf.AdjustStockResponse, error) {
+	s.logger.Info("AdjustStock request received", zap.String("product_id", r.ProductId), zap.Int32("delta", r.Delta))
+
+	stock, err := s.service.AdjustStock(ctx, r.ProductId, r.Delta)
+	if err != nil {
+		s.logger.Error("Failed to adjust stock", zap.String("product_id", r.ProductId), zap.String("error", err.Error()))
+		return &protobuf.AdjustStockResponse{
+			Result: &protobuf.AdjustStockResponse_Error{Error: err.Error()},
+		}, inventoryError(err)
+	}
+
+	s.logger.Info("Stock adjusted successfully", zap.String("product_id", stock.ProductID), zap.Int32("on_hand", stock.OnHand), zap.Int32("reserved", stock.Reserved))
+
+	return &protobuf.AdjustStockResponse{
+		Result: &protobuf.AdjustStockResponse_Stock{Stock: stockToProto(*stock)},
+	}, nil
+}
+
+func (s *inventoryGrpcServer) Reserve(ctx context.Context, r *protobuf.ReserveRequest) (*protobuf.ReserveResponse, error) {
+	s.logger.Info("Reserve request received", zap.String("idempotency_key", r.IdempotencyKey), zap.Int("item_count", len(r.Items)), zap.Uint32("ttl_seconds", r.TtlSeconds))
+
+	reservation, err := s.service.Reserve(ctx, r.IdempotencyKey, reservationItemsFromProto(r.Items), time.Duration(r.TtlSeconds)*time.Second)
+	if err != nil {
+		s.logger.Error("Failed to reserve stock", zap.String("error", err.Error()))
+		return &protobuf.ReserveResponse{
+			Result: &protobuf.ReserveResponse_Error{Error: err.Error()},
+		}, inventoryError(err)
+	}
+
+	s.logger.Info("Stock reserved successfully", zap.String("reservation_id", reservation.ID.String()), zap.Time("expires_at", reservation.ExpiresAt))
+
+	return &protobuf.ReserveResponse{
+		Result: &protobuf.ReserveResponse_Reservation{Reservation: reservationToProto(reservation)},
+	}, nil
+}
+
+func (s *inventoryGrpcServer) Commit(ctx context.Context, r *protobuf.CommitRequest) (*protobuf.CommitResponse, error) {
+	s.logger.Info("Commit request received", zap.String("reservation_id", r.ReservationId))
+
+	reservation, err := s.service.Commit(ctx, r.ReservationId)
+	if err != nil {
+		s.logger.Error("Failed to commit reservation", zap.String("reservation_id", r.ReservationId), zap.String("error", err.Error()))
+		return &protobuf.CommitResponse{
+			Result: &protobuf.CommitResponse_Error{Error: err.Error()},
+		}, inventoryError(err)
+	}
+
+	s.logger.Info("Reservation committed successfully", zap.String("reservation_id", r.ReservationId))
+
+	return &protobuf.CommitResponse{
+		Result: &protobuf.CommitResponse_Reservation{Reservation: reservationToProto(reservation)},
+	}, nil
+}
+
+func (s *inventoryGrpcServer) Release(ctx context.Context, r *protobuf.ReleaseRequest) (*protobuf.ReleaseResponse, error) {
+	s.logger.Info("Release request received", zap.String("reservation_id", r.ReservationId))
+
+	reservation, err := s.service.Release(ctx, r.ReservationId)
+	if err != nil {
+		s.logger.Error("Failed to release reservation", zap.String("reservation_id", r.ReservationId), zap.String("error", err.Error()))
+		return &protobuf.ReleaseResponse{
+			Result: &protobuf.ReleaseResponse_Error{Error: err.Error()},
+		}, inventoryError(err)
+	}
+
+	s.logger.Info("Reservation released successfully", zap.String("reservation_id", r.ReservationId))
+
+	return &protobuf.ReleaseResponse{
+		Result: &protobuf.ReleaseResponse_Reservation{Reservation: reservationToProto(reservation)},
+	}, nil
+}
+
+func inventoryError(err error) error {
+	var validationErr *common.ValidationError
+	switch {
+	case errors.As(err, &validationErr):
+		return validationErr.GRPCStatus().Err()
+	case errors.Is(err, ErrReservationNotFound):
+		return grpcResponseStatus.Errorf(grpcResponseCodes.NotFound, err.Error())
+	case errors.Is(err, ErrInsufficientStock), errors.Is(err, ErrReservationNotPending):
+		return grpcResponseStatus.Errorf(grpcResponseCodes.FailedPrecondition, err.Error())
+	}
+	return grpcResponseStatus.Errorf(grpcResponseCodes.Internal, err.Error())
+}
+
+func stockToProto(s Stock) *protobuf.Stock {
+	return &protobuf.Stock{
+		ProductId: s.ProductID,
+		OnHand:    s.OnHand,
+		Reserved:  s.Reserved,
+		Available: s.Available(),
+	}
+}
+
+func reservationToProto(r *Reservation) *protobuf.Reservation {
+	items := make([]*protobuf.ReservationItem, 0, len(r.Items))
+	for _, item := range r.Items {
+		items = append(items, &protobuf.ReservationItem{ProductId: item.ProductID, Sku: item.SKU, Quantity: item.Quantity})
+	}
+
+	return &protobuf.Reservation{
+		Id:        r.ID.String(),
+		Status:    r.Status,
+		Items:     items,
+		ExpiresAt: timestamppb.New(r.ExpiresAt),
+		CreatedAt: timestamppb.New(r.CreatedAt),
+	}
+}
+
+func reservationItemsFromProto(items []*protobuf.ReservationItem) []ReservationItem {
+	converted := make([]ReservationItem, 0, len(items))
+	for _, item := range items {
+		converted = append(converted, ReservationItem{ProductID: item.GetProductId(), SKU: item.GetSku(), Quantity: item.GetQuantity()})
+	}
+	return converted
+}
@@ -0,0 +1,169 @@
+package inventory
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"graphql-grpc-go-microservice-project/common"
+
+	"go.uber.org/zap"
+)
+
+// expiredReservationBatchSize bounds how many expired reservations a single
+// sweep releases.
+const expiredReservationBatchSize = 100
+
+type InventoryService interface {
+	GetStock(ctx context.Context, productIDs []string) ([]Stock, error)
+	AdjustStock(ctx context.Context, productID string, delta int32) (*Stock, error)
+	Reserve(ctx context.Context, idempotencyKey string, items []ReservationItem, ttl time.Duration) (*Reservation, error)
+	Commit(ctx context.Context, reservationID string) (*Reservation, error)
+	Release(ctx context.Context, reservationID string) (*Reservation, error)
+	ReleaseExpiredReservations(ctx context.Context) (int, error)
+}
+
+type inventoryService struct {
+	repository     InventoryRepository
+	reservationTTL time.Duration
+}
+
+func NewInventoryService(repository InventoryRepository, reservationTTL time.Duration) (InventoryService, error) {
+	return &inventoryService{repository: repository, reservationTTL: reservationTTL}, nil
+}
+
+// GetStock returns one entry per requested product, in request order.
+// Products that have never been stocked are reported with zero units.
+func (service *inventoryService) GetStock(ctx context.Context, productIDs []string) ([]Stock, error) {
+	if err := validateStockLookup(productIDs); err != nil {
+		return nil, err
+	}
+
+	stored, err := service.repository.GetStock(ctx, productIDs)
+	if err != nil {
+		return nil, err
+	}
+
+	byProduct := make(map[string]Stock, len(stored))
+	for _, s := range stored {
+		byProduct[s.ProductID] = s
+	}
+
+	stock := make([]Stock, 0, len(productIDs))
+	for _, productID := range productIDs {
+		s, ok := byProduct[productID]
+		if !ok {
+			s = Stock{ProductID: productID}
+		}
+		stock = append(stock, s)
+	}
+	return stock, nil
+}
+
+func (service *inventoryService) AdjustStock(ctx context.Context, productID string, delta int32) (*Stock, error) {
+	productID, err := validateAdjustment(productID, delta)
+	if err != nil {
+		return nil, err
+	}
+
+	stock, err := service.repository.AdjustStock(ctx, productID, delta)
+	if err != nil {
+		return nil, err
+	}
+	return &stock, nil
+}
+
+func (service *inventoryService) Reserve(ctx context.Context, idempotencyKey string, items []ReservationItem, ttl time.Duration) (*Reservation, error) {
+	idempotencyKey, err := validateIdempotencyKey(idempotencyKey)
+	if err != nil {
+		return nil, err
+	}
+
+	items, err = validateReservationItems(items)
+	if err != nil {
+		return nil, err
+	}
+
+	ttl, err = validateReservationTTL(ttl, service.reservationTTL)
+	if err != nil {
+		return nil, err
+	}
+
+	reservation, err := service.repository.Reserve(ctx, idempotencyKey, items, ttl)
+	if err != nil {
+		return nil, err
+	}
+	return &reservation, nil
+}
+
+func (service *inventoryService) Commit(ctx context.Context, reservationID string) (*Reservation, error) {
+	if err := validateReservationID(reservationID); err != nil {
+		return nil, err
+	}
+
+	reservation, err := service.repository.Commit(ctx, reservationID)
+	if err != nil {
+		return nil, err
+	}
+	return &reservation, nil
+}
+
+func (service *inventoryService) Release(ctx context.Context, reservationID string) (*Reservation, error) {
+	if err := validateReservationID(reservationID); err != nil {
+		return nil, err
+	}
+
+	reservation, err := service.repository.Release(ctx, reservationID)
+	if err != nil {
+		return nil, err
+	}
+	return &reservation, nil
+}
+
+// ReleaseExpiredReservations returns the stock held by pending reservations
+// whose TTL has passed, and reports how many were released.
+func (service *inventoryService) ReleaseExpiredReservations(ctx context.Context) (int, error) {
+	ids, err := service.repository.ListExpiredReservations(ctx, expiredReservationBatchSize)
+	if err != nil {
+		return 0, err
+	}
+
+	released := 0
+	for _, id := range ids {
+		_, err := service.repository.ExpireReservation(ctx, id)
+		switch {
+		case err == nil:
+			released++
+		case errors.Is(err, ErrReservationNotPending):
+			// Committed or released concurrently.
+		default:
+			return released, err
+		}
+	}
+	return released, nil
+}
+
+// RunReservationSweeper releases expired reservations every interval until
+// ctx is cancelled.
+func RunReservationSweeper(ctx context.Context, service InventoryService, interval time.Duration) {
+	logger := common.GetLogger()
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			released, err := service.ReleaseExpiredReservations(ctx)
+			if err != nil {
+				logger.Error("Failed to release expired reservations", zap.String("error", err.Error()))
+				continue
+			}
+			if released > 0 {
+				logger.Info("Released expired reservations", zap.Int("count", released))
+			}
+		}
+	}
+}
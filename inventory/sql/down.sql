@@ -0,0 +1,4 @@
+DROP TABLE IF EXISTS reservation_items;
+DROP TABLE IF EXISTS reservations;
+DROP TABLE IF EXISTS stock;
+DROP FUNCTION IF EXISTS update_updated_at_column;
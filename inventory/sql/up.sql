@@ -0,0 +1,47 @@
+CREATE EXTENSION IF NOT EXISTS "uuid-ossp";
+
+CREATE OR REPLACE FUNCTION update_updated_at_column()
+RETURNS TRIGGER AS $$
+BEGIN
+    NEW.updated_at = CURRENT_TIMESTAMP;
+    RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TABLE stock (
+    product_id VARCHAR(64) PRIMARY KEY,
+    on_hand INTEGER NOT NULL DEFAULT 0 CHECK (on_hand >= 0),
+    reserved INTEGER NOT NULL DEFAULT 0 CHECK (reserved >= 0),
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    CHECK (reserved <= on_hand)
+);
+
+CREATE TRIGGER set_updated_at BEFORE
+UPDATE ON stock FOR EACH ROW
+EXECUTE FUNCTION update_updated_at_column ();
+
+CREATE TABLE reservations (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4 (),
+    status VARCHAR(16) NOT NULL DEFAULT 'pending',
+    expires_at TIMESTAMP NOT NULL,
+    idempotency_key VARCHAR(255) UNIQUE,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE TRIGGER set_updated_at BEFORE
+UPDATE ON reservations FOR EACH ROW
+EXECUTE FUNCTION update_updated_at_column ();
+
+CREATE INDEX idx_reservations_pending_expires_at ON reservations (expires_at)
+WHERE
+    status = 'pending';
+
+CREATE TABLE reservation_items (
+    reservation_id UUID NOT NULL REFERENCES reservations (id),
+    product_id VARCHAR(64) NOT NULL REFERENCES stock (product_id),
+    -- sku is empty for products without variants.
+    sku VARCHAR(64) NOT NULL DEFAULT '',
+    quantity INTEGER NOT NULL CHECK (quantity > 0),
+    PRIMARY KEY (reservation_id, product_id, sku)
+);
@@ -0,0 +1,50 @@
+package inventory
+
+import (
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+var (
+	ErrInsufficientStock     = errors.New("insufficient stock")
+	ErrReservationNotFound   = errors.New("reservation not found")
+	ErrReservationNotPending = errors.New("reservation is no longer pending")
+)
+
+// Stock is the inventory of a single product. Reserved units are held by
+// pending reservations and are no longer available to new ones.
+type Stock struct {
+	ProductID string    `json:"product_id"`
+	OnHand    int32     `json:"on_hand"`
+	Reserved  int32     `json:"reserved"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+func (s Stock) Available() int32 {
+	return s.OnHand - s.Reserved
+}
+
+const (
+	ReservationStatusPending   = "pending"
+	ReservationStatusCommitted = "committed"
+	ReservationStatusReleased  = "released"
+	ReservationStatusExpired   = "expired"
+)
+
+// ReservationItem holds units of a product. Stock is counted per product, so
+// SKU only records which variant the units were reserved for.
+type ReservationItem struct {
+	ProductID string `json:"product_id"`
+	SKU       string `json:"sku,omitempty"`
+	Quantity  int32  `json:"quantity"`
+}
+
+type Reservation struct {
+	ID        uuid.UUID         `json:"id"`
+	Status    string            `json:"status"`
+	Items     []ReservationItem `json:"items"`
+	ExpiresAt time.Time         `json:"expires_at"`
+	CreatedAt time.Time         `json:"created_at"`
+}
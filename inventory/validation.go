@@ -0,0 +1,121 @@
+package inventory
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"graphql-grpc-go-microservice-project/common"
+
+	"github.com/google/uuid"
+)
+
+// maxProductIDLength matches the VARCHAR(64) product_id columns.
+const maxProductIDLength = 64
+
+// maxSKULength matches the VARCHAR(64) sku column.
+const maxSKULength = 64
+
+// maxIdempotencyKeyLength matches the VARCHAR(255) idempotency_key column.
+const maxIdempotencyKeyLength = 255
+
+const maxReservationTTL = time.Hour
+
+// maxStockLookup bounds how many products a single GetStock call may ask for.
+const maxStockLookup = 100
+
+func validateProductID(violations *common.ValidationError, field, productID string) string {
+	productID = strings.TrimSpace(productID)
+	switch {
+	case productID == "":
+		violations.Add(field, "must not be empty")
+	case len(productID) > maxProductIDLength:
+		violations.Add(field, "must be at most 64 characters")
+	}
+	return productID
+}
+
+func validateStockLookup(productIDs []string) error {
+	violations := &common.ValidationError{}
+	if len(productIDs) > maxStockLookup {
+		violations.Add("productIds", "must contain at most 100 products")
+	}
+	return violations.Err()
+}
+
+func validateAdjustment(productID string, delta int32) (string, error) {
+	violations := &common.ValidationError{}
+	productID = validateProductID(violations, "productId", productID)
+	if delta == 0 {
+		violations.Add("delta", "must not be zero")
+	}
+	return productID, violations.Err()
+}
+
+// validateReservationItems merges repeated variants of a product into a
+// single item and sorts the result by product, which is also the order the
+// rows are locked in.
+func validateReservationItems(items []ReservationItem) ([]ReservationItem, error) {
+	violations := &common.ValidationError{}
+	if len(items) == 0 {
+		violations.Add("items", "must not be empty")
+	}
+
+	quantities := map[ReservationItem]int32{}
+	for i, item := range items {
+		productID := validateProductID(violations, fmt.Sprintf("items[%d].productId", i), item.ProductID)
+		sku := strings.TrimSpace(item.SKU)
+		if len(sku) > maxSKULength {
+			violations.Add(fmt.Sprintf("items[%d].sku", i), "must be at most 64 characters")
+		}
+		if item.Quantity <= 0 {
+			violations.Add(fmt.Sprintf("items[%d].quantity", i), "must be greater than 0")
+			continue
+		}
+		quantities[ReservationItem{ProductID: productID, SKU: sku}] += item.Quantity
+	}
+
+	merged := make([]ReservationItem, 0, len(quantities))
+	for key, quantity := range quantities {
+		merged = append(merged, ReservationItem{ProductID: key.ProductID, SKU: key.SKU, Quantity: quantity})
+	}
+	sort.Slice(merged, func(i, j int) bool {
+		if merged[i].ProductID != merged[j].ProductID {
+			return merged[i].ProductID < merged[j].ProductID
+		}
+		return merged[i].SKU < merged[j].SKU
+	})
+
+	return merged, violations.Err()
+}
+
+func validateIdempotencyKey(key string) (string, error) {
+	key = strings.TrimSpace(key)
+	violations := &common.ValidationError{}
+	if len(key) > maxIdempotencyKeyLength {
+		violations.Add("idempotencyKey", "must be at most 255 characters")
+	}
+	return key, violations.Err()
+}
+
+func validateReservationTTL(ttl, defaultTTL time.Duration) (time.Duration, error) {
+	if ttl == 0 {
+		return defaultTTL, nil
+	}
+
+	violations := &common.ValidationError{}
+	if ttl < time.Second || ttl > maxReservationTTL {
+		violations.Add("ttlSeconds", "must be between 1 and 3600")
+	}
+	return ttl, violations.Err()
+}
+
+func validateReservationID(id string) error {
+	if _, err := uuid.Parse(id); err != nil {
+		violations := &common.ValidationError{}
+		violations.Add("reservationId", "must be a valid UUID")
+		return violations.Err()
+	}
+	return nil
+}
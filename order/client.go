@@ -0,0 +1,127 @@
+package order
+
+import (
+	"context"
+	"crypto/tls"
+	"time"
+
+	"go.uber.org/zap"
+
+	"graphql-grpc-go-microservice-project/order/protobuf"
+
+	"graphql-grpc-go-microservice-project/common"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/credentials/insecure"
+)
+
+type OrderClient struct {
+	conn    *grpc.ClientConn
+	service protobuf.OrderServiceClient
+	logger  *zap.Logger
+}
+
+func NewOrderClient(url string, secure bool) (*OrderClient, error) {
+	logger := common.GetLogger()
+
+	var opts []grpc.DialOption
+	if secure {
+		creds := credentials.NewTLS(&tls.Config{
+			InsecureSkipVerify: false,
+		})
+		opts = append(opts, grpc.WithTransportCredentials(creds))
+	} else {
+		opts = append(opts, grpc.WithTransportCredentials(insecure.NewCredentials()))
+	}
+
+	opts = append(opts, grpc.WithChainUnaryInterceptor(common.ServiceErrorUnaryInterceptor()))
+
+	conn, err := grpc.NewClient(url, opts...)
+	if err != nil {
+		logger.Error("Failed to connect to gRPC server", zap.String("url", url), zap.String("error", err.Error()))
+		return nil, err
+	}
+
+	logger.Info("Connected to gRPC server", zap.String("url", url))
+
+	client := protobuf.NewOrderServiceClient(conn)
+
+	return &OrderClient{conn: conn, service: client, logger: logger}, nil
+}
+
+func (c *OrderClient) Close() error {
+	c.logger.Info("Closing gRPC connection")
+	return c.conn.Close()
+}
+
+func (c *OrderClient) Checkout(ctx context.Context, accountID string, items []CheckoutItem, paymentToken string) (*Order, error) {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	c.logger.Info("Checkout request received", zap.String("account_id", accountID), zap.Int("item_count", len(items)))
+
+	protoItems := make([]*protobuf.CheckoutItem, 0, len(items))
+	for _, item := range items {
+		protoItems = append(protoItems, &protobuf.CheckoutItem{ProductId: item.ProductID, Sku: item.SKU, Quantity: item.Quantity})
+	}
+
+	r, err := c.service.Checkout(ctx, &protobuf.CheckoutRequest{
+		AccountId:    accountID,
+		Items:        protoItems,
+		PaymentToken: paymentToken,
+	})
+	if err != nil {
+		c.logger.Error("Failed to check out", zap.String("account_id", accountID), zap.String("error", err.Error()))
+		return nil, err
+	}
+
+	c.logger.Info("Checkout finished", zap.String("order_id", r.GetOrder().GetId()), zap.String("status", r.GetOrder().GetStatus()))
+
+	return orderFromProto(r.GetOrder()), nil
+}
+
+func (c *OrderClient) GetOrder(ctx context.Context, id, accountID string) (*Order, error) {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	c.logger.Info("GetOrder request received", zap.String("order_id", id), zap.String("account_id", accountID))
+
+	r, err := c.service.GetOrder(ctx, &protobuf.GetOrderRequest{Id: id, AccountId: accountID})
+	if err != nil {
+		c.logger.Error("Failed to fetch order", zap.String("order_id", id), zap.String("error", err.Error()))
+		return nil, err
+	}
+
+	c.logger.Info("Order fetched successfully", zap.String("order_id", r.GetOrder().GetId()), zap.String("status", r.GetOrder().GetStatus()))
+
+	return orderFromProto(r.GetOrder()), nil
+}
+
+func moneyFromProto(m *protobuf.Money) common.Money {
+	return common.Money{Amount: m.GetAmount(), CurrencyCode: m.GetCurrencyCode()}
+}
+
+func orderFromProto(o *protobuf.Order) *Order {
+	items := make([]OrderItem, 0, len(o.GetItems()))
+	for _, item := range o.GetItems() {
+		items = append(items, OrderItem{
+			ProductID: item.GetProductId(),
+			SKU:       item.GetSku(),
+			Name:      item.GetName(),
+			Quantity:  item.GetQuantity(),
+			UnitPrice: moneyFromProto(item.GetUnitPrice()),
+		})
+	}
+
+	return &Order{
+		ID:            o.GetId(),
+		AccountID:     o.GetAccountId(),
+		Status:        o.GetStatus(),
+		Items:         items,
+		Total:         moneyFromProto(o.GetTotal()),
+		FailureReason: o.GetFailureReason(),
+		CreatedAt:     o.GetCreatedAt().AsTime(),
+		UpdatedAt:     o.GetUpdatedAt().AsTime(),
+	}
+}
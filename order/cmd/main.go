@@ -0,0 +1,98 @@
+package main
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"graphql-grpc-go-microservice-project/account"
+	"graphql-grpc-go-microservice-project/common"
+	"graphql-grpc-go-microservice-project/inventory"
+	"graphql-grpc-go-microservice-project/order"
+	"graphql-grpc-go-microservice-project/product"
+
+	"github.com/kelseyhightower/envconfig"
+	"github.com/tinrab/retry"
+)
+
+type Config struct {
+	ORDER_GRPC_SERVER_PORT     int           `envconfig:"ORDER_GRPC_SERVER_PORT" default:"8080"`
+	ORDER_DATABASE_URL         string        `envconfig:"ORDER_DATABASE_URL"`
+	ORDER_PAYMENT_PROVIDER     string        `envconfig:"ORDER_PAYMENT_PROVIDER" default:"fake"`
+	ORDER_RESERVATION_TTL      time.Duration `envconfig:"ORDER_RESERVATION_TTL" default:"15m"`
+	ORDER_SAGA_RESUME_AFTER    time.Duration `envconfig:"ORDER_SAGA_RESUME_AFTER" default:"1m"`
+	ORDER_SAGA_RECOVERY_PERIOD time.Duration `envconfig:"ORDER_SAGA_RECOVERY_PERIOD" default:"30s"`
+	ACCOUNT_SERVICE_URL        string        `envconfig:"ACCOUNT_SERVICE_URL" required:"true"`
+	PRODUCT_SERVICE_URL        string        `envconfig:"PRODUCT_SERVICE_URL" required:"true"`
+	INVENTORY_SERVICE_URL      string        `envconfig:"INVENTORY_SERVICE_URL" required:"true"`
+}
+
+func main() {
+	var cfg Config
+	if err := envconfig.Process("", &cfg); err != nil {
+		log.Fatalf("Error processing environment variables: %v", err)
+	}
+	if cfg.ORDER_SAGA_RECOVERY_PERIOD <= 0 {
+		log.Fatalf("ORDER_SAGA_RECOVERY_PERIOD must be positive, got %s", cfg.ORDER_SAGA_RECOVERY_PERIOD)
+	}
+
+	var payments order.PaymentProvider
+	switch cfg.ORDER_PAYMENT_PROVIDER {
+	case "fake":
+		payments = order.NewFakePaymentProvider()
+	default:
+		log.Fatalf("Unknown payment provider %q", cfg.ORDER_PAYMENT_PROVIDER)
+	}
+
+	var repo order.OrderRepository
+	retry.ForeverSleep(2*time.Second, func(_ int) error {
+		var err error
+		repo, err = order.NewOrderRepository(cfg.ORDER_DATABASE_URL)
+		if err != nil {
+			log.Printf("Database connection failed: %v", err)
+		}
+		return err
+	})
+
+	defer func() {
+		if err := repo.Close(); err != nil {
+			log.Printf("Error closing repository: %v", err)
+		}
+	}()
+
+	accountClient, err := account.NewAccountClient(cfg.ACCOUNT_SERVICE_URL, false, common.DefaultClientConfig())
+	if err != nil {
+		log.Fatalf("Failed to create account client: %v", err)
+	}
+	defer accountClient.Close()
+
+	productClient, err := product.NewProductClient(cfg.PRODUCT_SERVICE_URL, false, common.DefaultClientConfig())
+	if err != nil {
+		log.Fatalf("Failed to create product client: %v", err)
+	}
+	defer productClient.Close()
+
+	inventoryClient, err := inventory.NewInventoryClient(cfg.INVENTORY_SERVICE_URL, false)
+	if err != nil {
+		log.Fatalf("Failed to create inventory client: %v", err)
+	}
+	defer inventoryClient.Close()
+
+	log.Println("Initializing order service...")
+	service, err := order.NewOrderService(repo, accountClient, productClient, inventoryClient, payments, order.ServiceConfig{
+		ReservationTTL: cfg.ORDER_RESERVATION_TTL,
+		ResumeAfter:    cfg.ORDER_SAGA_RESUME_AFTER,
+	})
+	if err != nil {
+		log.Fatalf("Failed to create order service: %v", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go order.RunSagaRecovery(ctx, service, cfg.ORDER_SAGA_RECOVERY_PERIOD)
+
+	log.Printf("Starting gRPC server on port %d...", cfg.ORDER_GRPC_SERVER_PORT)
+	if err := order.ListenGRPC(service, cfg.ORDER_GRPC_SERVER_PORT, false); err != nil {
+		log.Fatalf("Failed to start gRPC server: %v", err)
+	}
+}
@@ -0,0 +1,84 @@
+package order
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+
+	"graphql-grpc-go-microservice-project/common"
+
+	"github.com/google/uuid"
+)
+
+// ErrPaymentDeclined is returned by a PaymentProvider when the charge was
+// refused. Any other error is treated as temporary and the charge is retried.
+var ErrPaymentDeclined = errors.New("payment declined")
+
+type PaymentRequest struct {
+	// IdempotencyKey identifies the charge, so retrying it after a crash or
+	// timeout does not charge the customer twice.
+	IdempotencyKey string
+	Amount         common.Money
+	Token          string
+}
+
+type Payment struct {
+	ID     string
+	Amount common.Money
+}
+
+type PaymentProvider interface {
+	Charge(ctx context.Context, request PaymentRequest) (Payment, error)
+	Refund(ctx context.Context, paymentID string) error
+}
+
+// FakeDeclinedToken is a payment token the fake provider always declines.
+const FakeDeclinedToken = "tok_declined"
+
+// FakePaymentProvider is an in-process PaymentProvider for development and
+// tests. It accepts every token except FakeDeclinedToken.
+type FakePaymentProvider struct {
+	mu       sync.Mutex
+	payments map[string]Payment
+	refunded map[string]bool
+}
+
+func NewFakePaymentProvider() *FakePaymentProvider {
+	return &FakePaymentProvider{
+		payments: map[string]Payment{},
+		refunded: map[string]bool{},
+	}
+}
+
+func (p *FakePaymentProvider) Charge(ctx context.Context, request PaymentRequest) (Payment, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if payment, ok := p.payments[request.IdempotencyKey]; ok {
+		return payment, nil
+	}
+	if request.Token == FakeDeclinedToken {
+		return Payment{}, fmt.Errorf("%w: card was declined", ErrPaymentDeclined)
+	}
+
+	payment := Payment{ID: "fake_" + uuid.NewString(), Amount: request.Amount}
+	p.payments[request.IdempotencyKey] = payment
+	return payment, nil
+}
+
+func (p *FakePaymentProvider) Refund(ctx context.Context, paymentID string) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.refunded[paymentID] = true
+	return nil
+}
+
+// IsRefunded reports whether a payment was refunded.
+func (p *FakePaymentProvider) IsRefunded(paymentID string) bool {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	return p.refunded[paymentID]
+}
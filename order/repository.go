@@ -0,0 +1,249 @@
+package order
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"graphql-grpc-go-microservice-project/common"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+type OrderRepository interface {
+	Close() error
+	CreateOrder(ctx context.Context, order Order, lease time.Duration) (Order, error)
+	GetOrder(ctx context.Context, id string) (Order, error)
+	ClaimUnfinishedOrders(ctx context.Context, lease time.Duration, limit uint32) ([]Order, error)
+	TransitionOrder(ctx context.Context, id, from, to string, update OrderUpdate) (Order, error)
+}
+
+type orderRepository struct {
+	db *pgxpool.Pool
+}
+
+func NewOrderRepository(connString string) (OrderRepository, error) {
+	config, err := pgxpool.ParseConfig(connString)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse database connection string: %w", err)
+	}
+
+	config.MaxConns = 25
+	config.MaxConnIdleTime = 5 * time.Minute
+
+	db, err := pgxpool.NewWithConfig(context.Background(), config)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create connection pool: %w", err)
+	}
+
+	if err := db.Ping(context.Background()); err != nil {
+		return nil, fmt.Errorf("failed to ping database: %w", err)
+	}
+
+	return &orderRepository{db}, nil
+}
+
+func (repository *orderRepository) Close() error {
+	repository.db.Close()
+	return nil
+}
+
+// CreateOrder stores a pending order with its items and the first saga event.
+// The order is claimed for lease by the caller, which runs its saga.
+func (repository *orderRepository) CreateOrder(ctx context.Context, order Order, lease time.Duration) (Order, error) {
+	tx, err := repository.db.Begin(ctx)
+	if err != nil {
+		return Order{}, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	query := `
+        INSERT INTO orders (account_id, status, total_amount, currency_code, payment_token, claimed_until)
+        VALUES ($1, $2, $3, $4, $5, CURRENT_TIMESTAMP + make_interval(secs => $6))
+        RETURNING id`
+	err = tx.QueryRow(ctx, query, order.AccountID, OrderStatusPending, order.Total.Amount, order.Total.CurrencyCode, order.PaymentToken, lease.Seconds()).Scan(&order.ID)
+	if err != nil {
+		return Order{}, fmt.Errorf("failed to create order: %w", err)
+	}
+
+	for _, item := range order.Items {
+		query := `
+            INSERT INTO order_items (order_id, product_id, sku, name, quantity, unit_amount)
+            VALUES ($1, $2, $3, $4, $5, $6)`
+		if _, err := tx.Exec(ctx, query, order.ID, item.ProductID, item.SKU, item.Name, item.Quantity, item.UnitPrice.Amount); err != nil {
+			return Order{}, fmt.Errorf("failed to create order item: %w", err)
+		}
+	}
+
+	if err := insertEvent(ctx, tx, order.ID, "", OrderStatusPending, ""); err != nil {
+		return Order{}, err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return Order{}, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return repository.GetOrder(ctx, order.ID)
+}
+
+func (repository *orderRepository) GetOrder(ctx context.Context, id string) (Order, error) {
+	query := `
+        SELECT id, account_id, status, total_amount, currency_code, COALESCE(payment_token, ''),
+               COALESCE(reservation_id::text, ''), COALESCE(payment_id, ''), COALESCE(failure_reason, ''),
+               created_at, updated_at
+        FROM orders
+        WHERE id = $1`
+	order, err := scanOrder(repository.db.QueryRow(ctx, query, id))
+	if errors.Is(err, pgx.ErrNoRows) {
+		return Order{}, ErrOrderNotFound
+	}
+	if err != nil {
+		return Order{}, fmt.Errorf("failed to get order: %w", err)
+	}
+
+	order.Items, err = repository.orderItems(ctx, order)
+	if err != nil {
+		return Order{}, err
+	}
+
+	return order, nil
+}
+
+// ClaimUnfinishedOrders claims orders whose saga has not reached a final
+// status and whose previous claim has run out, longest unclaimed first. The
+// orders stay claimed for lease, so concurrent workers never pick up the same
+// order while its saga is being run.
+func (repository *orderRepository) ClaimUnfinishedOrders(ctx context.Context, lease time.Duration, limit uint32) ([]Order, error) {
+	query := `
+        UPDATE orders
+        SET claimed_until = CURRENT_TIMESTAMP + make_interval(secs => $3)
+        WHERE id IN (
+            SELECT id
+            FROM orders
+            WHERE status NOT IN ($1, $2) AND claimed_until < CURRENT_TIMESTAMP
+            ORDER BY claimed_until
+            LIMIT $4
+            FOR UPDATE SKIP LOCKED)
+        RETURNING id`
+	rows, err := repository.db.Query(ctx, query, OrderStatusCompleted, OrderStatusFailed, lease.Seconds(), limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to claim unfinished orders: %w", err)
+	}
+
+	ids, err := pgx.CollectRows(rows, pgx.RowTo[string])
+	if err != nil {
+		return nil, fmt.Errorf("failed to scan order id: %w", err)
+	}
+
+	orders := make([]Order, 0, len(ids))
+	for _, id := range ids {
+		order, err := repository.GetOrder(ctx, id)
+		if err != nil {
+			return nil, err
+		}
+		orders = append(orders, order)
+	}
+
+	return orders, nil
+}
+
+// TransitionOrder moves an order from one status to another and records the
+// saga event. It returns ErrInvalidTransition when the transition is not part
+// of the state machine or the order is no longer in the from status, so two
+// workers can never drive the same step. The payment token is only kept
+// until the order leaves stock_reserved, the status in which it is charged.
+func (repository *orderRepository) TransitionOrder(ctx context.Context, id, from, to string, update OrderUpdate) (Order, error) {
+	if !canTransition(from, to) {
+		return Order{}, fmt.Errorf("%w: %s to %s", ErrInvalidTransition, from, to)
+	}
+
+	tx, err := repository.db.Begin(ctx)
+	if err != nil {
+		return Order{}, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	query := `
+        UPDATE orders
+        SET status = $3,
+            reservation_id = COALESCE(NULLIF($4, '')::uuid, reservation_id),
+            payment_id = COALESCE(NULLIF($5, ''), payment_id),
+            failure_reason = COALESCE(NULLIF($6, ''), failure_reason),
+            payment_token = CASE WHEN $3 = $7 THEN payment_token END
+        WHERE id = $1 AND status = $2`
+	tag, err := tx.Exec(ctx, query, id, from, to, update.ReservationID, update.PaymentID, update.FailureReason, OrderStatusStockReserved)
+	if err != nil {
+		return Order{}, fmt.Errorf("failed to update order: %w", err)
+	}
+	if tag.RowsAffected() == 0 {
+		return Order{}, fmt.Errorf("%w: order %s is not %s", ErrInvalidTransition, id, from)
+	}
+
+	if err := insertEvent(ctx, tx, id, from, to, update.FailureReason); err != nil {
+		return Order{}, err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return Order{}, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return repository.GetOrder(ctx, id)
+}
+
+func insertEvent(ctx context.Context, tx pgx.Tx, orderID, from, to, detail string) error {
+	query := `
+        INSERT INTO order_events (order_id, from_status, to_status, detail)
+        VALUES ($1, NULLIF($2, ''), $3, NULLIF($4, ''))`
+	if _, err := tx.Exec(ctx, query, orderID, from, to, detail); err != nil {
+		return fmt.Errorf("failed to record order event: %w", err)
+	}
+	return nil
+}
+
+func scanOrder(row pgx.Row) (Order, error) {
+	var order Order
+	err := row.Scan(
+		&order.ID,
+		&order.AccountID,
+		&order.Status,
+		&order.Total.Amount,
+		&order.Total.CurrencyCode,
+		&order.PaymentToken,
+		&order.ReservationID,
+		&order.PaymentID,
+		&order.FailureReason,
+		&order.CreatedAt,
+		&order.UpdatedAt,
+	)
+	return order, err
+}
+
+func (repository *orderRepository) orderItems(ctx context.Context, order Order) ([]OrderItem, error) {
+	query := `
+        SELECT product_id, sku, name, quantity, unit_amount
+        FROM order_items
+        WHERE order_id = $1
+        ORDER BY product_id, sku`
+	rows, err := repository.db.Query(ctx, query, order.ID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get order items: %w", err)
+	}
+	defer rows.Close()
+
+	items := []OrderItem{}
+	for rows.Next() {
+		item := OrderItem{UnitPrice: common.Money{CurrencyCode: order.Total.CurrencyCode}}
+		if err := rows.Scan(&item.ProductID, &item.SKU, &item.Name, &item.Quantity, &item.UnitPrice.Amount); err != nil {
+			return nil, fmt.Errorf("failed to scan order item: %w", err)
+		}
+		items = append(items, item)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating over order item rows: %w", err)
+	}
+
+	return items, nil
+}
@@ -0,0 +1,148 @@
+package order
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"graphql-grpc-go-microservice-project/inventory"
+
+	"go.uber.org/zap"
+	grpcResponseCodes "google.golang.org/grpc/codes"
+	grpcResponseStatus "google.golang.org/grpc/status"
+)
+
+var errSagaStalled = errors.New("order saga stalled")
+
+// runSaga advances an order step by step until it is completed or failed.
+// Every step is persisted before the next one starts, so a saga interrupted
+// by an error can be resumed from the order's status:
+//
+//   - pending: reserve stock, keyed by the order ID; a rejected reservation
+//     fails the order.
+//   - stock_reserved: charge the payment, keyed by the order ID so a retried
+//     charge is not captured twice; a declined payment compensates.
+//   - payment_captured: commit the reservation; an expired reservation
+//     compensates.
+//   - compensating: refund the payment and release the stock, then fail.
+//
+// On error the order is returned in the last status it reached.
+func (service *orderService) runSaga(ctx context.Context, order Order) (Order, error) {
+	for !order.IsFinished() {
+		next, err := service.step(ctx, order)
+		if err != nil {
+			return order, err
+		}
+		order = next
+	}
+	return order, nil
+}
+
+func (service *orderService) step(ctx context.Context, order Order) (Order, error) {
+	service.logger.Info("Running order saga step", zap.String("order_id", order.ID), zap.String("status", order.Status))
+
+	switch order.Status {
+	case OrderStatusPending:
+		return service.reserveStock(ctx, order)
+	case OrderStatusStockReserved:
+		return service.capturePayment(ctx, order)
+	case OrderStatusPaymentCaptured:
+		return service.commitStock(ctx, order)
+	case OrderStatusCompensating:
+		return service.compensate(ctx, order)
+	}
+	return order, fmt.Errorf("%w: unknown status %q", errSagaStalled, order.Status)
+}
+
+func (service *orderService) reserveStock(ctx context.Context, order Order) (Order, error) {
+	items := make([]inventory.ReservationItem, 0, len(order.Items))
+	for _, item := range order.Items {
+		items = append(items, inventory.ReservationItem{ProductID: item.ProductID, SKU: item.SKU, Quantity: item.Quantity})
+	}
+
+	// Keyed by the order ID, so a step retried after a crash or by another
+	// worker gets the same reservation back instead of holding stock twice.
+	reservation, err := service.stock.Reserve(ctx, order.ID, items, service.config.ReservationTTL)
+	if isRejection(err) {
+		return service.repository.TransitionOrder(ctx, order.ID, order.Status, OrderStatusFailed, OrderUpdate{
+			FailureReason: "stock could not be reserved: " + errorMessage(err),
+		})
+	}
+	if err != nil {
+		return order, fmt.Errorf("failed to reserve stock: %w", err)
+	}
+
+	return service.repository.TransitionOrder(ctx, order.ID, order.Status, OrderStatusStockReserved, OrderUpdate{
+		ReservationID: reservation.ID.String(),
+	})
+}
+
+func (service *orderService) capturePayment(ctx context.Context, order Order) (Order, error) {
+	payment, err := service.payments.Charge(ctx, PaymentRequest{
+		IdempotencyKey: order.ID,
+		Amount:         order.Total,
+		Token:          order.PaymentToken,
+	})
+	if errors.Is(err, ErrPaymentDeclined) {
+		return service.repository.TransitionOrder(ctx, order.ID, order.Status, OrderStatusCompensating, OrderUpdate{
+			FailureReason: err.Error(),
+		})
+	}
+	if err != nil {
+		return order, fmt.Errorf("failed to capture payment: %w", err)
+	}
+
+	return service.repository.TransitionOrder(ctx, order.ID, order.Status, OrderStatusPaymentCaptured, OrderUpdate{
+		PaymentID: payment.ID,
+	})
+}
+
+func (service *orderService) commitStock(ctx context.Context, order Order) (Order, error) {
+	_, err := service.stock.Commit(ctx, order.ReservationID)
+	if isRejection(err) {
+		return service.repository.TransitionOrder(ctx, order.ID, order.Status, OrderStatusCompensating, OrderUpdate{
+			FailureReason: "stock reservation could not be committed: " + errorMessage(err),
+		})
+	}
+	if err != nil {
+		return order, fmt.Errorf("failed to commit stock reservation: %w", err)
+	}
+
+	return service.repository.TransitionOrder(ctx, order.ID, order.Status, OrderStatusCompleted, OrderUpdate{})
+}
+
+// compensate undoes the steps that succeeded. Both undo operations are safe
+// to repeat, so a compensation interrupted halfway is simply run again.
+func (service *orderService) compensate(ctx context.Context, order Order) (Order, error) {
+	if order.PaymentID != "" {
+		if err := service.payments.Refund(ctx, order.PaymentID); err != nil {
+			return order, fmt.Errorf("failed to refund payment: %w", err)
+		}
+	}
+
+	if order.ReservationID != "" {
+		// A rejection means the reservation was already released or expired.
+		if _, err := service.stock.Release(ctx, order.ReservationID); err != nil && !isRejection(err) {
+			return order, fmt.Errorf("failed to release stock reservation: %w", err)
+		}
+	}
+
+	return service.repository.TransitionOrder(ctx, order.ID, order.Status, OrderStatusFailed, OrderUpdate{})
+}
+
+// isRejection reports whether a downstream gRPC error is a definitive
+// answer, as opposed to an outage worth retrying.
+func isRejection(err error) bool {
+	switch grpcResponseStatus.Code(err) {
+	case grpcResponseCodes.InvalidArgument, grpcResponseCodes.NotFound, grpcResponseCodes.FailedPrecondition:
+		return true
+	}
+	return false
+}
+
+func errorMessage(err error) string {
+	if s, ok := grpcResponseStatus.FromError(err); ok {
+		return s.Message()
+	}
+	return err.Error()
+}
@@ -0,0 +1,168 @@
+package order
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"graphql-grpc-go-microservice-project/common"
+	"graphql-grpc-go-microservice-project/order/protobuf"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/keepalive"
+	"google.golang.org/grpc/reflection"
+	"google.golang.org/protobuf/types/known/timestamppb"
+
+	"go.uber.org/zap"
+	grpcResponseCodes "google.golang.org/grpc/codes"
+	grpcResponseStatus "google.golang.org/grpc/status"
+)
+
+type orderGrpcServer struct {
+	protobuf.UnimplementedOrderServiceServer
+	service OrderService
+	logger  *zap.Logger
+}
+
+func ListenGRPC(s OrderService, port int, secure bool) error {
+	logger := common.GetLogger()
+
+	lis, err := net.Listen("tcp", fmt.Sprintf(":%d", port))
+	if err != nil {
+		return fmt.Errorf("failed to listen on port %d: %v", port, err)
+	}
+
+	var opts []grpc.ServerOption
+	keepAliveParams := keepalive.ServerParameters{
+		Time:    5 * time.Minute,
+		Timeout: 20 * time.Second,
+	}
+
+	opts = append(opts, grpc.KeepaliveParams(keepAliveParams))
+
+	if secure {
+		creds := credentials.NewTLS(&tls.Config{
+			InsecureSkipVerify: false,
+		})
+		opts = append(opts, grpc.Creds(creds))
+	} else {
+		opts = append(opts, grpc.Creds(insecure.NewCredentials()))
+	}
+
+	serv := grpc.NewServer(opts...)
+	orderServer := &orderGrpcServer{
+		UnimplementedOrderServiceServer: protobuf.UnimplementedOrderServiceServer{},
+		service:                         s,
+		logger:                          logger,
+	}
+	protobuf.RegisterOrderServiceServer(serv, orderServer)
+	reflection.Register(serv)
+
+	errChan := make(chan error)
+	go func() {
+		if err := serv.Serve(lis); err != nil {
+			errChan <- fmt.Errorf("failed to serve gRPC server: %v", err)
+		}
+	}()
+
+	signalChan := make(chan os.Signal, 1)
+	signal.Notify(signalChan, os.Interrupt, syscall.SIGTERM)
+
+	select {
+	case sig := <-signalChan:
+		logger.Info("Received signal, shutting down gRPC server", zap.String("signal", sig.String()))
+		serv.GracefulStop()
+	case err := <-errChan:
+		return err
+	}
+
+	return nil
+}
+
+func (s *orderGrpcServer) Checkout(ctx context.Context, r *protobuf.CheckoutRequest) (*protobuf.CheckoutResponse, error) {
+	s.logger.Info("Checkout request received", zap.String("account_id", r.AccountId), zap.Int("item_count", len(r.Items)))
+
+	items := make([]CheckoutItem, 0, len(r.Items))
+	for _, item := range r.Items {
+		items = append(items, CheckoutItem{ProductID: item.GetProductId(), SKU: item.GetSku(), Quantity: item.GetQuantity()})
+	}
+
+	o, err := s.service.Checkout(ctx, r.AccountId, items, r.PaymentToken)
+	if err != nil {
+		s.logger.Error("Failed to check out", zap.String("account_id", r.AccountId), zap.String("error", err.Error()))
+		return &protobuf.CheckoutResponse{
+			Result: &protobuf.CheckoutResponse_Error{Error: err.Error()},
+		}, orderError(err)
+	}
+
+	s.logger.Info("Checkout finished", zap.String("order_id", o.ID), zap.String("status", o.Status))
+
+	return &protobuf.CheckoutResponse{
+		Result: &protobuf.CheckoutResponse_Order{Order: orderToProto(o)},
+	}, nil
+}
+
+func (s *orderGrpcServer) GetOrder(ctx context.Context, r *protobuf.GetOrderRequest) (*protobuf.GetOrderResponse, error) {
+	s.logger.Info("GetOrder request received", zap.String("order_id", r.Id), zap.String("account_id", r.AccountId))
+
+	o, err := s.service.GetOrder(ctx, r.Id, r.AccountId)
+	if err != nil {
+		s.logger.Error("Failed to fetch order", zap.String("order_id", r.Id), zap.String("error", err.Error()))
+		return &protobuf.GetOrderResponse{
+			Result: &protobuf.GetOrderResponse_Error{Error: err.Error()},
+		}, orderError(err)
+	}
+
+	s.logger.Info("Order fetched successfully", zap.String("order_id", o.ID), zap.String("status", o.Status))
+
+	return &protobuf.GetOrderResponse{
+		Result: &protobuf.GetOrderResponse_Order{Order: orderToProto(o)},
+	}, nil
+}
+
+func orderError(err error) error {
+	var validationErr *common.ValidationError
+	switch {
+	case errors.As(err, &validationErr):
+		return validationErr.GRPCStatus().Err()
+	case errors.Is(err, ErrOrderNotFound):
+		return grpcResponseStatus.Errorf(grpcResponseCodes.NotFound, err.Error())
+	}
+	return grpcResponseStatus.Errorf(grpcResponseCodes.Internal, err.Error())
+}
+
+func moneyToProto(m common.Money) *protobuf.Money {
+	return &protobuf.Money{Amount: m.Amount, CurrencyCode: m.CurrencyCode}
+}
+
+func orderToProto(o *Order) *protobuf.Order {
+	items := make([]*protobuf.OrderItem, 0, len(o.Items))
+	for _, item := range o.Items {
+		items = append(items, &protobuf.OrderItem{
+			ProductId: item.ProductID,
+			Sku:       item.SKU,
+			Name:      item.Name,
+			Quantity:  item.Quantity,
+			UnitPrice: moneyToProto(item.UnitPrice),
+		})
+	}
+
+	return &protobuf.Order{
+		Id:            o.ID,
+		AccountId:     o.AccountID,
+		Status:        o.Status,
+		Items:         items,
+		Total:         moneyToProto(o.Total),
+		FailureReason: o.FailureReason,
+		CreatedAt:     timestamppb.New(o.CreatedAt),
+		UpdatedAt:     timestamppb.New(o.UpdatedAt),
+	}
+}
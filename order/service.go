@@ -0,0 +1,272 @@
+package order
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"graphql-grpc-go-microservice-project/account"
+	"graphql-grpc-go-microservice-project/common"
+	"graphql-grpc-go-microservice-project/inventory"
+	"graphql-grpc-go-microservice-project/product"
+
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+	grpcResponseCodes "google.golang.org/grpc/codes"
+	grpcResponseStatus "google.golang.org/grpc/status"
+)
+
+// AccountDirectory looks up the account placing an order.
+// *account.AccountClient satisfies it.
+type AccountDirectory interface {
+	GetAccountByID(ctx context.Context, id string) (*account.Account, error)
+}
+
+// ProductCatalog prices the items of an order.
+// *product.ProductClient satisfies it.
+type ProductCatalog interface {
+	ListProductsWithIDs(ctx context.Context, ids []string, limit, offset uint32) ([]*product.Product, error)
+}
+
+// StockReserver holds stock for an order until its payment is captured.
+// Reserve must return the existing reservation when called again with the
+// same idempotency key. *inventory.InventoryClient satisfies it.
+type StockReserver interface {
+	Reserve(ctx context.Context, idempotencyKey string, items []inventory.ReservationItem, ttl time.Duration) (*inventory.Reservation, error)
+	Commit(ctx context.Context, reservationID string) (*inventory.Reservation, error)
+	Release(ctx context.Context, reservationID string) (*inventory.Reservation, error)
+}
+
+type OrderService interface {
+	Checkout(ctx context.Context, accountID string, items []CheckoutItem, paymentToken string) (*Order, error)
+	GetOrder(ctx context.Context, id, accountID string) (*Order, error)
+	ResumeUnfinishedOrders(ctx context.Context) (int, error)
+}
+
+// ServiceConfig holds the saga's timing settings.
+type ServiceConfig struct {
+	// ReservationTTL is how long stock stays reserved for an order whose
+	// payment has not been captured yet.
+	ReservationTTL time.Duration
+	// ResumeAfter is how long Checkout and ResumeUnfinishedOrders claim an
+	// order for while running its saga. An unfinished order is only resumed
+	// once its claim has run out, so it does not race a saga still in flight.
+	ResumeAfter time.Duration
+}
+
+const unfinishedOrderBatchSize = 100
+
+type orderService struct {
+	repository OrderRepository
+	accounts   AccountDirectory
+	catalog    ProductCatalog
+	stock      StockReserver
+	payments   PaymentProvider
+	config     ServiceConfig
+	logger     *zap.Logger
+}
+
+func NewOrderService(repository OrderRepository, accounts AccountDirectory, catalog ProductCatalog, stock StockReserver, payments PaymentProvider, config ServiceConfig) (OrderService, error) {
+	if config.ReservationTTL <= 0 {
+		return nil, fmt.Errorf("reservation ttl must be positive, got %s", config.ReservationTTL)
+	}
+	if config.ResumeAfter <= 0 {
+		return nil, fmt.Errorf("saga resume delay must be positive, got %s", config.ResumeAfter)
+	}
+
+	return &orderService{
+		repository: repository,
+		accounts:   accounts,
+		catalog:    catalog,
+		stock:      stock,
+		payments:   payments,
+		config:     config,
+		logger:     common.GetLogger(),
+	}, nil
+}
+
+// Checkout prices the items, stores a pending order and runs the saga. The
+// order is returned in whatever status the saga reached: completed, failed,
+// or an intermediate status when a service was unavailable, in which case
+// ResumeUnfinishedOrders finishes it later.
+func (service *orderService) Checkout(ctx context.Context, accountID string, items []CheckoutItem, paymentToken string) (*Order, error) {
+	violations := &common.ValidationError{}
+	accountID, items, paymentToken = validateCheckout(violations, accountID, items, paymentToken)
+	if err := violations.Err(); err != nil {
+		return nil, err
+	}
+
+	if _, err := service.accounts.GetAccountByID(ctx, accountID); err != nil {
+		if grpcResponseStatus.Code(err) == grpcResponseCodes.NotFound {
+			violations.Add("accountId", "must reference an existing account")
+			return nil, violations.Err()
+		}
+		return nil, err
+	}
+
+	order, err := service.price(ctx, violations, accountID, items)
+	if err != nil {
+		return nil, err
+	}
+	order.PaymentToken = paymentToken
+
+	created, err := service.repository.CreateOrder(ctx, order, service.config.ResumeAfter)
+	if err != nil {
+		return nil, err
+	}
+
+	// The saga must not stop halfway because the caller went away.
+	result, err := service.runSaga(context.WithoutCancel(ctx), created)
+	if err != nil {
+		service.logger.Warn("Checkout saga interrupted", zap.String("order_id", result.ID), zap.String("status", result.Status), zap.String("error", err.Error()))
+	}
+
+	return &result, nil
+}
+
+// GetOrder returns an order placed by accountID. Orders of other accounts are
+// reported as not found rather than forbidden, so their IDs cannot be probed.
+func (service *orderService) GetOrder(ctx context.Context, id, accountID string) (*Order, error) {
+	violations := &common.ValidationError{}
+	if _, err := uuid.Parse(id); err != nil {
+		violations.Add("id", "must be a valid UUID")
+	}
+	if _, err := uuid.Parse(accountID); err != nil {
+		violations.Add("accountId", "must be a valid UUID")
+	}
+	if err := violations.Err(); err != nil {
+		return nil, err
+	}
+
+	order, err := service.repository.GetOrder(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if order.AccountID != accountID {
+		return nil, ErrOrderNotFound
+	}
+
+	return &order, nil
+}
+
+// ResumeUnfinishedOrders drives orders left in an intermediate status, e.g.
+// after a crash or an outage of a downstream service, to a final status. It
+// returns the number of orders that reached one.
+func (service *orderService) ResumeUnfinishedOrders(ctx context.Context) (int, error) {
+	orders, err := service.repository.ClaimUnfinishedOrders(ctx, service.config.ResumeAfter, unfinishedOrderBatchSize)
+	if err != nil {
+		return 0, err
+	}
+
+	finished := 0
+	for _, order := range orders {
+		result, err := service.runSaga(ctx, order)
+		if err != nil {
+			service.logger.Warn("Order saga interrupted", zap.String("order_id", order.ID), zap.String("status", result.Status), zap.String("error", err.Error()))
+			continue
+		}
+		finished++
+	}
+	return finished, nil
+}
+
+// price builds the order from the catalog's current prices, using the price
+// of the chosen variant for products with variants. All items must share a
+// currency since the order is charged in a single payment.
+func (service *orderService) price(ctx context.Context, violations *common.ValidationError, accountID string, items []CheckoutItem) (Order, error) {
+	ids := make([]string, 0, len(items))
+	for _, item := range items {
+		ids = append(ids, item.ProductID)
+	}
+
+	products, err := service.catalog.ListProductsWithIDs(ctx, ids, uint32(len(ids)), 0)
+	if err != nil {
+		return Order{}, err
+	}
+
+	byID := make(map[string]*product.Product, len(products))
+	for _, p := range products {
+		byID[p.ID] = p
+	}
+
+	order := Order{AccountID: accountID, Items: make([]OrderItem, 0, len(items))}
+	for i, item := range items {
+		p, ok := byID[item.ProductID]
+		if !ok {
+			violations.Add(fmt.Sprintf("items[%d].productId", i), "must reference an existing product")
+			continue
+		}
+
+		price, ok := variantPrice(violations, fmt.Sprintf("items[%d].sku", i), p, item.SKU)
+		if !ok {
+			continue
+		}
+
+		if order.Total.CurrencyCode == "" {
+			order.Total.CurrencyCode = price.CurrencyCode
+		} else if order.Total.CurrencyCode != price.CurrencyCode {
+			violations.Add(fmt.Sprintf("items[%d].productId", i), "must be priced in the same currency as the other items")
+			continue
+		}
+
+		order.Items = append(order.Items, OrderItem{
+			ProductID: p.ID,
+			SKU:       item.SKU,
+			Name:      p.Name,
+			Quantity:  item.Quantity,
+			UnitPrice: price,
+		})
+		order.Total.Amount += price.Amount * int64(item.Quantity)
+	}
+
+	if err := violations.Err(); err != nil {
+		return Order{}, err
+	}
+
+	return order, nil
+}
+
+// variantPrice returns the price of the variant of p named by sku, or the
+// product's own price when it has no variants.
+func variantPrice(violations *common.ValidationError, field string, p *product.Product, sku string) (common.Money, bool) {
+	if len(p.Variants) == 0 {
+		if sku != "" {
+			violations.Add(field, "must be empty for a product without variants")
+			return common.Money{}, false
+		}
+		return p.Price, true
+	}
+
+	for _, variant := range p.Variants {
+		if variant.SKU == sku {
+			return variant.Price, true
+		}
+	}
+	violations.Add(field, "must name one of the product's variants")
+	return common.Money{}, false
+}
+
+// RunSagaRecovery resumes unfinished orders every interval until ctx is
+// cancelled. interval must be positive.
+func RunSagaRecovery(ctx context.Context, service OrderService, interval time.Duration) {
+	logger := common.GetLogger()
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			finished, err := service.ResumeUnfinishedOrders(ctx)
+			if err != nil {
+				logger.Error("Failed to resume unfinished orders", zap.String("error", err.Error()))
+				continue
+			}
+			if finished > 0 {
+				logger.Info("Resumed unfinished orders", zap.Int("count", finished))
+			}
+		}
+	}
+}
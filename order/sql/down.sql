@@ -0,0 +1,4 @@
+DROP TABLE IF EXISTS order_events;
+DROP TABLE IF EXISTS order_items;
+DROP TABLE IF EXISTS orders;
+DROP FUNCTION IF EXISTS update_updated_at_column;
@@ -0,0 +1,60 @@
+CREATE EXTENSION IF NOT EXISTS "uuid-ossp";
+
+CREATE OR REPLACE FUNCTION update_updated_at_column()
+RETURNS TRIGGER AS $$
+BEGIN
+    NEW.updated_at = CURRENT_TIMESTAMP;
+    RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TABLE orders (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4 (),
+    account_id UUID NOT NULL,
+    status VARCHAR(32) NOT NULL,
+    total_amount BIGINT NOT NULL,
+    currency_code CHAR(3) NOT NULL,
+    -- payment_token is cleared once the payment was charged or the order failed.
+    payment_token VARCHAR(255),
+    reservation_id UUID,
+    payment_id VARCHAR(255),
+    failure_reason TEXT,
+    -- claimed_until is when the worker running the order's saga gives it up,
+    -- after which another worker may resume it.
+    claimed_until TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE TRIGGER set_updated_at BEFORE
+UPDATE ON orders FOR EACH ROW
+EXECUTE FUNCTION update_updated_at_column ();
+
+CREATE INDEX idx_orders_account_id ON orders (account_id, created_at);
+
+CREATE INDEX idx_orders_in_progress ON orders (claimed_until)
+WHERE
+    status NOT IN ('completed', 'failed');
+
+CREATE TABLE order_items (
+    order_id UUID NOT NULL REFERENCES orders (id),
+    product_id VARCHAR(64) NOT NULL,
+    -- sku is empty for products without variants.
+    sku VARCHAR(64) NOT NULL DEFAULT '',
+    name VARCHAR(255) NOT NULL,
+    quantity INTEGER NOT NULL CHECK (quantity > 0),
+    unit_amount BIGINT NOT NULL,
+    PRIMARY KEY (order_id, product_id, sku)
+);
+
+-- order_events records every saga transition of an order.
+CREATE TABLE order_events (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4 (),
+    order_id UUID NOT NULL REFERENCES orders (id),
+    from_status VARCHAR(32),
+    to_status VARCHAR(32) NOT NULL,
+    detail TEXT,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX idx_order_events_order_id ON order_events (order_id, created_at);
@@ -0,0 +1,86 @@
+package order
+
+import (
+	"errors"
+	"time"
+
+	"graphql-grpc-go-microservice-project/common"
+)
+
+var (
+	ErrOrderNotFound     = errors.New("order not found")
+	ErrInvalidTransition = errors.New("invalid order status transition")
+)
+
+// Order statuses form the checkout saga's state machine:
+//
+//	pending -> stock_reserved -> payment_captured -> completed
+//
+// A failure after stock was reserved moves the order to compensating, which
+// refunds the payment and releases the stock before ending in failed.
+const (
+	OrderStatusPending         = "pending"
+	OrderStatusStockReserved   = "stock_reserved"
+	OrderStatusPaymentCaptured = "payment_captured"
+	OrderStatusCompleted       = "completed"
+	OrderStatusCompensating    = "compensating"
+	OrderStatusFailed          = "failed"
+)
+
+var orderTransitions = map[string][]string{
+	OrderStatusPending:         {OrderStatusStockReserved, OrderStatusFailed},
+	OrderStatusStockReserved:   {OrderStatusPaymentCaptured, OrderStatusCompensating},
+	OrderStatusPaymentCaptured: {OrderStatusCompleted, OrderStatusCompensating},
+	OrderStatusCompensating:    {OrderStatusFailed},
+}
+
+func canTransition(from, to string) bool {
+	for _, allowed := range orderTransitions[from] {
+		if allowed == to {
+			return true
+		}
+	}
+	return false
+}
+
+type Order struct {
+	ID            string       `json:"id"`
+	AccountID     string       `json:"account_id"`
+	Status        string       `json:"status"`
+	Items         []OrderItem  `json:"items"`
+	Total         common.Money `json:"total"`
+	PaymentToken  string       `json:"-"`
+	ReservationID string       `json:"reservation_id,omitempty"`
+	PaymentID     string       `json:"payment_id,omitempty"`
+	FailureReason string       `json:"failure_reason,omitempty"`
+	CreatedAt     time.Time    `json:"created_at"`
+	UpdatedAt     time.Time    `json:"updated_at"`
+}
+
+func (o Order) IsFinished() bool {
+	return o.Status == OrderStatusCompleted || o.Status == OrderStatusFailed
+}
+
+type OrderItem struct {
+	ProductID string       `json:"product_id"`
+	SKU       string       `json:"sku,omitempty"`
+	Name      string       `json:"name"`
+	Quantity  int32        `json:"quantity"`
+	UnitPrice common.Money `json:"unit_price"`
+}
+
+// CheckoutItem buys a product, or the variant of it named by SKU. SKU is
+// required for products with variants and must be empty otherwise.
+type CheckoutItem struct {
+	ProductID string `json:"product_id"`
+	SKU       string `json:"sku,omitempty"`
+	Quantity  int32  `json:"quantity"`
+}
+
+// OrderUpdate holds the fields a saga step records along with a transition.
+// Empty fields are left unchanged.
+type OrderUpdate struct {
+	ReservationID string
+	PaymentID     string
+	FailureReason string
+}
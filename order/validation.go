@@ -0,0 +1,84 @@
+package order
+
+import (
+	"fmt"
+	"strings"
+
+	"graphql-grpc-go-microservice-project/common"
+
+	"github.com/google/uuid"
+)
+
+const (
+	// maxProductIDLength matches the VARCHAR(64) product_id column.
+	maxProductIDLength = 64
+	// maxSKULength matches the VARCHAR(64) sku column.
+	maxSKULength = 64
+	// maxPaymentTokenLength matches the VARCHAR(255) payment_token column.
+	maxPaymentTokenLength = 255
+	maxItemQuantity       = 999
+	maxCheckoutItems      = 100
+)
+
+// validateCheckout trims the checkout input and merges repeated variants of a
+// product into a single item.
+func validateCheckout(violations *common.ValidationError, accountID string, items []CheckoutItem, paymentToken string) (string, []CheckoutItem, string) {
+	accountID = strings.TrimSpace(accountID)
+	if _, err := uuid.Parse(accountID); err != nil {
+		violations.Add("accountId", "must be a valid UUID")
+	}
+
+	paymentToken = strings.TrimSpace(paymentToken)
+	switch {
+	case paymentToken == "":
+		violations.Add("paymentToken", "must not be empty")
+	case len(paymentToken) > maxPaymentTokenLength:
+		violations.Add("paymentToken", "must be at most 255 characters")
+	}
+
+	switch {
+	case len(items) == 0:
+		violations.Add("items", "must not be empty")
+	case len(items) > maxCheckoutItems:
+		violations.Add("items", "must contain at most 100 items")
+	}
+
+	merged := make([]CheckoutItem, 0, len(items))
+	indexes := map[CheckoutItem]int{}
+	for i, item := range items {
+		field := fmt.Sprintf("items[%d]", i)
+
+		item.ProductID = strings.TrimSpace(item.ProductID)
+		switch {
+		case item.ProductID == "":
+			violations.Add(field+".productId", "must not be empty")
+		case len(item.ProductID) > maxProductIDLength:
+			violations.Add(field+".productId", "must be at most 64 characters")
+		}
+
+		item.SKU = strings.TrimSpace(item.SKU)
+		if len(item.SKU) > maxSKULength {
+			violations.Add(field+".sku", "must be at most 64 characters")
+		}
+
+		switch {
+		case item.Quantity < 1:
+			violations.Add(field+".quantity", "must be greater than 0")
+		case item.Quantity > maxItemQuantity:
+			violations.Add(field+".quantity", "must be at most 999")
+		}
+
+		key := CheckoutItem{ProductID: item.ProductID, SKU: item.SKU}
+		if index, ok := indexes[key]; ok {
+			merged[index].Quantity += item.Quantity
+			if merged[index].Quantity > maxItemQuantity {
+				violations.Add(field+".quantity", "total quantity of a product must be at most 999")
+			}
+			continue
+		}
+		indexes[key] = len(merged)
+		merged = append(merged, item)
+	}
+
+	return accountID, merged, paymentToken
+}
@@ -0,0 +1,112 @@
+package product
+
+import (
+	"sort"
+	"strings"
+)
+
+const categoryPathSeparator = " > "
+
+// categoryTree resolves the ancestry of categories. The whole taxonomy is
+// loaded at once since it stays small compared to the catalog.
+type categoryTree struct {
+	categories map[string]Category
+}
+
+func newCategoryTree(categories []Category) *categoryTree {
+	tree := &categoryTree{categories: make(map[string]Category, len(categories))}
+	for _, category := range categories {
+		tree.categories[category.ID] = category
+	}
+	return tree
+}
+
+// resolve returns the category with its Path and AncestorIDs filled in from
+// the root down.
+func (t *categoryTree) resolve(id string) (Category, bool) {
+	category, ok := t.categories[id]
+	if !ok {
+		return Category{}, false
+	}
+
+	names := []string{category.Name}
+	ancestorIDs := []string{}
+	seen := map[string]bool{id: true}
+	for parentID := category.ParentID; parentID != "" && !seen[parentID]; {
+		parent, ok := t.categories[parentID]
+		if !ok {
+			break
+		}
+		seen[parentID] = true
+		names = append([]string{parent.Name}, names...)
+		ancestorIDs = append([]string{parentID}, ancestorIDs...)
+		parentID = parent.ParentID
+	}
+
+	category.Path = strings.Join(names, categoryPathSeparator)
+	category.AncestorIDs = ancestorIDs
+	return category, true
+}
+
+// resolveAll resolves the given IDs, silently dropping categories that no
+// longer exist.
+func (t *categoryTree) resolveAll(ids []string) []Category {
+	categories := []Category{}
+	for _, id := range ids {
+		if category, ok := t.resolve(id); ok {
+			categories = append(categories, category)
+		}
+	}
+	return categories
+}
+
+func (t *categoryTree) all() []Category {
+	categories := make([]Category, 0, len(t.categories))
+	for id := range t.categories {
+		category, _ := t.resolve(id)
+		categories = append(categories, category)
+	}
+	sort.Slice(categories, func(i, j int) bool {
+		return categories[i].Path < categories[j].Path
+	})
+	return categories
+}
+
+func (t *categoryTree) isDescendant(id, ancestorID string) bool {
+	category, ok := t.resolve(id)
+	if !ok {
+		return false
+	}
+	for _, candidate := range category.AncestorIDs {
+		if candidate == ancestorID {
+			return true
+		}
+	}
+	return false
+}
+
+func (t *categoryTree) hasChildren(id string) bool {
+	for _, category := range t.categories {
+		if category.ParentID == id {
+			return true
+		}
+	}
+	return false
+}
+
+// categoryPathIDs lists every category a product is filed under, including
+// the ancestors of its assigned categories, so filtering on a category also
+// matches products in its subcategories.
+func categoryPathIDs(categories []Category) []string {
+	ids := []string{}
+	seen := map[string]bool{}
+	for _, category := range categories {
+		for _, id := range append(append([]string{}, category.AncestorIDs...), category.ID) {
+			if !seen[id] {
+				seen[id] = true
+				ids = append(ids, id)
+			}
+		}
+	}
+	return ids
+}
@@ -0,0 +1,355 @@
+package product
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/google/uuid"
+)
+
+const categoryIndex = "categories"
+
+// maxCategories bounds how many categories ListCategories loads. The taxonomy
+// is read in a single request, so it has to fit in one search window.
+const maxCategories = 10000
+
+// maxResultWindow is the default index.max_result_window, the furthest
+// Elasticsearch pages with from and size.
+const maxResultWindow = 10000
+
+const (
+	// categoryScanBatchSize is the page size ListAllProductsInCategory reads
+	// with, and categoryScanKeepAlive how long its point in time is kept
+	// open between pages.
+	categoryScanBatchSize = 500
+	categoryScanKeepAlive = "1m"
+)
+
+const categoryMappingProperties = `{
+	"properties": {
+		"name": {"type": "keyword"},
+		"parent_id": {"type": "keyword"}
+	}
+}`
+
+type categoryDocument struct {
+	Name     string `json:"name"`
+	ParentID string `json:"parent_id,omitempty"`
+}
+
+func (d categoryDocument) toCategory(id string) Category {
+	return Category{
+		ID:       id,
+		Name:     d.Name,
+		ParentID: d.ParentID,
+	}
+}
+
+func (r *elasticRepository) CreateCategory(ctx context.Context, name, parentID string) (*Category, error) {
+	category := Category{ID: uuid.NewString(), Name: name, ParentID: parentID}
+	if err := r.indexCategory(ctx, category); err != nil {
+		return nil, err
+	}
+
+	return &category, nil
+}
+
+func (r *elasticRepository) UpdateCategory(ctx context.Context, category Category) (*Category, error) {
+	if err := r.indexCategory(ctx, category); err != nil {
+		return nil, err
+	}
+
+	return &category, nil
+}
+
+// indexCategory waits for the refresh so that the next ListCategories call,
+// which rebuilds the tree, already sees the change.
+func (r *elasticRepository) indexCategory(ctx context.Context, category Category) error {
+	body, err := json.Marshal(categoryDocument{Name: category.Name, ParentID: category.ParentID})
+	if err != nil {
+		return err
+	}
+
+	res, err := r.client.Index(
+		categoryIndex,
+		bytes.NewReader(body),
+		r.client.Index.WithDocumentID(category.ID),
+		r.client.Index.WithRefresh("wait_for"),
+		r.client.Index.WithContext(ctx),
+	)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+
+	if res.IsError() {
+		return fmt.Errorf("failed to index category: %s", res.String())
+	}
+	return nil
+}
+
+func (r *elasticRepository) GetCategoryByID(ctx context.Context, id string) (*Category, error) {
+	res, err := r.client.Get(
+		categoryIndex,
+		id,
+		r.client.Get.WithContext(ctx),
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode == http.StatusNotFound {
+		return nil, ErrCategoryNotFound
+	}
+	if res.IsError() {
+		return nil, fmt.Errorf("failed to get category by id: %s", res.String())
+	}
+
+	var result struct {
+		Source *categoryDocument `json:"_source"`
+	}
+	if err := json.NewDecoder(res.Body).Decode(&result); err != nil {
+		return nil, err
+	}
+
+	if result.Source == nil {
+		return nil, fmt.Errorf("failed to retrieve _source for category %s", id)
+	}
+
+	category := result.Source.toCategory(id)
+	return &category, nil
+}
+
+func (r *elasticRepository) ListCategories(ctx context.Context) ([]Category, error) {
+	searchBody := fmt.Sprintf(`{
+		"size": %d,
+		"query": {
+			"match_all": {}
+		}
+	}`, maxCategories)
+	res, err := r.client.Search(
+		r.client.Search.WithContext(ctx),
+		r.client.Search.WithIndex(categoryIndex),
+		r.client.Search.WithBody(strings.NewReader(searchBody)),
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer res.Body.Close()
+
+	if res.IsError() {
+		return nil, fmt.Errorf("failed to list categories: %s", res.String())
+	}
+
+	var result struct {
+		Hits struct {
+			Hits []struct {
+				ID     string           `json:"_id"`
+				Source categoryDocument `json:"_source"`
+			} `json:"hits"`
+		} `json:"hits"`
+	}
+	if err := json.NewDecoder(res.Body).Decode(&result); err != nil {
+		return nil, err
+	}
+
+	categories := []Category{}
+	for _, hit := range result.Hits.Hits {
+		categories = append(categories, hit.Source.toCategory(hit.ID))
+	}
+
+	return categories, nil
+}
+
+func (r *elasticRepository) DeleteCategory(ctx context.Context, id string) error {
+	res, err := r.client.Delete(
+		categoryIndex,
+		id,
+		r.client.Delete.WithRefresh("wait_for"),
+		r.client.Delete.WithContext(ctx),
+	)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode == http.StatusNotFound {
+		return ErrCategoryNotFound
+	}
+	if res.IsError() {
+		return fmt.Errorf("failed to delete category: %s", res.String())
+	}
+	return nil
+}
+
+// SetProductCategories replaces the denormalized categories of a product.
+// The categories must already be resolved so that their paths and ancestors
+// are stored alongside the product.
+func (r *elasticRepository) SetProductCategories(ctx context.Context, productID string, categories []Category) (*Product, error) {
+	refs := []productCategoryDocument{}
+	for _, category := range categories {
+		refs = append(refs, productCategoryDocument{
+			ID:   category.ID,
+			Name: category.Name,
+			Path: category.Path,
+		})
+	}
+
+	body, err := json.Marshal(map[string]interface{}{
+		"doc": map[string]interface{}{
+			"categories":        refs,
+			"category_path_ids": categoryPathIDs(categories),
+		},
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	res, err := r.client.Update(
+		catalogIndex,
+		productID,
+		bytes.NewReader(body),
+		r.client.Update.WithRefresh("wait_for"),
+		r.client.Update.WithContext(ctx),
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode == http.StatusNotFound {
+		return nil, ErrProductNotFound
+	}
+	if res.IsError() {
+		return nil, fmt.Errorf("failed to update product categories: %s", res.String())
+	}
+
+	return r.GetProductByID(ctx, productID)
+}
+
+// ListProductsInCategory matches on category_path_ids, so products filed under
+// any subcategory are included as well.
+func (r *elasticRepository) ListProductsInCategory(ctx context.Context, categoryID string, offset, limit uint32) ([]Product, error) {
+	categoryIDStr, _ := json.Marshal(categoryID)
+	searchBody := fmt.Sprintf(`{
+		"from": %d,
+		"size": %d,
+		"sort": ["_doc"],
+		"query": {
+			"term": {
+				"category_path_ids": %s
+			}
+		}
+	}`, offset, limit, string(categoryIDStr))
+	res, err := r.client.Search(
+		r.client.Search.WithContext(ctx),
+		r.client.Search.WithIndex(catalogIndex),
+		r.client.Search.WithBody(strings.NewReader(searchBody)),
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer res.Body.Close()
+
+	if res.IsError() {
+		return nil, fmt.Errorf("failed to list products in category: %s", res.String())
+	}
+
+	return decodeProducts(res.Body)
+}
+
+// ListAllProductsInCategory pages through a point in time with search_after,
+// so it sees one snapshot of the index and is not bound by maxResultWindow.
+func (r *elasticRepository) ListAllProductsInCategory(ctx context.Context, categoryID string) ([]Product, error) {
+	res, err := r.client.OpenPointInTime(
+		[]string{catalogIndex},
+		categoryScanKeepAlive,
+		r.client.OpenPointInTime.WithContext(ctx),
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer res.Body.Close()
+
+	if res.IsError() {
+		return nil, fmt.Errorf("failed to open point in time: %s", res.String())
+	}
+
+	var pit struct {
+		ID string `json:"id"`
+	}
+	if err := json.NewDecoder(res.Body).Decode(&pit); err != nil {
+		return nil, err
+	}
+	defer r.closePointInTime(pit.ID)
+
+	products := []Product{}
+	var searchAfter []json.RawMessage
+	for {
+		search := map[string]interface{}{
+			"size":  categoryScanBatchSize,
+			"query": map[string]interface{}{"term": map[string]interface{}{"category_path_ids": categoryID}},
+			"pit":   map[string]interface{}{"id": pit.ID, "keep_alive": categoryScanKeepAlive},
+			"sort":  []string{"_shard_doc"},
+		}
+		if searchAfter != nil {
+			search["search_after"] = searchAfter
+		}
+		body, err := json.Marshal(search)
+		if err != nil {
+			return nil, err
+		}
+
+		res, err := r.client.Search(
+			r.client.Search.WithContext(ctx),
+			r.client.Search.WithBody(bytes.NewReader(body)),
+		)
+		if err != nil {
+			return nil, err
+		}
+
+		var result struct {
+			PitID string `json:"pit_id"`
+			Hits  struct {
+				Hits []struct {
+					ID     string            `json:"_id"`
+					Source productDocument   `json:"_source"`
+					Sort   []json.RawMessage `json:"sort"`
+				} `json:"hits"`
+			} `json:"hits"`
+		}
+		if res.IsError() {
+			err = fmt.Errorf("failed to list products in category: %s", res.String())
+		} else {
+			err = json.NewDecoder(res.Body).Decode(&result)
+		}
+		res.Body.Close()
+		if err != nil {
+			return nil, err
+		}
+
+		hits := result.Hits.Hits
+		for _, hit := range hits {
+			products = append(products, hit.Source.toProduct(hit.ID))
+		}
+		if len(hits) < categoryScanBatchSize {
+			return products, nil
+		}
+		searchAfter = hits[len(hits)-1].Sort
+		pit.ID = result.PitID
+	}
+}
+
+func (r *elasticRepository) closePointInTime(id string) {
+	body, _ := json.Marshal(map[string]string{"id": id})
+	res, err := r.client.ClosePointInTime(r.client.ClosePointInTime.WithBody(bytes.NewReader(body)))
+	if err != nil {
+		return
+	}
+	res.Body.Close()
+}
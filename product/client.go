@@ -17,9 +17,22 @@ type ProductClient struct {
 	conn    *grpc.ClientConn
 	service protobuf.ProductServiceClient
 	logger  *zap.Logger
+	timeout time.Duration
 }
 
-func NewProductClient(url string, secure bool) (*ProductClient, error) {
+// idempotentMethods are the read-only RPCs that are safe to retry.
+var idempotentMethods = []string{
+	"GetProductByID",
+	"GetProductBySKU",
+	"ListProducts",
+	"ListProductsWithIDs",
+	"SearchProducts",
+	"ListCategories",
+	"ListProductsInCategory",
+	"ListReviews",
+}
+
+func NewProductClient(url string, secure bool, config common.ClientConfig) (*ProductClient, error) {
 	logger := common.GetLogger()
 
 	var opts []grpc.DialOption
@@ -35,6 +48,8 @@ func NewProductClient(url string, secure bool) (*ProductClient, error) {
 	_, cancel := context.WithTimeout(context.Background(), 5*time.Second)
 	defer cancel()
 
+	opts = append(opts, config.DialOptions(protobuf.ProductService_ServiceDesc.ServiceName, idempotentMethods)...)
+
 	conn, err := grpc.NewClient(url, opts...)
 	if err != nil {
 		logger.Error("Failed to connect to gRPC server", zap.String("url", url), zap.String("error", err.Error()))
@@ -45,7 +60,7 @@ func NewProductClient(url string, secure bool) (*ProductClient, error) {
 
 	client := protobuf.NewProductServiceClient(conn)
 
-	return &ProductClient{conn: conn, service: client, logger: logger}, nil
+	return &ProductClient{conn: conn, service: client, logger: logger, timeout: config.Timeout}, nil
 }
 
 func (c *ProductClient) Close() error {
@@ -53,34 +68,35 @@ func (c *ProductClient) Close() error {
 	return c.conn.Close()
 }
 
-func (c *ProductClient) CreateProduct(ctx context.Context, name, description string, price float64) (*Product, error) {
-	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+func (c *ProductClient) CreateProduct(ctx context.Context, name, description string, price common.Money, variants []Variant) (*Product, error) {
+	ctx, cancel := common.WithDefaultTimeout(ctx, c.timeout)
 	defer cancel()
 
-	c.logger.Info("CreateProduct request received", zap.String("name", name), zap.String("description", description), zap.Float64("price", price))
+	c.logger.Info("CreateProduct request received", zap.String("name", name), zap.String("description", description), zap.String("price", price.String()), zap.Int("variant_count", len(variants)))
+
+	var protoVariants []*protobuf.ProductVariant
+	for _, v := range variants {
+		protoVariants = append(protoVariants, variantToProto(v))
+	}
 
 	r, err := c.service.CreateProduct(ctx, &protobuf.CreateProductRequest{
 		Name:        name,
 		Description: description,
-		Price:       price,
+		Price:       &protobuf.Money{Amount: price.Amount, CurrencyCode: price.CurrencyCode},
+		Variants:    protoVariants,
 	})
 	if err != nil {
 		c.logger.Error("Failed to create product", zap.String("name", name), zap.String("error", err.Error()))
 		return nil, err
 	}
 
-	c.logger.Info("Product created successfully", zap.String("product_id", r.GetProduct().GetId()), zap.String("name", name), zap.String("description", description), zap.Float64("price", price))
+	c.logger.Info("Product created successfully", zap.String("product_id", r.GetProduct().GetId()), zap.String("name", name), zap.String("description", description), zap.String("price", price.String()))
 
-	return &Product{
-		ID:          r.GetProduct().GetId(),
-		Name:        r.GetProduct().GetName(),
-		Description: r.GetProduct().GetDescription(),
-		Price:       r.GetProduct().GetPrice(),
-	}, nil
+	return productFromProto(r.GetProduct()), nil
 }
 
 func (c *ProductClient) GetProductByID(ctx context.Context, id string) (*Product, error) {
-	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	ctx, cancel := common.WithDefaultTimeout(ctx, c.timeout)
 	defer cancel()
 
 	c.logger.Info("GetProduct request received", zap.String("product_id", id))
@@ -93,18 +109,32 @@ func (c *ProductClient) GetProductByID(ctx context.Context, id string) (*Product
 		return nil, err
 	}
 
-	c.logger.Info("Product fetched successfully", zap.String("product_id", r.GetProduct().GetId()), zap.String("name", r.GetProduct().GetName()), zap.String("description", r.GetProduct().GetDescription()), zap.Float64("price", r.GetProduct().GetPrice()))
+	c.logger.Info("Product fetched successfully", zap.String("product_id", r.GetProduct().GetId()), zap.String("name", r.GetProduct().GetName()), zap.String("description", r.GetProduct().GetDescription()), zap.Int64("price_amount", r.GetProduct().GetPrice().GetAmount()), zap.String("price_currency", r.GetProduct().GetPrice().GetCurrencyCode()))
 
-	return &Product{
-		ID:          r.GetProduct().GetId(),
-		Name:        r.GetProduct().GetName(),
-		Description: r.GetProduct().GetDescription(),
-		Price:       r.GetProduct().GetPrice(),
-	}, nil
+	return productFromProto(r.GetProduct()), nil
+}
+
+func (c *ProductClient) GetProductBySKU(ctx context.Context, sku string) (*Product, error) {
+	ctx, cancel := common.WithDefaultTimeout(ctx, c.timeout)
+	defer cancel()
+
+	c.logger.Info("GetProductBySKU request received", zap.String("sku", sku))
+
+	r, err := c.service.GetProductBySKU(ctx, &protobuf.GetProductBySKURequest{
+		Sku: sku,
+	})
+	if err != nil {
+		c.logger.Error("Failed to fetch product by SKU", zap.String("sku", sku), zap.String("error", err.Error()))
+		return nil, err
+	}
+
+	c.logger.Info("Product fetched successfully", zap.String("product_id", r.GetProduct().GetId()), zap.String("sku", sku))
+
+	return productFromProto(r.GetProduct()), nil
 }
 
 func (c *ProductClient) ListProducts(ctx context.Context, limit, offset uint32) ([]*Product, error) {
-	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	ctx, cancel := common.WithDefaultTimeout(ctx, c.timeout)
 	defer cancel()
 
 	c.logger.Info("ListProducts request received", zap.Uint32("limit", limit), zap.Uint32("offset", offset))
@@ -120,12 +150,7 @@ func (c *ProductClient) ListProducts(ctx context.Context, limit, offset uint32)
 
 	products := make([]*Product, 0)
 	for _, p := range r.GetProducts() {
-		products = append(products, &Product{
-			ID:          p.GetId(),
-			Name:        p.GetName(),
-			Description: p.GetDescription(),
-			Price:       p.GetPrice(),
-		})
+		products = append(products, productFromProto(p))
 	}
 
 	c.logger.Info("Products fetched successfully", zap.Int("product_count", len(products)))
@@ -134,7 +159,7 @@ func (c *ProductClient) ListProducts(ctx context.Context, limit, offset uint32)
 }
 
 func (c *ProductClient) ListProductsWithIDs(ctx context.Context, ids []string, limit, offset uint32) ([]*Product, error) {
-	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	ctx, cancel := common.WithDefaultTimeout(ctx, c.timeout)
 	defer cancel()
 
 	c.logger.Info("ListProductsWithIDs request received", zap.Strings("product_ids", ids), zap.Uint32("limit", limit), zap.Uint32("offset", offset))
@@ -151,12 +176,7 @@ func (c *ProductClient) ListProductsWithIDs(ctx context.Context, ids []string, l
 
 	products := make([]*Product, 0)
 	for _, p := range r.GetProducts() {
-		products = append(products, &Product{
-			ID:          p.GetId(),
-			Name:        p.GetName(),
-			Description: p.GetDescription(),
-			Price:       p.GetPrice(),
-		})
+		products = append(products, productFromProto(p))
 	}
 
 	c.logger.Info("Products fetched successfully", zap.Int("product_count", len(products)))
@@ -164,16 +184,29 @@ func (c *ProductClient) ListProductsWithIDs(ctx context.Context, ids []string, l
 	return products, nil
 }
 
-func (c *ProductClient) SearchProducts(ctx context.Context, query string, limit, offset uint32) ([]*Product, error) {
-	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+func (c *ProductClient) SearchProducts(ctx context.Context, query string, filter ProductSearchFilter, orderBy ProductOrderField, limit, offset uint32) ([]*Product, error) {
+	ctx, cancel := common.WithDefaultTimeout(ctx, c.timeout)
 	defer cancel()
 
-	c.logger.Info("SearchProducts request received", zap.String("query", query), zap.Uint32("limit", limit), zap.Uint32("offset", offset))
+	c.logger.Info("SearchProducts request received", zap.String("query", query), zap.Int("attribute_count", len(filter.Attributes)), zap.Float64("min_rating", filter.MinRating), zap.String("order_by", string(orderBy)), zap.Uint32("limit", limit), zap.Uint32("offset", offset))
+
+	var protoAttributes []*protobuf.Attribute
+	for _, a := range filter.Attributes {
+		protoAttributes = append(protoAttributes, &protobuf.Attribute{Key: a.Key, Value: a.Value})
+	}
+
+	protoOrderBy := protobuf.ProductOrderField_PRODUCT_ORDER_FIELD_RELEVANCE
+	if orderBy == ProductOrderByRating {
+		protoOrderBy = protobuf.ProductOrderField_PRODUCT_ORDER_FIELD_RATING
+	}
 
 	r, err := c.service.SearchProducts(ctx, &protobuf.SearchProductsRequest{
-		Query:  query,
-		Limit:  limit,
-		Offset: offset,
+		Query:      query,
+		Limit:      limit,
+		Offset:     offset,
+		Attributes: protoAttributes,
+		MinRating:  filter.MinRating,
+		OrderBy:    protoOrderBy,
 	})
 	if err != nil {
 		c.logger.Error("Failed to search products", zap.String("query", query), zap.String("error", err.Error()))
@@ -182,15 +215,291 @@ func (c *ProductClient) SearchProducts(ctx context.Context, query string, limit,
 
 	products := make([]*Product, 0)
 	for _, p := range r.GetProducts() {
-		products = append(products, &Product{
-			ID:          p.GetId(),
-			Name:        p.GetName(),
-			Description: p.GetDescription(),
-			Price:       p.GetPrice(),
-		})
+		products = append(products, productFromProto(p))
 	}
 
 	c.logger.Info("Products searched successfully", zap.Int("product_count", len(products)))
 
 	return products, nil
 }
+
+func (c *ProductClient) CreateCategory(ctx context.Context, name, parentID string) (*Category, error) {
+	ctx, cancel := common.WithDefaultTimeout(ctx, c.timeout)
+	defer cancel()
+
+	c.logger.Info("CreateCategory request received", zap.String("name", name), zap.String("parent_id", parentID))
+
+	r, err := c.service.CreateCategory(ctx, &protobuf.CreateCategoryRequest{
+		Name:     name,
+		ParentId: parentID,
+	})
+	if err != nil {
+		c.logger.Error("Failed to create category", zap.String("name", name), zap.String("error", err.Error()))
+		return nil, err
+	}
+
+	c.logger.Info("Category created successfully", zap.String("category_id", r.GetCategory().GetId()), zap.String("path", r.GetCategory().GetPath()))
+
+	return categoryFromProto(r.GetCategory()), nil
+}
+
+func (c *ProductClient) RenameCategory(ctx context.Context, id, name string) (*Category, error) {
+	ctx, cancel := common.WithDefaultTimeout(ctx, c.timeout)
+	defer cancel()
+
+	c.logger.Info("RenameCategory request received", zap.String("category_id", id), zap.String("name", name))
+
+	r, err := c.service.RenameCategory(ctx, &protobuf.RenameCategoryRequest{
+		Id:   id,
+		Name: name,
+	})
+	if err != nil {
+		c.logger.Error("Failed to rename category", zap.String("category_id", id), zap.String("error", err.Error()))
+		return nil, err
+	}
+
+	c.logger.Info("Category renamed successfully", zap.String("category_id", id), zap.String("path", r.GetCategory().GetPath()))
+
+	return categoryFromProto(r.GetCategory()), nil
+}
+
+func (c *ProductClient) MoveCategory(ctx context.Context, id, parentID string) (*Category, error) {
+	ctx, cancel := common.WithDefaultTimeout(ctx, c.timeout)
+	defer cancel()
+
+	c.logger.Info("MoveCategory request received", zap.String("category_id", id), zap.String("parent_id", parentID))
+
+	r, err := c.service.MoveCategory(ctx, &protobuf.MoveCategoryRequest{
+		Id:       id,
+		ParentId: parentID,
+	})
+	if err != nil {
+		c.logger.Error("Failed to move category", zap.String("category_id", id), zap.String("error", err.Error()))
+		return nil, err
+	}
+
+	c.logger.Info("Category moved successfully", zap.String("category_id", id), zap.String("path", r.GetCategory().GetPath()))
+
+	return categoryFromProto(r.GetCategory()), nil
+}
+
+func (c *ProductClient) DeleteCategory(ctx context.Context, id string) (*Category, error) {
+	ctx, cancel := common.WithDefaultTimeout(ctx, c.timeout)
+	defer cancel()
+
+	c.logger.Info("DeleteCategory request received", zap.String("category_id", id))
+
+	r, err := c.service.DeleteCategory(ctx, &protobuf.DeleteCategoryRequest{
+		Id: id,
+	})
+	if err != nil {
+		c.logger.Error("Failed to delete category", zap.String("category_id", id), zap.String("error", err.Error()))
+		return nil, err
+	}
+
+	c.logger.Info("Category deleted successfully", zap.String("category_id", id))
+
+	return categoryFromProto(r.GetCategory()), nil
+}
+
+func (c *ProductClient) ListCategories(ctx context.Context) ([]*Category, error) {
+	ctx, cancel := common.WithDefaultTimeout(ctx, c.timeout)
+	defer cancel()
+
+	c.logger.Info("ListCategories request received")
+
+	r, err := c.service.ListCategories(ctx, &protobuf.ListCategoriesRequest{})
+	if err != nil {
+		c.logger.Error("Failed to fetch categories", zap.String("error", err.Error()))
+		return nil, err
+	}
+
+	categories := make([]*Category, 0)
+	for _, category := range r.GetCategories() {
+		categories = append(categories, categoryFromProto(category))
+	}
+
+	c.logger.Info("Categories fetched successfully", zap.Int("category_count", len(categories)))
+
+	return categories, nil
+}
+
+func (c *ProductClient) SetProductCategories(ctx context.Context, productID string, categoryIDs []string) (*Product, error) {
+	ctx, cancel := common.WithDefaultTimeout(ctx, c.timeout)
+	defer cancel()
+
+	c.logger.Info("SetProductCategories request received", zap.String("product_id", productID), zap.Strings("category_ids", categoryIDs))
+
+	r, err := c.service.SetProductCategories(ctx, &protobuf.SetProductCategoriesRequest{
+		ProductId:   productID,
+		CategoryIds: categoryIDs,
+	})
+	if err != nil {
+		c.logger.Error("Failed to set product categories", zap.String("product_id", productID), zap.String("error", err.Error()))
+		return nil, err
+	}
+
+	c.logger.Info("Product categories set successfully", zap.String("product_id", productID))
+
+	return productFromProto(r.GetProduct()), nil
+}
+
+func (c *ProductClient) ListProductsInCategory(ctx context.Context, categoryID string, limit, offset uint32) ([]*Product, error) {
+	ctx, cancel := common.WithDefaultTimeout(ctx, c.timeout)
+	defer cancel()
+
+	c.logger.Info("ListProductsInCategory request received", zap.String("category_id", categoryID), zap.Uint32("limit", limit), zap.Uint32("offset", offset))
+
+	r, err := c.service.ListProductsInCategory(ctx, &protobuf.ListProductsInCategoryRequest{
+		CategoryId: categoryID,
+		Limit:      limit,
+		Offset:     offset,
+	})
+	if err != nil {
+		c.logger.Error("Failed to fetch products in category", zap.String("category_id", categoryID), zap.String("error", err.Error()))
+		return nil, err
+	}
+
+	products := make([]*Product, 0)
+	for _, p := range r.GetProducts() {
+		products = append(products, productFromProto(p))
+	}
+
+	c.logger.Info("Products in category fetched successfully", zap.Int("product_count", len(products)))
+
+	return products, nil
+}
+
+func (c *ProductClient) SubmitReview(ctx context.Context, productID, accountID string, rating int32, text string) (*Review, error) {
+	ctx, cancel := common.WithDefaultTimeout(ctx, c.timeout)
+	defer cancel()
+
+	c.logger.Info("SubmitReview request received", zap.String("product_id", productID), zap.String("account_id", accountID), zap.Int32("rating", rating))
+
+	r, err := c.service.SubmitReview(ctx, &protobuf.SubmitReviewRequest{
+		ProductId: productID,
+		AccountId: accountID,
+		Rating:    rating,
+		Text:      text,
+	})
+	if err != nil {
+		c.logger.Error("Failed to submit review", zap.String("product_id", productID), zap.String("account_id", accountID), zap.String("error", err.Error()))
+		return nil, err
+	}
+
+	c.logger.Info("Review submitted successfully", zap.String("product_id", productID), zap.String("account_id", accountID))
+
+	return reviewFromProto(r.GetReview()), nil
+}
+
+func (c *ProductClient) ListReviews(ctx context.Context, productID string, limit, offset uint32) ([]*Review, error) {
+	ctx, cancel := common.WithDefaultTimeout(ctx, c.timeout)
+	defer cancel()
+
+	c.logger.Info("ListReviews request received", zap.String("product_id", productID), zap.Uint32("limit", limit), zap.Uint32("offset", offset))
+
+	r, err := c.service.ListReviews(ctx, &protobuf.ListReviewsRequest{
+		ProductId: productID,
+		Limit:     limit,
+		Offset:    offset,
+	})
+	if err != nil {
+		c.logger.Error("Failed to fetch reviews", zap.String("product_id", productID), zap.String("error", err.Error()))
+		return nil, err
+	}
+
+	reviews := make([]*Review, 0, len(r.GetReviews()))
+	for _, review := range r.GetReviews() {
+		reviews = append(reviews, reviewFromProto(review))
+	}
+
+	c.logger.Info("Reviews fetched successfully", zap.Int("review_count", len(reviews)))
+
+	return reviews, nil
+}
+
+// WatchProducts streams the events of the product with the given ID, or of
+// all products when productID is empty, until ctx is done or the stream
+// breaks. The returned channel is closed in both cases.
+func (c *ProductClient) WatchProducts(ctx context.Context, productID string) (<-chan ProductEvent, error) {
+	c.logger.Info("WatchProducts request received", zap.String("product_id", productID))
+
+	stream, err := c.service.WatchProducts(ctx, &protobuf.WatchProductsRequest{ProductId: productID})
+	if err != nil {
+		c.logger.Error("Failed to watch products", zap.String("product_id", productID), zap.String("error", err.Error()))
+		return nil, err
+	}
+
+	events := make(chan ProductEvent)
+	go func() {
+		defer close(events)
+		for {
+			e, err := stream.Recv()
+			if err != nil {
+				if ctx.Err() == nil {
+					c.logger.Error("Product event stream ended", zap.String("product_id", productID), zap.String("error", err.Error()))
+				}
+				return
+			}
+
+			event := ProductEvent{Type: ProductEventCreated, Product: *productFromProto(e.GetProduct())}
+			if e.GetType() == protobuf.ProductEventType_PRODUCT_EVENT_TYPE_UPDATED {
+				event.Type = ProductEventUpdated
+			}
+
+			select {
+			case events <- event:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	return events, nil
+}
+
+func productFromProto(p *protobuf.Product) *Product {
+	categories := make([]Category, 0, len(p.GetCategories()))
+	for _, c := range p.GetCategories() {
+		categories = append(categories, *categoryFromProto(c))
+	}
+
+	variants := make([]Variant, 0, len(p.GetVariants()))
+	for _, v := range p.GetVariants() {
+		variants = append(variants, variantFromProto(v))
+	}
+
+	return &Product{
+		ID:          p.GetId(),
+		Name:        p.GetName(),
+		Description: p.GetDescription(),
+		Price: common.Money{
+			Amount:       p.GetPrice().GetAmount(),
+			CurrencyCode: p.GetPrice().GetCurrencyCode(),
+		},
+		Categories:    categories,
+		Variants:      variants,
+		AverageRating: p.GetAverageRating(),
+		ReviewCount:   int32(p.GetReviewCount()),
+	}
+}
+
+func reviewFromProto(r *protobuf.Review) *Review {
+	return &Review{
+		ProductID: r.GetProductId(),
+		AccountID: r.GetAccountId(),
+		Rating:    r.GetRating(),
+		Text:      r.GetText(),
+		CreatedAt: r.GetCreatedAt().AsTime(),
+		UpdatedAt: r.GetUpdatedAt().AsTime(),
+	}
+}
+
+func categoryFromProto(c *protobuf.Category) *Category {
+	return &Category{
+		ID:       c.GetId(),
+		Name:     c.GetName(),
+		ParentID: c.GetParentId(),
+		Path:     c.GetPath(),
+	}
+}
@@ -1,6 +1,9 @@
 package main
 
 import (
+	"context"
+	"errors"
+	"graphql-grpc-go-microservice-project/common"
 	"graphql-grpc-go-microservice-project/product"
 	"log"
 	"time"
@@ -12,6 +15,20 @@ import (
 type Config struct {
 	PRODUCT_GRPC_SERVER_PORT int    `envconfig:"PRODUCT_GRPC_SERVER_PORT" default:"8080"`
 	PRODUCT_DATABASE_URL     string `envconfig:"PRODUCT_DATABASE_URL"`
+
+	PRODUCT_EVENT_BUS   string `envconfig:"PRODUCT_EVENT_BUS" default:"memory"`
+	PRODUCT_NATS_URL    string `envconfig:"PRODUCT_NATS_URL" default:"nats://localhost:4222"`
+	PRODUCT_NATS_STREAM string `envconfig:"PRODUCT_NATS_STREAM" default:"PRODUCT_EVENTS"`
+	// PRODUCT_EVENT_QUEUE_SIZE bounds the events waiting to be published.
+	PRODUCT_EVENT_QUEUE_SIZE int `envconfig:"PRODUCT_EVENT_QUEUE_SIZE" default:"1024"`
+
+	PRODUCT_RATE_LIMIT       float64 `envconfig:"PRODUCT_RATE_LIMIT" default:"100"`
+	PRODUCT_RATE_LIMIT_BURST int     `envconfig:"PRODUCT_RATE_LIMIT_BURST" default:"200"`
+	// PRODUCT_RATE_LIMIT_TRUSTED_PEERS lists the addresses of the gateway, whose
+	// calls are limited by the client they are made for.
+	PRODUCT_RATE_LIMIT_TRUSTED_PEERS []string `envconfig:"PRODUCT_RATE_LIMIT_TRUSTED_PEERS"`
+
+	PRODUCT_SHUTDOWN_TIMEOUT time.Duration `envconfig:"PRODUCT_SHUTDOWN_TIMEOUT" default:"20s"`
 }
 
 func main() {
@@ -20,6 +37,8 @@ func main() {
 		log.Fatalf("Error processing environment variables: %v", err)
 	}
 
+	lifecycle := common.NewLifecycle(cfg.PRODUCT_SHUTDOWN_TIMEOUT)
+
 	var repo product.ProductRepository
 	retry.ForeverSleep(2*time.Second, func(_ int) error {
 		var err error
@@ -29,16 +48,51 @@ func main() {
 		}
 		return err
 	})
-	defer repo.Close()
+	lifecycle.OnShutdown("product repository", func(context.Context) error {
+		repo.Close()
+		return nil
+	})
+
+	var bus common.EventBus
+	retry.ForeverSleep(2*time.Second, func(_ int) error {
+		var err error
+		bus, err = common.NewEventBus(cfg.PRODUCT_EVENT_BUS, cfg.PRODUCT_NATS_URL, common.NATSStream{
+			Name:     cfg.PRODUCT_NATS_STREAM,
+			Subjects: []string{"product.>"},
+		})
+		if errors.Is(err, common.ErrUnknownEventBus) {
+			log.Fatalf("Failed to create event bus: %v", err)
+		}
+		if err != nil {
+			log.Printf("Event bus connection failed: %v", err)
+		}
+		return err
+	})
+	lifecycle.OnClose("event bus", bus)
+
+	// Closed before the bus, so the queued events are still published.
+	publisher := common.NewAsyncPublisher(bus, cfg.PRODUCT_EVENT_QUEUE_SIZE, 5*time.Second)
+	lifecycle.OnClose("event publisher", publisher)
 
 	log.Println("Initializing product service...")
-	service, err := product.NewProductService(repo)
+	service, err := product.NewProductService(repo, publisher)
 	if err != nil {
 		log.Fatalf("Failed to create product service: %v", err)
 	}
 
+	limiter := common.NewRateLimiter(cfg.PRODUCT_RATE_LIMIT, cfg.PRODUCT_RATE_LIMIT_BURST)
+	trustedPeers, err := common.ParseTrustedPeers(cfg.PRODUCT_RATE_LIMIT_TRUSTED_PEERS)
+	if err != nil {
+		log.Fatalf("Failed to parse trusted peers: %v", err)
+	}
+
 	log.Printf("Starting gRPC server on port %d...", cfg.PRODUCT_GRPC_SERVER_PORT)
-	if err := product.ListenGRPC(service, cfg.PRODUCT_GRPC_SERVER_PORT, false); err != nil {
+	if err := product.ListenGRPC(service, cfg.PRODUCT_GRPC_SERVER_PORT, false, limiter, trustedPeers, lifecycle); err != nil {
+		lifecycle.Shutdown()
 		log.Fatalf("Failed to start gRPC server: %v", err)
 	}
+
+	if err := lifecycle.Wait(); err != nil {
+		log.Fatalf("gRPC server failed: %v", err)
+	}
 }
@@ -5,25 +5,164 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"io"
+	"net/http"
 	"strings"
 
+	"graphql-grpc-go-microservice-project/common"
+
 	"github.com/elastic/go-elasticsearch/v7"
 	"github.com/google/uuid"
 )
 
+const catalogIndex = "catalog"
+
+// legacyPriceCurrency is the currency of documents indexed before prices were
+// stored as an amount in minor units with a currency code.
+const legacyPriceCurrency = "USD"
+
+const catalogMappingProperties = `{
+	"properties": {
+		"name": {"type": "text"},
+		"description": {"type": "text"},
+		"price_amount": {"type": "long"},
+		"price_currency": {"type": "keyword"},
+		"categories": {
+			"properties": {
+				"id": {"type": "keyword"},
+				"name": {"type": "keyword"},
+				"path": {"type": "keyword"}
+			}
+		},
+		"category_path_ids": {"type": "keyword"},
+		"rating_average": {"type": "double"},
+		"review_count": {"type": "integer"},
+		"variants": {
+			"type": "nested",
+			"properties": {
+				"sku": {"type": "keyword"},
+				"price_amount": {"type": "long"},
+				"price_currency": {"type": "keyword"},
+				"attributes": {
+					"type": "nested",
+					"properties": {
+						"key": {"type": "keyword"},
+						"value": {"type": "keyword"}
+					}
+				}
+			}
+		}
+	}
+}`
+
 type productDocument struct {
-	Name        string  `json:"name"`
-	Description string  `json:"description"`
-	Price       float64 `json:"price"`
+	Name            string                    `json:"name"`
+	Description     string                    `json:"description"`
+	PriceAmount     *int64                    `json:"price_amount,omitempty"`
+	PriceCurrency   string                    `json:"price_currency,omitempty"`
+	LegacyPrice     *float64                  `json:"price,omitempty"`
+	Categories      []productCategoryDocument `json:"categories,omitempty"`
+	CategoryPathIDs []string                  `json:"category_path_ids,omitempty"`
+	Variants        []variantDocument         `json:"variants,omitempty"`
+	RatingAverage   float64                   `json:"rating_average,omitempty"`
+	ReviewCount     int32                     `json:"review_count,omitempty"`
+}
+
+type variantDocument struct {
+	SKU           string      `json:"sku"`
+	PriceAmount   int64       `json:"price_amount"`
+	PriceCurrency string      `json:"price_currency"`
+	Attributes    []Attribute `json:"attributes,omitempty"`
+}
+
+// productCategoryDocument is the copy of a category stored on each product so
+// that reads do not need to join against the categories index.
+type productCategoryDocument struct {
+	ID   string `json:"id"`
+	Name string `json:"name"`
+	Path string `json:"path"`
+}
+
+func newProductDocument(name, description string, price common.Money, variants []Variant) productDocument {
+	document := productDocument{
+		Name:          name,
+		Description:   description,
+		PriceAmount:   &price.Amount,
+		PriceCurrency: price.CurrencyCode,
+	}
+	for _, variant := range variants {
+		document.Variants = append(document.Variants, variantDocument{
+			SKU:           variant.SKU,
+			PriceAmount:   variant.Price.Amount,
+			PriceCurrency: variant.Price.CurrencyCode,
+			Attributes:    variant.Attributes,
+		})
+	}
+	return document
+}
+
+func (d productDocument) toProduct(id string) Product {
+	price := common.Money{CurrencyCode: d.PriceCurrency}
+	switch {
+	case d.PriceAmount != nil:
+		price.Amount = *d.PriceAmount
+	case d.LegacyPrice != nil:
+		price = common.MoneyFromFloat(*d.LegacyPrice, legacyPriceCurrency)
+	}
+
+	categories := []Category{}
+	for _, category := range d.Categories {
+		categories = append(categories, Category{
+			ID:   category.ID,
+			Name: category.Name,
+			Path: category.Path,
+		})
+	}
+
+	variants := []Variant{}
+	for _, variant := range d.Variants {
+		attributes := variant.Attributes
+		if attributes == nil {
+			attributes = []Attribute{}
+		}
+		variants = append(variants, Variant{
+			SKU:        variant.SKU,
+			Price:      common.Money{Amount: variant.PriceAmount, CurrencyCode: variant.PriceCurrency},
+			Attributes: attributes,
+		})
+	}
+
+	return Product{
+		ID:            id,
+		Name:          d.Name,
+		Description:   d.Description,
+		Price:         price,
+		Categories:    categories,
+		Variants:      variants,
+		AverageRating: d.RatingAverage,
+		ReviewCount:   d.ReviewCount,
+	}
 }
 
 type ProductRepository interface {
 	Close()
-	CreateProduct(ctx context.Context, name, description string, price float64) (*Product, error)
+	CreateProduct(ctx context.Context, name, description string, price common.Money, variants []Variant) (*Product, error)
 	GetProductByID(ctx context.Context, id string) (*Product, error)
+	GetProductBySKU(ctx context.Context, sku string) (*Product, error)
 	ListProducts(ctx context.Context, offset, limit uint32) ([]Product, error)
 	ListProductsWithIDs(ctx context.Context, ids []string) ([]Product, error)
-	SearchProducts(ctx context.Context, query string, offset, limit uint32) ([]Product, error)
+	SearchProducts(ctx context.Context, query string, filter ProductSearchFilter, orderBy ProductOrderField, offset, limit uint32) ([]Product, error)
+	SetProductCategories(ctx context.Context, productID string, categories []Category) (*Product, error)
+	ListProductsInCategory(ctx context.Context, categoryID string, offset, limit uint32) ([]Product, error)
+	ListAllProductsInCategory(ctx context.Context, categoryID string) ([]Product, error)
+	CreateCategory(ctx context.Context, name, parentID string) (*Category, error)
+	GetCategoryByID(ctx context.Context, id string) (*Category, error)
+	ListCategories(ctx context.Context) ([]Category, error)
+	UpdateCategory(ctx context.Context, category Category) (*Category, error)
+	DeleteCategory(ctx context.Context, id string) error
+	SubmitReview(ctx context.Context, review Review) (*Review, error)
+	ListReviews(ctx context.Context, productID string, offset, limit uint32) ([]Review, error)
+	RefreshProductRating(ctx context.Context, productID string) (*Product, error)
 }
 
 type elasticRepository struct {
@@ -38,50 +177,104 @@ func NewElasticRepository(url string) (ProductRepository, error) {
 	if err != nil {
 		return nil, err
 	}
-	return &elasticRepository{client: client}, nil
+
+	repository := &elasticRepository{client: client}
+	if err := repository.ensureIndex(context.Background(), catalogIndex, catalogMappingProperties); err != nil {
+		return nil, err
+	}
+	if err := repository.ensureIndex(context.Background(), categoryIndex, categoryMappingProperties); err != nil {
+		return nil, err
+	}
+	if err := repository.ensureIndex(context.Background(), reviewIndex, reviewMappingProperties); err != nil {
+		return nil, err
+	}
+	if err := repository.ensureIndex(context.Background(), skuIndex, skuMappingProperties); err != nil {
+		return nil, err
+	}
+	return repository, nil
+}
+
+// ensureIndex creates an index with its mapping, or adds any missing fields to
+// the mapping of an index created by an older version.
+func (r *elasticRepository) ensureIndex(ctx context.Context, index, properties string) error {
+	res, err := r.client.Indices.Exists(
+		[]string{index},
+		r.client.Indices.Exists.WithContext(ctx),
+	)
+	if err != nil {
+		return err
+	}
+	res.Body.Close()
+
+	if res.StatusCode == http.StatusNotFound {
+		res, err = r.client.Indices.Create(
+			index,
+			r.client.Indices.Create.WithBody(strings.NewReader(`{"mappings": `+properties+`}`)),
+			r.client.Indices.Create.WithContext(ctx),
+		)
+	} else {
+		res, err = r.client.Indices.PutMapping(
+			strings.NewReader(properties),
+			r.client.Indices.PutMapping.WithIndex(index),
+			r.client.Indices.PutMapping.WithContext(ctx),
+		)
+	}
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+
+	if res.IsError() {
+		return fmt.Errorf("failed to prepare %s index: %s", index, res.String())
+	}
+	return nil
 }
 
 func (r *elasticRepository) Close() {}
 
-func (r *elasticRepository) CreateProduct(ctx context.Context, name, description string, price float64) (*Product, error) {
+// CreateProduct claims the SKUs of the variants before it indexes the
+// product, and returns a SKUTakenError when another product holds any of them.
+func (r *elasticRepository) CreateProduct(ctx context.Context, name, description string, price common.Money, variants []Variant) (*Product, error) {
 	productID := uuid.NewString()
-	product := productDocument{
-		Name:        name,
-		Description: description,
-		Price:       price,
-	}
+	product := newProductDocument(name, description, price, variants)
 
 	body, err := json.Marshal(product)
 	if err != nil {
 		return nil, err
 	}
 
+	skus := make([]string, 0, len(variants))
+	for _, variant := range variants {
+		skus = append(skus, variant.SKU)
+	}
+	if err := r.claimSKUs(ctx, productID, skus); err != nil {
+		return nil, err
+	}
+
 	res, err := r.client.Index(
-		"catalog",
+		catalogIndex,
 		bytes.NewReader(body),
 		r.client.Index.WithDocumentID(productID),
 		r.client.Index.WithContext(ctx),
 	)
 	if err != nil {
+		r.releaseSKUs(skus)
 		return nil, err
 	}
 	defer res.Body.Close()
 
 	if res.IsError() {
+		r.releaseSKUs(skus)
 		return nil, fmt.Errorf("failed to index product: %s", res.String())
 	}
 
-	return &Product{
-		ID:          productID,
-		Name:        name,
-		Description: description,
-		Price:       price,
-	}, nil
+	created := product.toProduct(productID)
+	return &created, nil
 }
 
 func (r *elasticRepository) GetProductByID(ctx context.Context, id string) (*Product, error) {
 	res, err := r.client.Get(
-		"catalog",
+		catalogIndex,
 		id,
 		r.client.Get.WithContext(ctx),
 	)
@@ -90,32 +283,26 @@ func (r *elasticRepository) GetProductByID(ctx context.Context, id string) (*Pro
 	}
 	defer res.Body.Close()
 
+	if res.StatusCode == http.StatusNotFound {
+		return nil, ErrProductNotFound
+	}
 	if res.IsError() {
 		return nil, fmt.Errorf("failed to get product by id: %s", res.String())
 	}
 
-	var result map[string]interface{}
+	var result struct {
+		Source *productDocument `json:"_source"`
+	}
 	if err := json.NewDecoder(res.Body).Decode(&result); err != nil {
 		return nil, err
 	}
 
-	source, ok := result["_source"].(map[string]interface{})
-	if !ok {
+	if result.Source == nil {
 		return nil, fmt.Errorf("failed to retrieve _source for document %s", id)
 	}
 
-	p := productDocument{
-		Name:        source["name"].(string),
-		Description: source["description"].(string),
-		Price:       source["price"].(float64),
-	}
-
-	return &Product{
-		ID:          id,
-		Name:        p.Name,
-		Description: p.Description,
-		Price:       p.Price,
-	}, nil
+	product := result.Source.toProduct(id)
+	return &product, nil
 }
 
 func (r *elasticRepository) ListProducts(ctx context.Context, offset, limit uint32) ([]Product, error) {
@@ -129,7 +316,7 @@ func (r *elasticRepository) ListProducts(ctx context.Context, offset, limit uint
 	searchBody := fmt.Sprintf(query, offset, limit)
 	res, err := r.client.Search(
 		r.client.Search.WithContext(ctx),
-		r.client.Search.WithIndex("catalog"),
+		r.client.Search.WithIndex(catalogIndex),
 		r.client.Search.WithBody(strings.NewReader(searchBody)),
 	)
 	if err != nil {
@@ -141,31 +328,14 @@ func (r *elasticRepository) ListProducts(ctx context.Context, offset, limit uint
 		return nil, fmt.Errorf("failed to list products: %s", res.String())
 	}
 
-	var result map[string]interface{}
-	if err := json.NewDecoder(res.Body).Decode(&result); err != nil {
-		return nil, err
-	}
-
-	products := []Product{}
-	hits := result["hits"].(map[string]interface{})["hits"].([]interface{})
-	for _, hit := range hits {
-		source := hit.(map[string]interface{})["_source"]
-		product := Product{
-			ID:          hit.(map[string]interface{})["_id"].(string),
-			Name:        source.(map[string]interface{})["name"].(string),
-			Description: source.(map[string]interface{})["description"].(string),
-			Price:       source.(map[string]interface{})["price"].(float64),
-		}
-		products = append(products, product)
-	}
-
-	return products, nil
+	return decodeProducts(res.Body)
 }
 
 func (r *elasticRepository) ListProductsWithIDs(ctx context.Context, ids []string) ([]Product, error) {
 	var buf bytes.Buffer
 
 	query := `{
+        "size": %d,
         "query": {
             "ids": {
                 "values": %s
@@ -174,13 +344,13 @@ func (r *elasticRepository) ListProductsWithIDs(ctx context.Context, ids []strin
     }`
 
 	idsStr, _ := json.Marshal(ids)
-	searchBody := fmt.Sprintf(query, string(idsStr))
+	searchBody := fmt.Sprintf(query, len(ids), string(idsStr))
 
 	buf.WriteString(searchBody)
 
 	res, err := r.client.Search(
 		r.client.Search.WithContext(ctx),
-		r.client.Search.WithIndex("catalog"),
+		r.client.Search.WithIndex(catalogIndex),
 		r.client.Search.WithBody(&buf),
 	)
 	if err != nil {
@@ -192,47 +362,95 @@ func (r *elasticRepository) ListProductsWithIDs(ctx context.Context, ids []strin
 		return nil, fmt.Errorf("failed to retrieve products by IDs: %s", res.String())
 	}
 
-	var result map[string]interface{}
-	if err := json.NewDecoder(res.Body).Decode(&result); err != nil {
+	return decodeProducts(res.Body)
+}
+
+func (r *elasticRepository) GetProductBySKU(ctx context.Context, sku string) (*Product, error) {
+	searchBody, err := json.Marshal(map[string]interface{}{
+		"size": 1,
+		"query": map[string]interface{}{
+			"nested": map[string]interface{}{
+				"path": "variants",
+				"query": map[string]interface{}{
+					"term": map[string]interface{}{"variants.sku": sku},
+				},
+			},
+		},
+	})
+	if err != nil {
 		return nil, err
 	}
 
-	products := []Product{}
-	hits := result["hits"].(map[string]interface{})["hits"].([]interface{})
-	for _, hit := range hits {
-		source := hit.(map[string]interface{})["_source"]
-		product := Product{
-			ID:          hit.(map[string]interface{})["_id"].(string),
-			Name:        source.(map[string]interface{})["name"].(string),
-			Description: source.(map[string]interface{})["description"].(string),
-			Price:       source.(map[string]interface{})["price"].(float64),
-		}
-		products = append(products, product)
+	res, err := r.client.Search(
+		r.client.Search.WithContext(ctx),
+		r.client.Search.WithIndex(catalogIndex),
+		r.client.Search.WithBody(bytes.NewReader(searchBody)),
+	)
+	if err != nil {
+		return nil, err
 	}
+	defer res.Body.Close()
 
-	return products, nil
+	if res.IsError() {
+		return nil, fmt.Errorf("failed to get product by sku: %s", res.String())
+	}
+
+	products, err := decodeProducts(res.Body)
+	if err != nil {
+		return nil, err
+	}
+	if len(products) == 0 {
+		return nil, ErrProductNotFound
+	}
+
+	return &products[0], nil
 }
 
-func (r *elasticRepository) SearchProducts(ctx context.Context, query string, offset, limit uint32) ([]Product, error) {
-	var buf bytes.Buffer
+func (r *elasticRepository) SearchProducts(ctx context.Context, query string, filter ProductSearchFilter, orderBy ProductOrderField, offset, limit uint32) ([]Product, error) {
+	must := []interface{}{}
+	if query != "" {
+		must = append(must, map[string]interface{}{
+			"multi_match": map[string]interface{}{
+				"query":  query,
+				"fields": []string{"name", "description"},
+			},
+		})
+	}
+	if len(filter.Attributes) > 0 {
+		must = append(must, variantAttributesQuery(filter.Attributes))
+	}
+	if filter.MinRating > 0 {
+		must = append(must, map[string]interface{}{
+			"range": map[string]interface{}{
+				"rating_average": map[string]interface{}{"gte": filter.MinRating},
+			},
+		})
+	}
 
-	searchQuery := `{
-		"from": %d,
-		"size": %d,
-		"query": {
-			"multi_match": {
-				"query": "%s",
-				"fields": ["name", "description"]
-			}
+	search := map[string]interface{}{
+		"from":  offset,
+		"size":  limit,
+		"query": map[string]interface{}{"bool": map[string]interface{}{"must": must}},
+	}
+	if orderBy == ProductOrderByRating {
+		// Products without reviews come last; ties are broken by how many
+		// reviews back the average, then by relevance.
+		search["sort"] = []interface{}{
+			map[string]interface{}{"rating_average": map[string]interface{}{"order": "desc", "missing": "_last"}},
+			map[string]interface{}{"review_count": map[string]interface{}{"order": "desc", "missing": "_last"}},
+			"_score",
 		}
-	}`
-	searchBody := fmt.Sprintf(searchQuery, offset, limit, query)
-	buf.WriteString(searchBody)
+	}
+
+	searchBody, err := json.Marshal(search)
+	if err != nil {
+		return nil, err
+	}
 
 	res, err := r.client.Search(
 		r.client.Search.WithContext(ctx),
-		r.client.Search.WithIndex("catalog"),
-		r.client.Search.WithBody(&buf),
+		r.client.Search.WithIndex(catalogIndex),
+		r.client.Search.WithBody(bytes.NewReader(searchBody)),
 	)
 	if err != nil {
 		return nil, err
@@ -243,22 +461,47 @@ func (r *elasticRepository) SearchProducts(ctx context.Context, query string, of
 		return nil, fmt.Errorf("failed to search products: %s", res.String())
 	}
 
-	var result map[string]interface{}
-	if err := json.NewDecoder(res.Body).Decode(&result); err != nil {
+	return decodeProducts(res.Body)
+}
+
+// variantAttributesQuery matches products that have one variant carrying every
+// given attribute, so color=red and size=M does not match a product that is
+// only sold as a red S and a blue M.
+func variantAttributesQuery(attributes []Attribute) map[string]interface{} {
+	must := []interface{}{}
+	for _, attribute := range attributes {
+		must = append(must, map[string]interface{}{
+			"nested": map[string]interface{}{
+				"path": "variants.attributes",
+				"query": map[string]interface{}{
+					"bool": map[string]interface{}{
+						"must": []interface{}{
+							map[string]interface{}{"term": map[string]interface{}{"variants.attributes.key": attribute.Key}},
+							map[string]interface{}{"term": map[string]interface{}{"variants.attributes.value": attribute.Value}},
+						},
+					},
+				},
+			},
+		})
+	}
+
+	return map[string]interface{}{
+		"nested": map[string]interface{}{
+			"path":  "variants",
+			"query": map[string]interface{}{"bool": map[string]interface{}{"must": must}},
+		},
+	}
+}
+
+func decodeProducts(body io.Reader) ([]Product, error) {
+	var result SearchResponse
+	if err := json.NewDecoder(body).Decode(&result); err != nil {
 		return nil, err
 	}
 
 	products := []Product{}
-	hits := result["hits"].(map[string]interface{})["hits"].([]interface{})
-	for _, hit := range hits {
-		source := hit.(map[string]interface{})["_source"]
-		product := Product{
-			ID:          hit.(map[string]interface{})["_id"].(string),
-			Name:        source.(map[string]interface{})["name"].(string),
-			Description: source.(map[string]interface{})["description"].(string),
-			Price:       source.(map[string]interface{})["price"].(float64),
-		}
-		products = append(products, product)
+	for _, hit := range result.Hits.Hits {
+		products = append(products, hit.Source.toProduct(hit.ID))
 	}
 
 	return products, nil
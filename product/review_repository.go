@@ -0,0 +1,304 @@
+package product
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+)
+
+const reviewIndex = "reviews"
+
+const reviewMappingProperties = `{
+	"properties": {
+		"product_id": {"type": "keyword"},
+		"account_id": {"type": "keyword"},
+		"rating": {"type": "integer"},
+		"text": {"type": "text"},
+		"created_at": {"type": "date"},
+		"updated_at": {"type": "date"}
+	}
+}`
+
+type reviewDocument struct {
+	ProductID string    `json:"product_id"`
+	AccountID string    `json:"account_id"`
+	Rating    int32     `json:"rating"`
+	Text      string    `json:"text"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+func (d reviewDocument) toReview() Review {
+	return Review(d)
+}
+
+// reviewID derives the document ID from the product and the account, which
+// makes a second review by the same account replace the first.
+func reviewID(productID, accountID string) string {
+	return productID + ":" + accountID
+}
+
+// SubmitReview creates the account's review of a product or replaces its
+// rating and text, keeping the original creation time. It waits for the
+// refresh so that RefreshProductRating already counts the review.
+func (r *elasticRepository) SubmitReview(ctx context.Context, review Review) (*Review, error) {
+	now := time.Now().UTC()
+	body, err := json.Marshal(map[string]interface{}{
+		"doc": map[string]interface{}{
+			"rating":     review.Rating,
+			"text":       review.Text,
+			"updated_at": now,
+		},
+		"upsert": reviewDocument{
+			ProductID: review.ProductID,
+			AccountID: review.AccountID,
+			Rating:    review.Rating,
+			Text:      review.Text,
+			CreatedAt: now,
+			UpdatedAt: now,
+		},
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	id := reviewID(review.ProductID, review.AccountID)
+	res, err := r.client.Update(
+		reviewIndex,
+		id,
+		bytes.NewReader(body),
+		r.client.Update.WithRefresh("wait_for"),
+		r.client.Update.WithSource("true"),
+		r.client.Update.WithContext(ctx),
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer res.Body.Close()
+
+	if res.IsError() {
+		return nil, fmt.Errorf("failed to submit review: %s", res.String())
+	}
+
+	var result struct {
+		Get struct {
+			Source *reviewDocument `json:"_source"`
+		} `json:"get"`
+	}
+	if err := json.NewDecoder(res.Body).Decode(&result); err != nil {
+		return nil, err
+	}
+
+	if result.Get.Source == nil {
+		return nil, fmt.Errorf("failed to retrieve _source for review %s", id)
+	}
+
+	submitted := result.Get.Source.toReview()
+	return &submitted, nil
+}
+
+// ListReviews returns the reviews of a product, most recently updated first.
+func (r *elasticRepository) ListReviews(ctx context.Context, productID string, offset, limit uint32) ([]Review, error) {
+	searchBody, err := json.Marshal(map[string]interface{}{
+		"from": offset,
+		"size": limit,
+		"sort": []interface{}{
+			map[string]interface{}{"updated_at": "desc"},
+			map[string]interface{}{"account_id": "asc"},
+		},
+		"query": map[string]interface{}{
+			"term": map[string]interface{}{"product_id": productID},
+		},
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	res, err := r.client.Search(
+		r.client.Search.WithContext(ctx),
+		r.client.Search.WithIndex(reviewIndex),
+		r.client.Search.WithBody(bytes.NewReader(searchBody)),
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer res.Body.Close()
+
+	if res.IsError() {
+		return nil, fmt.Errorf("failed to list reviews: %s", res.String())
+	}
+
+	var result struct {
+		Hits struct {
+			Hits []struct {
+				Source reviewDocument `json:"_source"`
+			} `json:"hits"`
+		} `json:"hits"`
+	}
+	if err := json.NewDecoder(res.Body).Decode(&result); err != nil {
+		return nil, err
+	}
+
+	reviews := []Review{}
+	for _, hit := range result.Hits.Hits {
+		reviews = append(reviews, hit.Source.toReview())
+	}
+
+	return reviews, nil
+}
+
+// ratingRefreshAttempts bounds how often RefreshProductRating starts over
+// after the product changed while it was counting.
+const ratingRefreshAttempts = 5
+
+// RefreshProductRating recomputes the average rating and review count of a
+// product from its reviews and stores them on the catalog document, where
+// SearchProducts filters and sorts on them. The result is only stored if the
+// document did not change since the count started, and the count is redone
+// otherwise, so that a refresh which counted fewer reviews cannot overwrite
+// one that counted more.
+func (r *elasticRepository) RefreshProductRating(ctx context.Context, productID string) (*Product, error) {
+	for attempt := 1; ; attempt++ {
+		seqNo, primaryTerm, err := r.productVersion(ctx, productID)
+		if err != nil {
+			return nil, err
+		}
+
+		average, count, err := r.aggregateRating(ctx, productID)
+		if err != nil {
+			return nil, err
+		}
+
+		stored, err := r.storeRating(ctx, productID, average, count, seqNo, primaryTerm)
+		if err != nil {
+			return nil, err
+		}
+		if stored {
+			return r.GetProductByID(ctx, productID)
+		}
+		if attempt == ratingRefreshAttempts {
+			return nil, fmt.Errorf("failed to update product rating: product kept changing")
+		}
+	}
+}
+
+// productVersion returns the sequence number and primary term of the catalog
+// document of a product.
+func (r *elasticRepository) productVersion(ctx context.Context, productID string) (int, int, error) {
+	res, err := r.client.Get(
+		catalogIndex,
+		productID,
+		r.client.Get.WithSource("false"),
+		r.client.Get.WithContext(ctx),
+	)
+	if err != nil {
+		return 0, 0, err
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode == http.StatusNotFound {
+		return 0, 0, ErrProductNotFound
+	}
+	if res.IsError() {
+		return 0, 0, fmt.Errorf("failed to get product by id: %s", res.String())
+	}
+
+	var result struct {
+		SeqNo       int `json:"_seq_no"`
+		PrimaryTerm int `json:"_primary_term"`
+	}
+	if err := json.NewDecoder(res.Body).Decode(&result); err != nil {
+		return 0, 0, err
+	}
+	return result.SeqNo, result.PrimaryTerm, nil
+}
+
+func (r *elasticRepository) aggregateRating(ctx context.Context, productID string) (float64, int64, error) {
+	searchBody, err := json.Marshal(map[string]interface{}{
+		"size": 0,
+		"query": map[string]interface{}{
+			"term": map[string]interface{}{"product_id": productID},
+		},
+		"aggs": map[string]interface{}{
+			"average_rating": map[string]interface{}{
+				"avg": map[string]interface{}{"field": "rating"},
+			},
+		},
+	})
+	if err != nil {
+		return 0, 0, err
+	}
+
+	res, err := r.client.Search(
+		r.client.Search.WithContext(ctx),
+		r.client.Search.WithIndex(reviewIndex),
+		r.client.Search.WithBody(bytes.NewReader(searchBody)),
+		r.client.Search.WithTrackTotalHits(true),
+	)
+	if err != nil {
+		return 0, 0, err
+	}
+	defer res.Body.Close()
+
+	if res.IsError() {
+		return 0, 0, fmt.Errorf("failed to aggregate reviews: %s", res.String())
+	}
+
+	var result struct {
+		Hits struct {
+			Total HitsTotal `json:"total"`
+		} `json:"hits"`
+		Aggregations struct {
+			AverageRating struct {
+				Value *float64 `json:"value"`
+			} `json:"average_rating"`
+		} `json:"aggregations"`
+	}
+	if err := json.NewDecoder(res.Body).Decode(&result); err != nil {
+		return 0, 0, err
+	}
+
+	average := 0.0
+	if result.Aggregations.AverageRating.Value != nil {
+		average = *result.Aggregations.AverageRating.Value
+	}
+	return average, result.Hits.Total.Value, nil
+}
+
+// storeRating reports false when the document no longer has the given
+// sequence number and primary term.
+func (r *elasticRepository) storeRating(ctx context.Context, productID string, average float64, count int64, seqNo, primaryTerm int) (bool, error) {
+	body, err := json.Marshal(map[string]interface{}{
+		"doc": map[string]interface{}{
+			"rating_average": average,
+			"review_count":   count,
+		},
+	})
+	if err != nil {
+		return false, err
+	}
+
+	res, err := r.client.Update(
+		catalogIndex,
+		productID,
+		bytes.NewReader(body),
+		r.client.Update.WithIfSeqNo(seqNo),
+		r.client.Update.WithIfPrimaryTerm(primaryTerm),
+		r.client.Update.WithContext(ctx),
+	)
+	if err != nil {
+		return false, err
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode == http.StatusConflict {
+		return false, nil
+	}
+	if res.IsError() {
+		return false, fmt.Errorf("failed to update product rating: %s", res.String())
+	}
+	return true, nil
+}
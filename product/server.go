@@ -3,13 +3,12 @@ package product
 import (
 	"context"
 	"crypto/tls"
+	"errors"
 	"fmt"
 	"graphql-grpc-go-microservice-project/common"
 	"graphql-grpc-go-microservice-project/product/protobuf"
 	"net"
-	"os"
-	"os/signal"
-	"syscall"
+	"net/netip"
 	"time"
 
 	"go.uber.org/zap"
@@ -18,6 +17,7 @@ import (
 	"google.golang.org/grpc/credentials/insecure"
 	"google.golang.org/grpc/keepalive"
 	"google.golang.org/grpc/reflection"
+	"google.golang.org/protobuf/types/known/timestamppb"
 
 	grpcResponseCodes "google.golang.org/grpc/codes"
 	grpcResponseStatus "google.golang.org/grpc/status"
@@ -29,7 +29,11 @@ type productGrpcServer struct {
 	logger  *zap.Logger
 }
 
-func ListenGRPC(s ProductService, port int, secure bool) error {
+// ListenGRPC serves the service on port in the background until lifecycle
+// shuts down. limiter bounds the calls of each caller; a nil limiter disables
+// rate limiting. Calls from trustedPeers are limited by the client they are
+// made for, see common.ContextWithRateLimitKey.
+func ListenGRPC(s ProductService, port int, secure bool, limiter *common.RateLimiter, trustedPeers []netip.Prefix, lifecycle *common.Lifecycle) error {
 	logger := common.GetLogger()
 
 	lis, err := net.Listen("tcp", fmt.Sprintf(":%d", port))
@@ -44,6 +48,10 @@ func ListenGRPC(s ProductService, port int, secure bool) error {
 	}
 
 	opts = append(opts, grpc.KeepaliveParams(keepAliveParams))
+	opts = append(opts,
+		grpc.ChainUnaryInterceptor(common.RateLimitUnaryInterceptor(limiter, trustedPeers)),
+		grpc.ChainStreamInterceptor(common.RateLimitStreamInterceptor(limiter, trustedPeers)),
+	)
 
 	if secure {
 		creds := credentials.NewTLS(&tls.Config{
@@ -63,48 +71,34 @@ func ListenGRPC(s ProductService, port int, secure bool) error {
 	protobuf.RegisterProductServiceServer(serv, productServer)
 	reflection.Register(serv)
 
-	errChan := make(chan error)
-	go func() {
-		if err := serv.Serve(lis); err != nil {
-			errChan <- fmt.Errorf("failed to serve gRPC server: %v", err)
-		}
-	}()
-
-	signalChan := make(chan os.Signal, 1)
-	signal.Notify(signalChan, os.Interrupt, syscall.SIGTERM)
-
-	select {
-	case sig := <-signalChan:
-		logger.Info("Received signal, shutting down gRPC server", zap.String("signal", sig.String()))
-		serv.GracefulStop()
-	case err := <-errChan:
-		return err
-	}
-
+	lifecycle.ServeGRPC("gRPC server", serv, lis)
 	return nil
 }
 
 func (s *productGrpcServer) CreateProduct(ctx context.Context, r *protobuf.CreateProductRequest) (*protobuf.CreateProductResponse, error) {
-	s.logger.Info("CreateProduct request received", zap.String("name", r.Name), zap.String("description", r.Description), zap.Float64("price", r.Price))
+	s.logger.Info("CreateProduct request received", zap.String("name", r.Name), zap.String("description", r.Description), zap.Int64("price_amount", r.GetPrice().GetAmount()), zap.String("price_currency", r.GetPrice().GetCurrencyCode()))
 
-	p, err := s.service.CreateProduct(ctx, r.Name, r.Description, r.Price)
+	var variants []Variant
+	for _, v := range r.GetVariants() {
+		variants = append(variants, variantFromProto(v))
+	}
+
+	p, err := s.service.CreateProduct(ctx, r.Name, r.Description, common.Money{
+		Amount:       r.GetPrice().GetAmount(),
+		CurrencyCode: r.GetPrice().GetCurrencyCode(),
+	}, variants)
 	if err != nil {
 		s.logger.Error("Failed to create product", zap.String("name", r.Name), zap.String("error", err.Error()))
 		return &protobuf.CreateProductResponse{
 			Result: &protobuf.CreateProductResponse_Error{Error: err.Error()},
-		}, grpcResponseStatus.Errorf(grpcResponseCodes.Internal, err.Error())
+		}, productError(err)
 	}
 
-	s.logger.Info("Product created successfully", zap.String("name", r.Name), zap.String("description", r.Description), zap.Float64("price", r.Price))
+	s.logger.Info("Product created successfully", zap.String("name", r.Name), zap.String("description", r.Description), zap.Int64("price_amount", p.Price.Amount), zap.String("price_currency", p.Price.CurrencyCode))
 
 	return &protobuf.CreateProductResponse{
 		Result: &protobuf.CreateProductResponse_Product{
-			Product: &protobuf.Product{
-				Id:          p.ID,
-				Name:        p.Name,
-				Description: p.Description,
-				Price:       p.Price,
-			},
+			Product: productToProto(p),
 		},
 	}, nil
 }
@@ -120,16 +114,31 @@ func (s *productGrpcServer) GetProductByID(ctx context.Context, r *protobuf.GetP
 		}, grpcResponseStatus.Errorf(grpcResponseCodes.Internal, err.Error())
 	}
 
-	s.logger.Info("Product fetched successfully", zap.String("name", p.ID), zap.String("name", p.Name), zap.String("description", p.Description), zap.Float64("price", p.Price))
+	s.logger.Info("Product fetched successfully", zap.String("name", p.ID), zap.String("name", p.Name), zap.String("description", p.Description), zap.Int64("price_amount", p.Price.Amount), zap.String("price_currency", p.Price.CurrencyCode))
 
 	return &protobuf.GetProductByIDResponse{
 		Result: &protobuf.GetProductByIDResponse_Product{
-			Product: &protobuf.Product{
-				Id:          p.ID,
-				Name:        p.Name,
-				Description: p.Description,
-				Price:       p.Price,
-			},
+			Product: productToProto(p),
+		},
+	}, nil
+}
+
+func (s *productGrpcServer) GetProductBySKU(ctx context.Context, r *protobuf.GetProductBySKURequest) (*protobuf.GetProductBySKUResponse, error) {
+	s.logger.Info("GetProductBySKU request received", zap.String("sku", r.Sku))
+
+	p, err := s.service.GetProductBySKU(ctx, r.Sku)
+	if err != nil {
+		s.logger.Error("Failed to fetch product by SKU", zap.String("sku", r.Sku), zap.String("error", err.Error()))
+		return &protobuf.GetProductBySKUResponse{
+			Result: &protobuf.GetProductBySKUResponse_Error{Error: err.Error()},
+		}, productError(err)
+	}
+
+	s.logger.Info("Product fetched successfully", zap.String("product_id", p.ID), zap.String("sku", r.Sku))
+
+	return &protobuf.GetProductBySKUResponse{
+		Result: &protobuf.GetProductBySKUResponse_Product{
+			Product: productToProto(p),
 		},
 	}, nil
 }
@@ -147,12 +156,7 @@ func (s *productGrpcServer) ListProducts(ctx context.Context, r *protobuf.ListPr
 
 	var products []*protobuf.Product
 	for _, p := range p {
-		products = append(products, &protobuf.Product{
-			Id:          p.ID,
-			Name:        p.Name,
-			Description: p.Description,
-			Price:       p.Price,
-		})
+		products = append(products, productToProto(&p))
 	}
 
 	s.logger.Info("Products listed successfully", zap.Int("count", len(p)))
@@ -172,12 +176,7 @@ func (s *productGrpcServer) ListProductsWithIDs(ctx context.Context, r *protobuf
 
 	var products []*protobuf.Product
 	for _, p := range p {
-		products = append(products, &protobuf.Product{
-			Id:          p.ID,
-			Name:        p.Name,
-			Description: p.Description,
-			Price:       p.Price,
-		})
+		products = append(products, productToProto(&p))
 	}
 
 	s.logger.Info("Products listed successfully", zap.Int("count", len(p)))
@@ -185,26 +184,320 @@ func (s *productGrpcServer) ListProductsWithIDs(ctx context.Context, r *protobuf
 }
 
 func (s *productGrpcServer) SearchProducts(ctx context.Context, r *protobuf.SearchProductsRequest) (*protobuf.SearchProductsResponse, error) {
-	s.logger.Info("SearchProducts request received", zap.String("query", r.Query), zap.Uint32("offset", r.Offset), zap.Uint32("limit", r.Limit))
+	s.logger.Info("SearchProducts request received", zap.String("query", r.Query), zap.Int("attribute_count", len(r.Attributes)), zap.Float64("min_rating", r.MinRating), zap.String("order_by", r.OrderBy.String()), zap.Uint32("offset", r.Offset), zap.Uint32("limit", r.Limit))
+
+	filter := ProductSearchFilter{MinRating: r.MinRating}
+	for _, a := range r.GetAttributes() {
+		filter.Attributes = append(filter.Attributes, Attribute{Key: a.GetKey(), Value: a.GetValue()})
+	}
+
+	orderBy := ProductOrderByRelevance
+	if r.OrderBy == protobuf.ProductOrderField_PRODUCT_ORDER_FIELD_RATING {
+		orderBy = ProductOrderByRating
+	}
 
-	p, err := s.service.SearchProducts(ctx, r.Query, r.Offset, r.Limit)
+	p, err := s.service.SearchProducts(ctx, r.Query, filter, orderBy, r.Offset, r.Limit)
 	if err != nil {
 		s.logger.Error("Failed to search products", zap.String("query", r.Query), zap.String("error", err.Error()))
 		return &protobuf.SearchProductsResponse{
 			Error: err.Error(),
-		}, grpcResponseStatus.Errorf(grpcResponseCodes.Internal, err.Error())
+		}, productError(err)
 	}
 
 	var products []*protobuf.Product
 	for _, p := range p {
-		products = append(products, &protobuf.Product{
-			Id:          p.ID,
-			Name:        p.Name,
-			Description: p.Description,
-			Price:       p.Price,
-		})
+		products = append(products, productToProto(&p))
 	}
 
 	s.logger.Info("Products searched successfully", zap.Int("count", len(p)))
 	return &protobuf.SearchProductsResponse{Products: products}, nil
 }
+
+func (s *productGrpcServer) CreateCategory(ctx context.Context, r *protobuf.CreateCategoryRequest) (*protobuf.CreateCategoryResponse, error) {
+	s.logger.Info("CreateCategory request received", zap.String("name", r.Name), zap.String("parent_id", r.ParentId))
+
+	c, err := s.service.CreateCategory(ctx, r.Name, r.ParentId)
+	if err != nil {
+		s.logger.Error("Failed to create category", zap.String("name", r.Name), zap.String("error", err.Error()))
+		return &protobuf.CreateCategoryResponse{
+			Result: &protobuf.CreateCategoryResponse_Error{Error: err.Error()},
+		}, productError(err)
+	}
+
+	s.logger.Info("Category created successfully", zap.String("category_id", c.ID), zap.String("path", c.Path))
+
+	return &protobuf.CreateCategoryResponse{
+		Result: &protobuf.CreateCategoryResponse_Category{Category: categoryToProto(c)},
+	}, nil
+}
+
+func (s *productGrpcServer) RenameCategory(ctx context.Context, r *protobuf.RenameCategoryRequest) (*protobuf.RenameCategoryResponse, error) {
+	s.logger.Info("RenameCategory request received", zap.String("category_id", r.Id), zap.String("name", r.Name))
+
+	c, err := s.service.RenameCategory(ctx, r.Id, r.Name)
+	if err != nil {
+		s.logger.Error("Failed to rename category", zap.String("category_id", r.Id), zap.String("error", err.Error()))
+		return &protobuf.RenameCategoryResponse{
+			Result: &protobuf.RenameCategoryResponse_Error{Error: err.Error()},
+		}, productError(err)
+	}
+
+	s.logger.Info("Category renamed successfully", zap.String("category_id", c.ID), zap.String("path", c.Path))
+
+	return &protobuf.RenameCategoryResponse{
+		Result: &protobuf.RenameCategoryResponse_Category{Category: categoryToProto(c)},
+	}, nil
+}
+
+func (s *productGrpcServer) MoveCategory(ctx context.Context, r *protobuf.MoveCategoryRequest) (*protobuf.MoveCategoryResponse, error) {
+	s.logger.Info("MoveCategory request received", zap.String("category_id", r.Id), zap.String("parent_id", r.ParentId))
+
+	c, err := s.service.MoveCategory(ctx, r.Id, r.ParentId)
+	if err != nil {
+		s.logger.Error("Failed to move category", zap.String("category_id", r.Id), zap.String("error", err.Error()))
+		return &protobuf.MoveCategoryResponse{
+			Result: &protobuf.MoveCategoryResponse_Error{Error: err.Error()},
+		}, productError(err)
+	}
+
+	s.logger.Info("Category moved successfully", zap.String("category_id", c.ID), zap.String("path", c.Path))
+
+	return &protobuf.MoveCategoryResponse{
+		Result: &protobuf.MoveCategoryResponse_Category{Category: categoryToProto(c)},
+	}, nil
+}
+
+func (s *productGrpcServer) DeleteCategory(ctx context.Context, r *protobuf.DeleteCategoryRequest) (*protobuf.DeleteCategoryResponse, error) {
+	s.logger.Info("DeleteCategory request received", zap.String("category_id", r.Id))
+
+	c, err := s.service.DeleteCategory(ctx, r.Id)
+	if err != nil {
+		s.logger.Error("Failed to delete category", zap.String("category_id", r.Id), zap.String("error", err.Error()))
+		return &protobuf.DeleteCategoryResponse{
+			Result: &protobuf.DeleteCategoryResponse_Error{Error: err.Error()},
+		}, productError(err)
+	}
+
+	s.logger.Info("Category deleted successfully", zap.String("category_id", c.ID), zap.String("path", c.Path))
+
+	return &protobuf.DeleteCategoryResponse{
+		Result: &protobuf.DeleteCategoryResponse_Category{Category: categoryToProto(c)},
+	}, nil
+}
+
+func (s *productGrpcServer) ListCategories(ctx context.Context, r *protobuf.ListCategoriesRequest) (*protobuf.ListCategoriesResponse, error) {
+	s.logger.Info("ListCategories request received")
+
+	c, err := s.service.ListCategories(ctx)
+	if err != nil {
+		s.logger.Error("Failed to list categories", zap.String("error", err.Error()))
+		return &protobuf.ListCategoriesResponse{
+			Error: err.Error(),
+		}, productError(err)
+	}
+
+	var categories []*protobuf.Category
+	for _, c := range c {
+		categories = append(categories, categoryToProto(&c))
+	}
+
+	s.logger.Info("Categories listed successfully", zap.Int("count", len(c)))
+	return &protobuf.ListCategoriesResponse{Categories: categories}, nil
+}
+
+func (s *productGrpcServer) SetProductCategories(ctx context.Context, r *protobuf.SetProductCategoriesRequest) (*protobuf.SetProductCategoriesResponse, error) {
+	s.logger.Info("SetProductCategories request received", zap.String("product_id", r.ProductId), zap.Strings("category_ids", r.CategoryIds))
+
+	p, err := s.service.SetProductCategories(ctx, r.ProductId, r.CategoryIds)
+	if err != nil {
+		s.logger.Error("Failed to set product categories", zap.String("product_id", r.ProductId), zap.String("error", err.Error()))
+		return &protobuf.SetProductCategoriesResponse{
+			Result: &protobuf.SetProductCategoriesResponse_Error{Error: err.Error()},
+		}, productError(err)
+	}
+
+	s.logger.Info("Product categories set successfully", zap.String("product_id", p.ID), zap.Int("category_count", len(p.Categories)))
+
+	return &protobuf.SetProductCategoriesResponse{
+		Result: &protobuf.SetProductCategoriesResponse_Product{Product: productToProto(p)},
+	}, nil
+}
+
+func (s *productGrpcServer) ListProductsInCategory(ctx context.Context, r *protobuf.ListProductsInCategoryRequest) (*protobuf.ListProductsInCategoryResponse, error) {
+	s.logger.Info("ListProductsInCategory request received", zap.String("category_id", r.CategoryId), zap.Uint32("offset", r.Offset), zap.Uint32("limit", r.Limit))
+
+	p, err := s.service.ListProductsInCategory(ctx, r.CategoryId, r.Offset, r.Limit)
+	if err != nil {
+		s.logger.Error("Failed to list products in category", zap.String("category_id", r.CategoryId), zap.String("error", err.Error()))
+		return &protobuf.ListProductsInCategoryResponse{
+			Error: err.Error(),
+		}, productError(err)
+	}
+
+	var products []*protobuf.Product
+	for _, p := range p {
+		products = append(products, productToProto(&p))
+	}
+
+	s.logger.Info("Products in category listed successfully", zap.Int("count", len(p)))
+	return &protobuf.ListProductsInCategoryResponse{Products: products}, nil
+}
+
+func (s *productGrpcServer) SubmitReview(ctx context.Context, r *protobuf.SubmitReviewRequest) (*protobuf.SubmitReviewResponse, error) {
+	s.logger.Info("SubmitReview request received", zap.String("product_id", r.ProductId), zap.String("account_id", r.AccountId), zap.Int32("rating", r.Rating))
+
+	review, err := s.service.SubmitReview(ctx, r.ProductId, r.AccountId, r.Rating, r.Text)
+	if err != nil {
+		s.logger.Error("Failed to submit review", zap.String("product_id", r.ProductId), zap.String("account_id", r.AccountId), zap.String("error", err.Error()))
+		return &protobuf.SubmitReviewResponse{
+			Result: &protobuf.SubmitReviewResponse_Error{Error: err.Error()},
+		}, productError(err)
+	}
+
+	s.logger.Info("Review submitted successfully", zap.String("product_id", review.ProductID), zap.String("account_id", review.AccountID))
+
+	return &protobuf.SubmitReviewResponse{
+		Result: &protobuf.SubmitReviewResponse_Review{Review: reviewToProto(review)},
+	}, nil
+}
+
+func (s *productGrpcServer) ListReviews(ctx context.Context, r *protobuf.ListReviewsRequest) (*protobuf.ListReviewsResponse, error) {
+	s.logger.Info("ListReviews request received", zap.String("product_id", r.ProductId), zap.Uint32("offset", r.Offset), zap.Uint32("limit", r.Limit))
+
+	reviews, err := s.service.ListReviews(ctx, r.ProductId, r.Offset, r.Limit)
+	if err != nil {
+		s.logger.Error("Failed to list reviews", zap.String("product_id", r.ProductId), zap.String("error", err.Error()))
+		return &protobuf.ListReviewsResponse{
+			Error: err.Error(),
+		}, productError(err)
+	}
+
+	protoReviews := make([]*protobuf.Review, 0, len(reviews))
+	for _, review := range reviews {
+		protoReviews = append(protoReviews, reviewToProto(&review))
+	}
+
+	s.logger.Info("Reviews listed successfully", zap.Int("count", len(reviews)))
+	return &protobuf.ListReviewsResponse{Reviews: protoReviews}, nil
+}
+
+func (s *productGrpcServer) WatchProducts(r *protobuf.WatchProductsRequest, stream grpc.ServerStreamingServer[protobuf.ProductEvent]) error {
+	s.logger.Info("WatchProducts request received", zap.String("product_id", r.ProductId))
+
+	events, unsubscribe := s.service.WatchProducts()
+	defer unsubscribe()
+
+	for {
+		select {
+		case <-stream.Context().Done():
+			s.logger.Info("WatchProducts stream closed", zap.String("product_id", r.ProductId))
+			return nil
+		case event, ok := <-events:
+			if !ok {
+				s.logger.Warn("WatchProducts stream fell behind", zap.String("product_id", r.ProductId))
+				return grpcResponseStatus.Error(grpcResponseCodes.ResourceExhausted, "watcher fell behind")
+			}
+			if r.ProductId != "" && event.Product.ID != r.ProductId {
+				continue
+			}
+			if err := stream.Send(productEventToProto(&event)); err != nil {
+				s.logger.Error("Failed to send product event", zap.String("product_id", event.Product.ID), zap.String("error", err.Error()))
+				return err
+			}
+		}
+	}
+}
+
+func productError(err error) error {
+	var validationErr *common.ValidationError
+	if errors.As(err, &validationErr) {
+		return validationErr.GRPCStatus().Err()
+	}
+	if errors.Is(err, ErrProductNotFound) || errors.Is(err, ErrCategoryNotFound) {
+		return grpcResponseStatus.Errorf(grpcResponseCodes.NotFound, err.Error())
+	}
+	if errors.Is(err, ErrCategoryHasChildren) {
+		return grpcResponseStatus.Errorf(grpcResponseCodes.FailedPrecondition, err.Error())
+	}
+	return grpcResponseStatus.Errorf(grpcResponseCodes.Internal, err.Error())
+}
+
+func productToProto(p *Product) *protobuf.Product {
+	categories := make([]*protobuf.Category, 0, len(p.Categories))
+	for _, c := range p.Categories {
+		categories = append(categories, categoryToProto(&c))
+	}
+
+	variants := make([]*protobuf.ProductVariant, 0, len(p.Variants))
+	for _, v := range p.Variants {
+		variants = append(variants, variantToProto(v))
+	}
+
+	return &protobuf.Product{
+		Id:            p.ID,
+		Name:          p.Name,
+		Description:   p.Description,
+		Price:         &protobuf.Money{Amount: p.Price.Amount, CurrencyCode: p.Price.CurrencyCode},
+		Categories:    categories,
+		Variants:      variants,
+		AverageRating: p.AverageRating,
+		ReviewCount:   uint32(p.ReviewCount),
+	}
+}
+
+func reviewToProto(r *Review) *protobuf.Review {
+	return &protobuf.Review{
+		ProductId: r.ProductID,
+		AccountId: r.AccountID,
+		Rating:    r.Rating,
+		Text:      r.Text,
+		CreatedAt: timestamppb.New(r.CreatedAt),
+		UpdatedAt: timestamppb.New(r.UpdatedAt),
+	}
+}
+
+func categoryToProto(c *Category) *protobuf.Category {
+	return &protobuf.Category{
+		Id:       c.ID,
+		Name:     c.Name,
+		ParentId: c.ParentID,
+		Path:     c.Path,
+	}
+}
+
+func variantToProto(v Variant) *protobuf.ProductVariant {
+	attributes := make([]*protobuf.Attribute, 0, len(v.Attributes))
+	for _, a := range v.Attributes {
+		attributes = append(attributes, &protobuf.Attribute{Key: a.Key, Value: a.Value})
+	}
+
+	return &protobuf.ProductVariant{
+		Sku:        v.SKU,
+		Price:      &protobuf.Money{Amount: v.Price.Amount, CurrencyCode: v.Price.CurrencyCode},
+		Attributes: attributes,
+	}
+}
+
+func variantFromProto(v *protobuf.ProductVariant) Variant {
+	attributes := make([]Attribute, 0, len(v.GetAttributes()))
+	for _, a := range v.GetAttributes() {
+		attributes = append(attributes, Attribute{Key: a.GetKey(), Value: a.GetValue()})
+	}
+
+	return Variant{
+		SKU: v.GetSku(),
+		Price: common.Money{
+			Amount:       v.GetPrice().GetAmount(),
+			CurrencyCode: v.GetPrice().GetCurrencyCode(),
+		},
+		Attributes: attributes,
+	}
+}
+
+func productEventToProto(e *ProductEvent) *protobuf.ProductEvent {
+	eventType := protobuf.ProductEventType_PRODUCT_EVENT_TYPE_CREATED
+	if e.Type == ProductEventUpdated {
+		eventType = protobuf.ProductEventType_PRODUCT_EVENT_TYPE_UPDATED
+	}
+	return &protobuf.ProductEvent{Type: eventType, Product: productToProto(&e.Product)}
+}
@@ -1,25 +1,103 @@
 package product
 
-import "context"
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"graphql-grpc-go-microservice-project/common"
+
+	"go.uber.org/zap"
+)
 
 type ProductService interface {
-	CreateProduct(ctx context.Context, name, description string, price float64) (*Product, error)
+	CreateProduct(ctx context.Context, name, description string, price common.Money, variants []Variant) (*Product, error)
 	GetProductByID(ctx context.Context, id string) (*Product, error)
+	GetProductBySKU(ctx context.Context, sku string) (*Product, error)
 	ListProducts(ctx context.Context, limit, offset uint32) ([]Product, error)
 	ListProductsWithIDs(ctx context.Context, ids []string) ([]Product, error)
-	SearchProducts(ctx context.Context, query string, limit, offset uint32) ([]Product, error)
+	SearchProducts(ctx context.Context, query string, filter ProductSearchFilter, orderBy ProductOrderField, limit, offset uint32) ([]Product, error)
+	SetProductCategories(ctx context.Context, productID string, categoryIDs []string) (*Product, error)
+	ListProductsInCategory(ctx context.Context, categoryID string, offset, limit uint32) ([]Product, error)
+	CreateCategory(ctx context.Context, name, parentID string) (*Category, error)
+	RenameCategory(ctx context.Context, id, name string) (*Category, error)
+	MoveCategory(ctx context.Context, id, parentID string) (*Category, error)
+	DeleteCategory(ctx context.Context, id string) (*Category, error)
+	ListCategories(ctx context.Context) ([]Category, error)
+	SubmitReview(ctx context.Context, productID, accountID string, rating int32, text string) (*Review, error)
+	ListReviews(ctx context.Context, productID string, offset, limit uint32) ([]Review, error)
+	WatchProducts() (<-chan ProductEvent, func())
 }
 
+// watchBufferSize is the number of events a WatchProducts stream may lag
+// behind before it is dropped.
+const watchBufferSize = 64
+
 type productService struct {
 	repository ProductRepository
+	events     *common.Broadcaster[ProductEvent]
+	publisher  common.EventPublisher
+	logger     *zap.Logger
 }
 
-func NewProductService(repository ProductRepository) (ProductService, error) {
-	return &productService{repository: repository}, nil
+func NewProductService(repository ProductRepository, publisher common.EventPublisher) (ProductService, error) {
+	return &productService{
+		repository: repository,
+		events:     common.NewBroadcaster[ProductEvent](),
+		publisher:  publisher,
+		logger:     common.GetLogger(),
+	}, nil
+}
+
+func (service *productService) CreateProduct(ctx context.Context, name, description string, price common.Money, variants []Variant) (*Product, error) {
+	name, description, price, variants, err := validateProductInput(name, description, price, variants)
+	if err != nil {
+		return nil, err
+	}
+
+	// The repository claims SKUs atomically, but products created before SKU
+	// claims existed are only found by searching.
+	taken := map[string]bool{}
+	for _, variant := range variants {
+		_, err := service.repository.GetProductBySKU(ctx, variant.SKU)
+		switch {
+		case err == nil:
+			taken[variant.SKU] = true
+		case !errors.Is(err, ErrProductNotFound):
+			return nil, err
+		}
+	}
+
+	var product *Product
+	if len(taken) == 0 {
+		product, err = service.repository.CreateProduct(ctx, name, description, price, variants)
+		var takenErr *SKUTakenError
+		if errors.As(err, &takenErr) {
+			for _, sku := range takenErr.SKUs {
+				taken[sku] = true
+			}
+		}
+	}
+	if len(taken) > 0 {
+		violations := &common.ValidationError{}
+		for i, variant := range variants {
+			if taken[variant.SKU] {
+				violations.Add(fmt.Sprintf("variants[%d].sku", i), "is already used by another product")
+			}
+		}
+		return nil, violations.Err()
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	service.publish(ctx, ProductEvent{Type: ProductEventCreated, Product: *product})
+	return product, nil
 }
 
-func (service *productService) CreateProduct(ctx context.Context, name, description string, price float64) (*Product, error) {
-	product, err := service.repository.CreateProduct(ctx, name, description, price)
+func (service *productService) GetProductByID(ctx context.Context, id string) (*Product, error) {
+	product, err := service.repository.GetProductByID(ctx, id)
 	if err == nil {
 		return product, nil
 	}
@@ -27,8 +105,8 @@ func (service *productService) CreateProduct(ctx context.Context, name, descript
 	return nil, err
 }
 
-func (service *productService) GetProductByID(ctx context.Context, id string) (*Product, error) {
-	product, err := service.repository.GetProductByID(ctx, id)
+func (service *productService) GetProductBySKU(ctx context.Context, sku string) (*Product, error) {
+	product, err := service.repository.GetProductBySKU(ctx, strings.TrimSpace(sku))
 	if err == nil {
 		return product, nil
 	}
@@ -54,11 +132,272 @@ func (service *productService) ListProductsWithIDs(ctx context.Context, ids []st
 	return nil, err
 }
 
-func (service *productService) SearchProducts(ctx context.Context, query string, limit, offset uint32) ([]Product, error) {
-	products, err := service.repository.SearchProducts(ctx, query, limit, offset)
+func (service *productService) SearchProducts(ctx context.Context, query string, filter ProductSearchFilter, orderBy ProductOrderField, limit, offset uint32) ([]Product, error) {
+	filter, err := validateSearchFilter(filter)
+	if err != nil {
+		return nil, err
+	}
+
+	products, err := service.repository.SearchProducts(ctx, query, filter, orderBy, limit, offset)
 	if err == nil {
 		return products, nil
 	}
 
 	return nil, err
 }
+
+func (service *productService) SetProductCategories(ctx context.Context, productID string, categoryIDs []string) (*Product, error) {
+	tree, err := service.categoryTree(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	violations := &common.ValidationError{}
+	seen := map[string]bool{}
+	ids := []string{}
+	for _, id := range categoryIDs {
+		if _, ok := tree.categories[id]; !ok {
+			violations.Add("categoryIds", "unknown category "+id)
+			continue
+		}
+		if !seen[id] {
+			seen[id] = true
+			ids = append(ids, id)
+		}
+	}
+	if err := violations.Err(); err != nil {
+		return nil, err
+	}
+
+	product, err := service.repository.SetProductCategories(ctx, productID, tree.resolveAll(ids))
+	if err != nil {
+		return nil, err
+	}
+
+	service.publish(ctx, ProductEvent{Type: ProductEventUpdated, Product: *product})
+	return product, nil
+}
+
+func (service *productService) ListProductsInCategory(ctx context.Context, categoryID string, offset, limit uint32) ([]Product, error) {
+	if uint64(offset)+uint64(limit) > maxResultWindow {
+		return nil, fieldError("pagination", "offset and limit must add up to at most 10000")
+	}
+	return service.repository.ListProductsInCategory(ctx, categoryID, offset, limit)
+}
+
+func (service *productService) CreateCategory(ctx context.Context, name, parentID string) (*Category, error) {
+	name, err := validateCategoryName(name)
+	if err != nil {
+		return nil, err
+	}
+
+	tree, err := service.categoryTree(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if parentID != "" {
+		if _, ok := tree.categories[parentID]; !ok {
+			return nil, fieldError("parentId", "must reference an existing category")
+		}
+	}
+
+	category, err := service.repository.CreateCategory(ctx, name, parentID)
+	if err != nil {
+		return nil, err
+	}
+
+	tree.categories[category.ID] = *category
+	resolved, _ := tree.resolve(category.ID)
+	return &resolved, nil
+}
+
+func (service *productService) RenameCategory(ctx context.Context, id, name string) (*Category, error) {
+	name, err := validateCategoryName(name)
+	if err != nil {
+		return nil, err
+	}
+
+	return service.updateCategory(ctx, id, func(category *Category, _ *categoryTree) error {
+		category.Name = name
+		return nil
+	})
+}
+
+func (service *productService) MoveCategory(ctx context.Context, id, parentID string) (*Category, error) {
+	return service.updateCategory(ctx, id, func(category *Category, tree *categoryTree) error {
+		if parentID != "" {
+			if _, ok := tree.categories[parentID]; !ok {
+				return fieldError("parentId", "must reference an existing category")
+			}
+			if parentID == id || tree.isDescendant(parentID, id) {
+				return fieldError("parentId", "must not be the category itself or one of its subcategories")
+			}
+		}
+		category.ParentID = parentID
+		return nil
+	})
+}
+
+func (service *productService) DeleteCategory(ctx context.Context, id string) (*Category, error) {
+	tree, err := service.categoryTree(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	category, ok := tree.resolve(id)
+	if !ok {
+		return nil, ErrCategoryNotFound
+	}
+	if tree.hasChildren(id) {
+		return nil, ErrCategoryHasChildren
+	}
+
+	// Collect the products before the category disappears from the index,
+	// since they are found through its ID.
+	products, err := service.productsInCategory(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := service.repository.DeleteCategory(ctx, id); err != nil {
+		return nil, err
+	}
+
+	delete(tree.categories, id)
+	if err := service.refreshProductCategories(ctx, tree, products); err != nil {
+		return nil, err
+	}
+
+	return &category, nil
+}
+
+func (service *productService) ListCategories(ctx context.Context) ([]Category, error) {
+	tree, err := service.categoryTree(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return tree.all(), nil
+}
+
+func (service *productService) categoryTree(ctx context.Context) (*categoryTree, error) {
+	categories, err := service.repository.ListCategories(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return newCategoryTree(categories), nil
+}
+
+// updateCategory applies change to a category and rewrites the category paths
+// stored on every product filed under it or one of its subcategories.
+func (service *productService) updateCategory(ctx context.Context, id string, change func(*Category, *categoryTree) error) (*Category, error) {
+	tree, err := service.categoryTree(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	category, ok := tree.categories[id]
+	if !ok {
+		return nil, ErrCategoryNotFound
+	}
+	if err := change(&category, tree); err != nil {
+		return nil, err
+	}
+
+	products, err := service.productsInCategory(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if _, err := service.repository.UpdateCategory(ctx, category); err != nil {
+		return nil, err
+	}
+
+	tree.categories[id] = category
+	if err := service.refreshProductCategories(ctx, tree, products); err != nil {
+		return nil, err
+	}
+
+	resolved, _ := tree.resolve(id)
+	return &resolved, nil
+}
+
+// productsInCategory loads every product filed under a category up front, so
+// that rewriting their categories does not change the products being read.
+func (service *productService) productsInCategory(ctx context.Context, categoryID string) ([]Product, error) {
+	return service.repository.ListAllProductsInCategory(ctx, categoryID)
+}
+
+func (service *productService) refreshProductCategories(ctx context.Context, tree *categoryTree, products []Product) error {
+	for _, product := range products {
+		ids := []string{}
+		for _, category := range product.Categories {
+			ids = append(ids, category.ID)
+		}
+
+		updated, err := service.repository.SetProductCategories(ctx, product.ID, tree.resolveAll(ids))
+		if err != nil {
+			return err
+		}
+		service.publish(ctx, ProductEvent{Type: ProductEventUpdated, Product: *updated})
+	}
+	return nil
+}
+
+// SubmitReview stores the account's review of a product, replacing any
+// earlier one, and updates the product's average rating and review count.
+func (service *productService) SubmitReview(ctx context.Context, productID, accountID string, rating int32, text string) (*Review, error) {
+	review, err := validateReview(Review{ProductID: productID, AccountID: accountID, Rating: rating, Text: text})
+	if err != nil {
+		return nil, err
+	}
+
+	if _, err := service.repository.GetProductByID(ctx, review.ProductID); err != nil {
+		if errors.Is(err, ErrProductNotFound) {
+			return nil, fieldError("productId", "must reference an existing product")
+		}
+		return nil, err
+	}
+
+	submitted, err := service.repository.SubmitReview(ctx, review)
+	if err != nil {
+		return nil, err
+	}
+
+	product, err := service.repository.RefreshProductRating(ctx, review.ProductID)
+	if err != nil {
+		return nil, err
+	}
+
+	service.publish(ctx, ProductEvent{Type: ProductEventUpdated, Product: *product})
+	return submitted, nil
+}
+
+func (service *productService) ListReviews(ctx context.Context, productID string, offset, limit uint32) ([]Review, error) {
+	return service.repository.ListReviews(ctx, productID, offset, limit)
+}
+
+// publish notifies the WatchProducts streams and hands the event to the
+// publisher under the product.created or product.updated topic, which should
+// be a common.AsyncPublisher so that writes do not wait for the event bus.
+// Elasticsearch offers no transaction to share with an outbox, so bus
+// delivery is best effort: failures are logged and do not fail the write.
+func (service *productService) publish(ctx context.Context, event ProductEvent) {
+	service.events.Publish(event)
+
+	busEvent, err := common.NewEvent("product."+string(event.Type), event.Product.ID, event.Product)
+	if err == nil {
+		err = service.publisher.Publish(ctx, busEvent)
+	}
+	if err != nil {
+		service.logger.Error("Failed to publish product event", zap.String("product_id", event.Product.ID), zap.String("type", string(event.Type)), zap.String("error", err.Error()))
+	}
+}
+
+// WatchProducts subscribes to the products created and updated by this
+// instance of the service. The channel is closed when the subscriber falls
+// behind by more than watchBufferSize events.
+func (service *productService) WatchProducts() (<-chan ProductEvent, func()) {
+	return service.events.Subscribe(watchBufferSize)
+}
@@ -0,0 +1,175 @@
+package product
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// skuIndex holds one claim document per SKU, keyed by the SKU. Elasticsearch
+// only creates a document whose ID is not taken yet, so two products created
+// at the same time cannot both claim a SKU.
+const skuIndex = "skus"
+
+const skuMappingProperties = `{
+	"properties": {
+		"product_id": {"type": "keyword"},
+		"claimed_at": {"type": "date"}
+	}
+}`
+
+// staleSKUClaimAge is how long a claim may exist without its product before
+// another product can take the SKU over. It frees the SKUs of products whose
+// creation failed after claiming them.
+const staleSKUClaimAge = time.Minute
+
+type skuClaimDocument struct {
+	ProductID string    `json:"product_id"`
+	ClaimedAt time.Time `json:"claimed_at"`
+}
+
+// SKUTakenError lists the SKUs of a new product that are used by another
+// product.
+type SKUTakenError struct {
+	SKUs []string
+}
+
+func (e *SKUTakenError) Error() string {
+	return "skus already used by another product: " + strings.Join(e.SKUs, ", ")
+}
+
+// claimSKUs claims skus for productID. When another product holds any of
+// them, it releases the ones it claimed and returns a SKUTakenError.
+func (r *elasticRepository) claimSKUs(ctx context.Context, productID string, skus []string) error {
+	claimed := []string{}
+	taken := []string{}
+	for _, sku := range skus {
+		ok, err := r.claimSKU(ctx, productID, sku)
+		if err != nil {
+			r.releaseSKUs(claimed)
+			return err
+		}
+		if ok {
+			claimed = append(claimed, sku)
+		} else {
+			taken = append(taken, sku)
+		}
+	}
+
+	if len(taken) > 0 {
+		r.releaseSKUs(claimed)
+		return &SKUTakenError{SKUs: taken}
+	}
+	return nil
+}
+
+func (r *elasticRepository) claimSKU(ctx context.Context, productID, sku string) (bool, error) {
+	body, err := json.Marshal(skuClaimDocument{ProductID: productID, ClaimedAt: time.Now().UTC()})
+	if err != nil {
+		return false, err
+	}
+
+	res, err := r.client.Create(
+		skuIndex,
+		sku,
+		bytes.NewReader(body),
+		r.client.Create.WithContext(ctx),
+	)
+	if err != nil {
+		return false, err
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode == http.StatusConflict {
+		return r.takeOverStaleSKUClaim(ctx, productID, sku)
+	}
+	if res.IsError() {
+		return false, fmt.Errorf("failed to claim sku %s: %s", sku, res.String())
+	}
+	return true, nil
+}
+
+// takeOverStaleSKUClaim replaces the claim on sku when it is older than
+// staleSKUClaimAge and its product does not exist. The replacement only
+// succeeds if the claim did not change in the meantime.
+func (r *elasticRepository) takeOverStaleSKUClaim(ctx context.Context, productID, sku string) (bool, error) {
+	res, err := r.client.Get(
+		skuIndex,
+		sku,
+		r.client.Get.WithContext(ctx),
+	)
+	if err != nil {
+		return false, err
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode == http.StatusNotFound {
+		return false, nil
+	}
+	if res.IsError() {
+		return false, fmt.Errorf("failed to get sku claim %s: %s", sku, res.String())
+	}
+
+	var claim struct {
+		SeqNo       int              `json:"_seq_no"`
+		PrimaryTerm int              `json:"_primary_term"`
+		Source      skuClaimDocument `json:"_source"`
+	}
+	if err := json.NewDecoder(res.Body).Decode(&claim); err != nil {
+		return false, err
+	}
+
+	if time.Since(claim.Source.ClaimedAt) < staleSKUClaimAge {
+		return false, nil
+	}
+	_, err = r.GetProductByID(ctx, claim.Source.ProductID)
+	if err == nil {
+		return false, nil
+	}
+	if !errors.Is(err, ErrProductNotFound) {
+		return false, err
+	}
+
+	body, err := json.Marshal(skuClaimDocument{ProductID: productID, ClaimedAt: time.Now().UTC()})
+	if err != nil {
+		return false, err
+	}
+
+	res, err = r.client.Index(
+		skuIndex,
+		bytes.NewReader(body),
+		r.client.Index.WithDocumentID(sku),
+		r.client.Index.WithIfSeqNo(claim.SeqNo),
+		r.client.Index.WithIfPrimaryTerm(claim.PrimaryTerm),
+		r.client.Index.WithContext(ctx),
+	)
+	if err != nil {
+		return false, err
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode == http.StatusConflict {
+		return false, nil
+	}
+	if res.IsError() {
+		return false, fmt.Errorf("failed to claim sku %s: %s", sku, res.String())
+	}
+	return true, nil
+}
+
+// releaseSKUs deletes claims that the product will not use after all. It is
+// best effort, as stale claims are taken over after staleSKUClaimAge anyway.
+func (r *elasticRepository) releaseSKUs(skus []string) {
+	for _, sku := range skus {
+		res, err := r.client.Delete(skuIndex, sku)
+		if err != nil {
+			continue
+		}
+		res.Body.Close()
+	}
+}
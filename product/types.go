@@ -1,17 +1,107 @@
 package product
 
+import (
+	"errors"
+	"time"
+
+	"graphql-grpc-go-microservice-project/common"
+)
+
+var (
+	ErrProductNotFound     = errors.New("product not found")
+	ErrCategoryNotFound    = errors.New("category not found")
+	ErrCategoryHasChildren = errors.New("category has subcategories")
+)
+
 type Product struct {
-	ID          string  `json:"id"`
-	Name        string  `json:"name"`
-	Description string  `json:"description"`
-	Price       float64 `json:"price"`
+	ID          string       `json:"id"`
+	Name        string       `json:"name"`
+	Description string       `json:"description"`
+	Price       common.Money `json:"price"`
+	Categories  []Category   `json:"categories"`
+	Variants    []Variant    `json:"variants"`
+	// AverageRating is 0 when the product has no reviews.
+	AverageRating float64 `json:"average_rating"`
+	ReviewCount   int32   `json:"review_count"`
+}
+
+// Variant is a purchasable version of a product, such as a size or color,
+// identified by a SKU that is unique across the catalog.
+type Variant struct {
+	SKU        string       `json:"sku"`
+	Price      common.Money `json:"price"`
+	Attributes []Attribute  `json:"attributes"`
+}
+
+type Attribute struct {
+	Key   string `json:"key"`
+	Value string `json:"value"`
+}
+
+type Category struct {
+	ID          string   `json:"id"`
+	Name        string   `json:"name"`
+	ParentID    string   `json:"parent_id,omitempty"`
+	Path        string   `json:"path"`
+	AncestorIDs []string `json:"ancestor_ids,omitempty"`
 }
 
+// Review is an account's rating of a product from 1 to 5 with an optional
+// text. An account has at most one review per product.
+type Review struct {
+	ProductID string    `json:"product_id"`
+	AccountID string    `json:"account_id"`
+	Rating    int32     `json:"rating"`
+	Text      string    `json:"text"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+type ProductSearchFilter struct {
+	// Attributes only match products with a single variant carrying all of
+	// them.
+	Attributes []Attribute
+	// MinRating excludes products whose average rating is lower, including
+	// products without reviews.
+	MinRating float64
+}
+
+type ProductOrderField string
+
+const (
+	ProductOrderByRelevance ProductOrderField = "relevance"
+	ProductOrderByRating    ProductOrderField = "rating"
+)
+
 type HitsTotal struct {
 	Value    int64  `json:"value"`
 	Relation string `json:"relation"`
 }
 
+type SearchHit struct {
+	ID     string          `json:"_id"`
+	Source productDocument `json:"_source"`
+}
+
 type SearchHits struct {
-	Total HitsTotal `json:"total"`
+	Total HitsTotal   `json:"total"`
+	Hits  []SearchHit `json:"hits"`
+}
+
+type SearchResponse struct {
+	Hits SearchHits `json:"hits"`
+}
+
+type ProductEventType string
+
+const (
+	ProductEventCreated ProductEventType = "created"
+	ProductEventUpdated ProductEventType = "updated"
+)
+
+// ProductEvent is published to the WatchProducts streams after a product
+// was written to Elasticsearch.
+type ProductEvent struct {
+	Type    ProductEventType
+	Product Product
 }
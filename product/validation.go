@@ -0,0 +1,161 @@
+package product
+
+import (
+	"fmt"
+	"strings"
+	"unicode/utf8"
+
+	"graphql-grpc-go-microservice-project/common"
+
+	"github.com/google/uuid"
+)
+
+const (
+	maxNameLength        = 255
+	maxDescriptionLength = 5000
+	maxSKULength         = 64
+	maxAttributeLength   = 100
+	maxReviewTextLength  = 5000
+	minRating            = 1
+	maxRating            = 5
+)
+
+// validateProductInput normalizes the name, description and variants of a
+// product and reports every field that is missing or out of range.
+func validateProductInput(name, description string, price common.Money, variants []Variant) (string, string, common.Money, []Variant, error) {
+	name = strings.TrimSpace(name)
+	description = strings.TrimSpace(description)
+
+	violations := &common.ValidationError{}
+
+	switch {
+	case name == "":
+		violations.Add("name", "must not be empty")
+	case utf8.RuneCountInString(name) > maxNameLength:
+		violations.Add("name", "must be at most 255 characters")
+	}
+
+	if utf8.RuneCountInString(description) > maxDescriptionLength {
+		violations.Add("description", "must be at most 5000 characters")
+	}
+
+	price = validatePrice(violations, "price", price)
+
+	skus := map[string]bool{}
+	for i, variant := range variants {
+		field := fmt.Sprintf("variants[%d]", i)
+
+		variant.SKU = strings.TrimSpace(variant.SKU)
+		switch {
+		case variant.SKU == "":
+			violations.Add(field+".sku", "must not be empty")
+		case len(variant.SKU) > maxSKULength:
+			violations.Add(field+".sku", "must be at most 64 characters")
+		case skus[variant.SKU]:
+			violations.Add(field+".sku", "must be unique")
+		}
+		skus[variant.SKU] = true
+
+		variant.Price = validatePrice(violations, field+".price", variant.Price)
+		variant.Attributes = validateAttributes(violations, field+".attributes", variant.Attributes)
+		variants[i] = variant
+	}
+
+	return name, description, price, variants, violations.Err()
+}
+
+func validatePrice(violations *common.ValidationError, field string, price common.Money) common.Money {
+	price.CurrencyCode = strings.ToUpper(strings.TrimSpace(price.CurrencyCode))
+	if _, ok := common.CurrencyExponent(price.CurrencyCode); !ok {
+		violations.Add(field+".currencyCode", "must be a supported ISO 4217 currency code")
+	}
+	if price.Amount < 0 {
+		violations.Add(field+".amount", "must be greater than or equal to 0")
+	}
+	return price
+}
+
+// validateAttributes trims attribute values and lower-cases their keys, so
+// that "Color" and "color" filter the same way.
+func validateAttributes(violations *common.ValidationError, field string, attributes []Attribute) []Attribute {
+	keys := map[string]bool{}
+	normalized := make([]Attribute, 0, len(attributes))
+	for i, attribute := range attributes {
+		attributeField := fmt.Sprintf("%s[%d]", field, i)
+
+		attribute.Key = strings.ToLower(strings.TrimSpace(attribute.Key))
+		attribute.Value = strings.TrimSpace(attribute.Value)
+		switch {
+		case attribute.Key == "":
+			violations.Add(attributeField+".key", "must not be empty")
+		case utf8.RuneCountInString(attribute.Key) > maxAttributeLength:
+			violations.Add(attributeField+".key", "must be at most 100 characters")
+		case keys[attribute.Key]:
+			violations.Add(attributeField+".key", "must be unique")
+		}
+		keys[attribute.Key] = true
+
+		switch {
+		case attribute.Value == "":
+			violations.Add(attributeField+".value", "must not be empty")
+		case utf8.RuneCountInString(attribute.Value) > maxAttributeLength:
+			violations.Add(attributeField+".value", "must be at most 100 characters")
+		}
+
+		normalized = append(normalized, attribute)
+	}
+	return normalized
+}
+
+func validateCategoryName(name string) (string, error) {
+	name = strings.TrimSpace(name)
+
+	violations := &common.ValidationError{}
+	switch {
+	case name == "":
+		violations.Add("name", "must not be empty")
+	case utf8.RuneCountInString(name) > maxNameLength:
+		violations.Add("name", "must be at most 255 characters")
+	case strings.Contains(name, strings.TrimSpace(categoryPathSeparator)):
+		violations.Add("name", "must not contain '>'")
+	}
+
+	return name, violations.Err()
+}
+
+func fieldError(field, description string) error {
+	violations := &common.ValidationError{}
+	violations.Add(field, description)
+	return violations.Err()
+}
+
+func validateSearchFilter(filter ProductSearchFilter) (ProductSearchFilter, error) {
+	violations := &common.ValidationError{}
+	filter.Attributes = validateAttributes(violations, "attributes", filter.Attributes)
+	if filter.MinRating < 0 || filter.MinRating > maxRating {
+		violations.Add("minRating", "must be between 0 and 5")
+	}
+	return filter, violations.Err()
+}
+
+func validateReview(review Review) (Review, error) {
+	review.ProductID = strings.TrimSpace(review.ProductID)
+	review.AccountID = strings.TrimSpace(review.AccountID)
+	review.Text = strings.TrimSpace(review.Text)
+
+	violations := &common.ValidationError{}
+	if review.ProductID == "" {
+		violations.Add("productId", "must not be empty")
+	}
+	if _, err := uuid.Parse(review.AccountID); err != nil {
+		violations.Add("accountId", "must be a valid UUID")
+	}
+	if review.Rating < minRating || review.Rating > maxRating {
+		violations.Add("rating", "must be between 1 and 5")
+	}
+	if utf8.RuneCountInString(review.Text) > maxReviewTextLength {
+		violations.Add("text", "must be at most 5000 characters")
+	}
+
+	return review, violations.Err()
+}